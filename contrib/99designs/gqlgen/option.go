@@ -15,10 +15,11 @@ import (
 type config struct {
 	serviceName                       string
 	analyticsRate                     float64
-	withoutTraceIntrospectionQuery    bool
+	introspectionSampleRate           float64
 	withoutTraceTrivialResolvedFields bool
 	tags                              map[string]interface{}
 	errExtensions                     []string
+	maxQueryLength                    int
 }
 
 // An Option describes options for the gqlgen integration.
@@ -36,6 +37,7 @@ func (fn OptionFn) apply(cfg *config) {
 func defaults(cfg *config) {
 	cfg.serviceName = instr.ServiceName(instrumentation.ComponentDefault, nil)
 	cfg.analyticsRate = instr.AnalyticsRate(false)
+	cfg.introspectionSampleRate = 1.0
 	cfg.tags = make(map[string]interface{})
 	cfg.errExtensions = instrgraphql.ErrorExtensionsFromEnv()
 }
@@ -63,9 +65,20 @@ func WithService(name string) OptionFn {
 }
 
 // WithoutTraceIntrospectionQuery skips creating spans for fields when the operation name is IntrospectionQuery.
+// It is equivalent to WithIntrospectionSampleRate(0).
 func WithoutTraceIntrospectionQuery() OptionFn {
+	return WithIntrospectionSampleRate(0)
+}
+
+// WithIntrospectionSampleRate sets the rate at which spans are created for fields when the
+// operation name is IntrospectionQuery. The decision is taken once per trace, so either all
+// or none of the field spans of a given introspection query are kept. The rate must be
+// between 0 and 1; other values are ignored. It defaults to 1, tracing every introspection query.
+func WithIntrospectionSampleRate(rate float64) OptionFn {
 	return func(cfg *config) {
-		cfg.withoutTraceIntrospectionQuery = true
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.introspectionSampleRate = rate
+		}
 	}
 }
 
@@ -77,6 +90,16 @@ func WithoutTraceTrivialResolvedFields() OptionFn {
 	}
 }
 
+// WithMaxQueryLength limits the length of the query used as the resource name of the
+// root span to n characters, replacing the rest of the query with "...". It doesn't
+// apply to other tags holding the query. A value of 0 or less disables the limit, which
+// is the default.
+func WithMaxQueryLength(n int) OptionFn {
+	return func(cfg *config) {
+		cfg.maxQueryLength = n
+	}
+}
+
 // WithCustomTag will attach the value to the span tagged by the key.
 func WithCustomTag(key string, value interface{}) OptionFn {
 	return func(cfg *config) {
@@ -17,6 +17,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"math/rand/v2"
 	"time"
 
 	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
@@ -26,6 +27,7 @@ import (
 	instrgraphql "github.com/DataDog/dd-trace-go/v2/instrumentation/graphql"
 
 	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
 	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/vektah/gqlparser/v2/gqlerror"
 )
@@ -48,10 +50,12 @@ const (
 	tagGraphqlField         = "graphql.field"
 	tagGraphqlOperationType = "graphql.operation.type"
 	tagGraphqlOperationName = "graphql.operation.name"
+	tagGraphqlPersistedHash = "graphql.persisted_query_hash"
 )
 
 type gqlTracer struct {
-	cfg *config
+	cfg                  *config
+	introspectionSampler tracer.RateSampler
 }
 
 // NewTracer creates a graphql.HandlerExtension instance that can be used with
@@ -63,7 +67,10 @@ func NewTracer(opts ...Option) graphql.HandlerExtension {
 	for _, fn := range opts {
 		fn.apply(cfg)
 	}
-	return &gqlTracer{cfg: cfg}
+	return &gqlTracer{
+		cfg:                  cfg,
+		introspectionSampler: tracer.NewRateSampler(cfg.introspectionSampleRate),
+	}
 }
 
 func (t *gqlTracer) ExtensionName() string {
@@ -119,7 +126,7 @@ func (t *gqlTracer) InterceptOperation(ctx context.Context, next graphql.Operati
 
 func (t *gqlTracer) InterceptField(ctx context.Context, next graphql.Resolver) (res any, err error) {
 	opCtx := graphql.GetOperationContext(ctx)
-	if t.cfg.withoutTraceIntrospectionQuery && isIntrospectionQuery(opCtx) {
+	if isIntrospectionQuery(opCtx) && !t.sampleIntrospection(ctx) {
 		res, err = next(ctx)
 		return
 	}
@@ -164,22 +171,47 @@ func (*gqlTracer) InterceptResponse(ctx context.Context, next graphql.ResponseHa
 	return next(ctx)
 }
 
+// truncateQuery returns query cut down to its first n characters, followed by "...",
+// if it is longer than that. A value of n of 0 or less leaves query unchanged.
+func truncateQuery(query string, n int) string {
+	if n <= 0 || len(query) <= n {
+		return query
+	}
+	var chars int
+	for i := range query {
+		if chars == n {
+			return query[:i] + "..."
+		}
+		chars++
+	}
+	return query
+}
+
 // createRootSpan creates a graphql server root span starting at the beginning
 // of the operation context. If the operation is a subscription, a nil span is
 // returned as those may run indefinitely and would be problematic. This function
 // also creates child spans (orphans in the case of a subscription) for the
 // read, parsing and validation phases of the operation.
 func (t *gqlTracer) createRootSpan(ctx context.Context, opCtx *graphql.OperationContext) (*tracer.Span, context.Context) {
-	opts := make([]tracer.StartSpanOption, 0, 7+len(t.cfg.tags))
+	opts := make([]tracer.StartSpanOption, 0, 8+len(t.cfg.tags))
 	for k, v := range t.cfg.tags {
 		opts = append(opts, tracer.Tag(k, v))
 	}
+	resource := truncateQuery(opCtx.RawQuery, t.cfg.maxQueryLength)
+	if apq := extension.GetApqStats(ctx); apq != nil && apq.Hash != "" {
+		// Clients using automatic persisted queries only send the hash of the query
+		// once it's registered, so it identifies the operation when the query is unknown.
+		opts = append(opts, tracer.Tag(tagGraphqlPersistedHash, apq.Hash))
+		if resource == "" {
+			resource = apq.Hash
+		}
+	}
 	opts = append(opts,
 		tracer.SpanType(ext.SpanTypeGraphQL),
 		tracer.Tag(ext.SpanKind, ext.SpanKindServer),
 		tracer.ServiceName(t.cfg.serviceName),
 		tracer.Tag(ext.Component, componentName),
-		tracer.ResourceName(opCtx.RawQuery),
+		tracer.ResourceName(resource),
 		tracer.StartTime(opCtx.Stats.OperationStart),
 	)
 	if !math.IsNaN(t.cfg.analyticsRate) {
@@ -224,6 +256,23 @@ func serverSpanName(octx *graphql.OperationContext) string {
 		})
 }
 
+// sampleIntrospection reports whether the fields of an introspection query should be traced,
+// according to the configured introspection sample rate. The decision is made by the tracer's
+// rate sampler from the trace ID of the active span, so that it is consistent for all the
+// fields of a query, and with the sampling decisions of the tracer and the agent.
+func (t *gqlTracer) sampleIntrospection(ctx context.Context) bool {
+	rate := t.introspectionSampler.Rate()
+	if rate >= 1 {
+		return true
+	}
+	span, ok := tracer.SpanFromContext(ctx)
+	if !ok {
+		// No parent span to derive the decision from (e.g. subscriptions).
+		return rate > 0 && rand.Float64() < rate
+	}
+	return t.introspectionSampler.Sample(span)
+}
+
 func isIntrospectionQuery(octx *graphql.OperationContext) bool {
 	if octx.Operation != nil {
 		return octx.Operation.Name == "IntrospectionQuery"
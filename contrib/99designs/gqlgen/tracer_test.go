@@ -6,10 +6,14 @@
 package gqlgen
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"testing"
 
 	"github.com/99designs/gqlgen/client"
 	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
 	"github.com/99designs/gqlgen/graphql/handler/testserver"
 	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/stretchr/testify/assert"
@@ -78,6 +82,18 @@ func TestOptions(t *testing.T) {
 				assert.Equal(false, hasFieldOperation)
 			},
 		},
+		"WithMaxQueryLength": {
+			tracerOpts: []Option{WithMaxQueryLength(4)},
+			test: func(assert *assert.Assertions, root *mocktracer.Span, _ []*mocktracer.Span) {
+				assert.Equal("{ na...", root.Tag(ext.ResourceName))
+			},
+		},
+		"WithMaxQueryLength/longer": {
+			tracerOpts: []Option{WithMaxQueryLength(len(query))},
+			test: func(assert *assert.Assertions, root *mocktracer.Span, _ []*mocktracer.Span) {
+				assert.Equal(query, root.Tag(ext.ResourceName))
+			},
+		},
 		"WithCustomTag": {
 			tracerOpts: []Option{
 				WithCustomTag("customTag1", "customValue1"),
@@ -135,6 +151,25 @@ func TestOptions(t *testing.T) {
 			clientOpts: []client.Option{},
 			test:       testFunc,
 		},
+		"WithIntrospectionSampleRate zero": {
+			tracerOpts: []Option{WithIntrospectionSampleRate(0)},
+			clientOpts: []client.Option{client.Operation("IntrospectionQuery")},
+			test:       testFunc,
+		},
+		"WithIntrospectionSampleRate one": {
+			tracerOpts: []Option{WithIntrospectionSampleRate(1)},
+			clientOpts: []client.Option{client.Operation("IntrospectionQuery")},
+			test: func(assert *assert.Assertions, spans []*mocktracer.Span) {
+				var hasFieldSpan bool
+				for _, span := range spans {
+					if span.OperationName() == fieldOp {
+						hasFieldSpan = true
+						break
+					}
+				}
+				assert.True(hasFieldSpan)
+			},
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			assert := assert.New(t)
@@ -147,6 +182,41 @@ func TestOptions(t *testing.T) {
 	}
 }
 
+func TestIntrospectionSampleRate(t *testing.T) {
+	const (
+		query   = `query IntrospectionQuery { __schema { queryType { name } } }`
+		queries = 200
+	)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	c := newTestClient(t, testserver.New(), NewTracer(WithIntrospectionSampleRate(0.5)))
+	for range queries {
+		c.MustPost(query, &testServerResponse{}, client.Operation("IntrospectionQuery"))
+	}
+
+	var roots int
+	fields := make(map[uint64]int) // number of field spans by sampled trace
+	for _, span := range mt.FinishedSpans() {
+		switch {
+		case span.ParentID() == 0:
+			roots++
+		case span.OperationName() == fieldOp:
+			fields[span.TraceID()]++
+		}
+	}
+	require.Equal(t, queries, roots)
+	var want int
+	for _, n := range fields {
+		// the decision is made once per trace, so all the fields of a query are traced
+		if want == 0 {
+			want = n
+		}
+		assert.Equal(t, want, n)
+	}
+	sampled := len(fields)
+	assert.InDelta(t, queries/2, sampled, queries/5)
+}
+
 func TestError(t *testing.T) {
 	assert := assert.New(t)
 	mt := mocktracer.Start()
@@ -224,6 +294,61 @@ func TestChildSpans(t *testing.T) {
 	assert.Zero(root.Tag(ext.ErrorMsg))
 }
 
+func TestPersistedQueryHash(t *testing.T) {
+	query := `{ name }`
+	sum := sha256.Sum256([]byte(query))
+	hash := hex.EncodeToString(sum[:])
+	withPersistedQuery := func(r *client.Request) {
+		r.Extensions = map[string]any{
+			"persistedQuery": map[string]any{"version": 1, "sha256Hash": hash},
+		}
+	}
+	srv := testserver.New()
+	srv.Use(extension.AutomaticPersistedQuery{Cache: lru.New(10)})
+	c := newTestClient(t, srv, NewTracer())
+
+	rootSpan := func(mt mocktracer.Tracer) *mocktracer.Span {
+		for _, span := range mt.FinishedSpans() {
+			if span.ParentID() == 0 {
+				return span
+			}
+		}
+		return nil
+	}
+
+	t.Run("register", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		c.MustPost(query, &testServerResponse{}, withPersistedQuery)
+
+		root := rootSpan(mt)
+		require.NotNil(t, root)
+		assert.Equal(t, hash, root.Tag(tagGraphqlPersistedHash))
+		assert.Equal(t, query, root.Tag(ext.ResourceName))
+	})
+
+	t.Run("hash only", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		c.MustPost("", &testServerResponse{}, withPersistedQuery)
+
+		root := rootSpan(mt)
+		require.NotNil(t, root)
+		assert.Equal(t, hash, root.Tag(tagGraphqlPersistedHash))
+		assert.Equal(t, query, root.Tag(ext.ResourceName))
+	})
+
+	t.Run("no persisted query", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		c.MustPost(query, &testServerResponse{})
+
+		root := rootSpan(mt)
+		require.NotNil(t, root)
+		assert.Nil(t, root.Tag(tagGraphqlPersistedHash))
+	})
+}
+
 func newTestClient(t *testing.T, h *testserver.TestServer, tracer graphql.HandlerExtension) *client.Client {
 	t.Helper()
 	h.AddTransport(transport.POST{})
@@ -340,7 +340,7 @@ func (mw *traceMiddleware) deserializeTraceMiddleware(stack *middleware.Stack) e
 			url := *req.URL
 			url.User = nil // Do not include userinfo in the HTTPURL tag.
 			span.SetTag(ext.HTTPMethod, req.Method)
-			span.SetTag(ext.HTTPURL, url.String())
+			span.SetTag(ext.HTTPURL, instr.SanitizeURL(url.String()))
 			span.SetTag(ext.AWSAgent, req.Header.Get("User-Agent"))
 		}
 
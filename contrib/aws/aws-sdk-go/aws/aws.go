@@ -91,7 +91,7 @@ func (h *handlers) Send(req *request.Request) {
 		tracer.Tag(ext.AWSRegion, region),
 		tracer.Tag(ext.AWSService, awsService(req)),
 		tracer.Tag(ext.HTTPMethod, req.Operation.HTTPMethod),
-		tracer.Tag(ext.HTTPURL, url.String()),
+		tracer.Tag(ext.HTTPURL, instr.SanitizeURL(url.String())),
 		tracer.Tag(ext.Component, componentName),
 		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
 	}
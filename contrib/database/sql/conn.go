@@ -347,7 +347,7 @@ func (tp *traceParams) tryTrace(ctx context.Context, qtype QueryType, query stri
 	span, _ := tracer.StartSpanFromContext(ctx, tp.cfg.spanName, opts...)
 	resource := string(qtype)
 	if query != "" {
-		resource = query
+		resource = instr.ObfuscateSQL(query)
 	}
 
 	span.SetTag("sql.query_type", string(qtype))
@@ -64,7 +64,7 @@ func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 		tracer.ResourceName(resource),
 		tracer.Tag("elasticsearch.method", method),
 		tracer.Tag("elasticsearch.url", url),
-		tracer.Tag("elasticsearch.params", req.URL.Query().Encode()),
+		tracer.Tag("elasticsearch.params", instr.SanitizeQuery(req.URL.Query().Encode())),
 		tracer.Tag(ext.Component, componentName),
 		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
 		tracer.Tag(ext.DBSystem, ext.DBSystemElasticsearch),
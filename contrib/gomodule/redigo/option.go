@@ -16,6 +16,7 @@ type dialConfig struct {
 	spanName       string
 	analyticsRate  float64
 	connectionType int
+	hashScripts    bool
 }
 
 const (
@@ -95,3 +96,13 @@ func WithDefaultConnection() DialOptionFn {
 		cfg.connectionType = connectionTypeDefault
 	}
 }
+
+// WithScriptHashing sets whether the Lua script body sent with EVAL commands is hashed to
+// tag their spans with redis.script_sha, the same digest used by EVALSHA, so that both forms
+// of a script can be grouped together. It is disabled by default since it costs a SHA1 digest
+// of the script for every EVAL command. EVALSHA commands are always tagged.
+func WithScriptHashing(enabled bool) DialOptionFn {
+	return func(cfg *dialConfig) {
+		cfg.hashScripts = enabled
+	}
+}
@@ -9,11 +9,16 @@ package redigo
 import (
 	"bytes"
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"math"
 	"net"
 	"net/url"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
@@ -55,6 +60,14 @@ type params struct {
 	network string
 	host    string
 	port    string
+	// db holds the index of the database selected on the connection, or -1 when unknown.
+	db atomic.Int64
+}
+
+func newParams(cfg *dialConfig, network, host, port string, db int64) *params {
+	p := &params{config: cfg, network: network, host: host, port: port}
+	p.db.Store(db)
+	return p
 }
 
 // parseOptions parses a set of arbitrary options (which can be of type redis.DialOption
@@ -105,7 +118,7 @@ func Dial(network, address string, options ...interface{}) (redis.Conn, error) {
 	if err != nil {
 		return nil, err
 	}
-	tc := wrapConn(c, &params{cfg, network, host, port})
+	tc := wrapConn(c, newParams(cfg, network, host, port, -1))
 	return tc, nil
 }
 
@@ -122,7 +135,7 @@ func DialContext(ctx context.Context, network, address string, options ...interf
 	if err != nil {
 		return nil, err
 	}
-	tc := wrapConn(c, &params{cfg, network, host, port})
+	tc := wrapConn(c, newParams(cfg, network, host, port, -1))
 	return tc, nil
 }
 
@@ -146,8 +159,15 @@ func DialURL(rawurl string, options ...interface{}) (redis.Conn, error) {
 		host = "localhost"
 	}
 	network := "tcp"
+	db := int64(-1)
+	if path := strings.Trim(u.Path, "/"); path != "" {
+		// the database selected by redis.DialURL
+		if n, err := strconv.ParseInt(path, 10, 64); err == nil {
+			db = n
+		}
+	}
 	c, err := redis.DialURL(rawurl, dialOpts...)
-	tc := wrapConn(c, &params{cfg, network, host, port})
+	tc := wrapConn(c, newParams(cfg, network, host, port, db))
 	return tc, err
 }
 
@@ -167,9 +187,90 @@ func newChildSpan(ctx context.Context, p *params) *tracer.Span {
 	span.SetTag("out.network", p.network)
 	span.SetTag(ext.TargetPort, p.port)
 	span.SetTag(ext.TargetHost, p.host)
+	if db := p.db.Load(); db >= 0 {
+		span.SetTag(ext.TargetDB, strconv.FormatInt(db, 10))
+	}
 	return span
 }
 
+// selectedDB returns the index of the database selected by the command, if it is a
+// SELECT command.
+func selectedDB(commandName string, args []interface{}) (int64, bool) {
+	if !strings.EqualFold(commandName, "SELECT") || len(args) != 1 {
+		return 0, false
+	}
+	var (
+		db  int64
+		err error
+	)
+	switch arg := args[0].(type) {
+	case int:
+		db = int64(arg)
+	case int32:
+		db = int64(arg)
+	case int64:
+		db = arg
+	case string:
+		db, err = strconv.ParseInt(arg, 10, 64)
+	case []byte:
+		db, err = strconv.ParseInt(string(arg), 10, 64)
+	default:
+		return 0, false
+	}
+	return db, err == nil && db >= 0
+}
+
+// scriptInfo returns the SHA1 digest of the Lua script run by an EVAL or EVALSHA command
+// (or their read-only variants) and the number of keys passed to it. The script body of
+// EVAL is only hashed when hashBody is true, in which case the digest matches the one
+// reported by SCRIPT LOAD. An empty sha, or a negative numKeys, is returned when it can't
+// be determined.
+func scriptInfo(commandName string, args []interface{}, hashBody bool) (sha string, numKeys int64, ok bool) {
+	var isEval bool
+	switch strings.ToUpper(commandName) {
+	case "EVAL", "EVAL_RO":
+		isEval = true
+	case "EVALSHA", "EVALSHA_RO":
+	default:
+		return "", 0, false
+	}
+	if len(args) < 2 {
+		return "", 0, false
+	}
+	var script string
+	switch arg := args[0].(type) {
+	case string:
+		script = arg
+	case []byte:
+		script = string(arg)
+	}
+	if !isEval {
+		sha = strings.ToLower(script)
+	} else if hashBody && script != "" {
+		sum := sha1.Sum([]byte(script))
+		sha = hex.EncodeToString(sum[:])
+	}
+	var err error
+	switch arg := args[1].(type) {
+	case int:
+		numKeys = int64(arg)
+	case int32:
+		numKeys = int64(arg)
+	case int64:
+		numKeys = arg
+	case string:
+		numKeys, err = strconv.ParseInt(arg, 10, 64)
+	case []byte:
+		numKeys, err = strconv.ParseInt(string(arg), 10, 64)
+	default:
+		return sha, -1, true
+	}
+	if err != nil || numKeys < 0 {
+		numKeys = -1
+	}
+	return sha, numKeys, true
+}
+
 func withSpan(ctx context.Context, do func(commandName string, args ...interface{}) (interface{}, error), p *params, commandName string, args ...interface{}) (reply interface{}, err error) {
 	// When a context exists in the args, it takes precedence over the passed ctx.
 	if n := len(args); n > 0 {
@@ -181,6 +282,11 @@ func withSpan(ctx context.Context, do func(commandName string, args ...interface
 
 	span := newChildSpan(ctx, p)
 	defer func() {
+		if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+			// The caller gave up on the command; tag it so it isn't mistaken
+			// for a server-side error or a timeout.
+			span.SetTag("redis.cancelled", true)
+		}
 		span.Finish(tracer.WithError(err))
 	}()
 
@@ -211,7 +317,51 @@ func withSpan(ctx context.Context, do func(commandName string, args ...interface
 		}
 	}
 	span.SetTag("redis.raw_command", b.String())
-	return do(commandName, args...)
+	if sha, numKeys, ok := scriptInfo(commandName, args, p.config.hashScripts); ok {
+		if sha != "" {
+			span.SetTag("redis.script_sha", sha)
+		}
+		if numKeys >= 0 {
+			span.SetTag("redis.script_numkeys", strconv.FormatInt(numKeys, 10))
+		}
+	}
+	reply, err = do(commandName, args...)
+	if err == nil && span.ShouldCapturePayload() {
+		var rb bytes.Buffer
+		writeReply(&rb, reply)
+		span.CapturePayload(ext.RedisReply, rb.Bytes())
+	}
+	if db, ok := selectedDB(commandName, args); ok && err == nil {
+		// the following commands on this connection run against the selected database
+		p.db.Store(db)
+	}
+	return reply, err
+}
+
+// writeReply writes reply to b on a single line, with the elements of arrays
+// separated by spaces.
+func writeReply(b *bytes.Buffer, reply interface{}) {
+	switch reply := reply.(type) {
+	case nil:
+		b.WriteString("(nil)")
+	case []byte:
+		b.Write(reply)
+	case string:
+		b.WriteString(reply)
+	case int64:
+		b.WriteString(strconv.FormatInt(reply, 10))
+	case []interface{}:
+		b.WriteByte('[')
+		for i, r := range reply {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			writeReply(b, r)
+		}
+		b.WriteByte(']')
+	default:
+		fmt.Fprint(b, reply)
+	}
 }
 
 // Do wraps redis.Conn.Do. It sends a command to the Redis server and returns the received reply.
@@ -7,6 +7,8 @@ package redigo
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"testing"
@@ -19,6 +21,7 @@ import (
 
 	"github.com/gomodule/redigo/redis"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMain(m *testing.M) {
@@ -160,6 +163,39 @@ func TestCommandsToSring(t *testing.T) {
 	assert.Equal("redis", span.Tag(ext.DBSystem))
 }
 
+type replyRecorder struct {
+	replies []interface{}
+}
+
+func (r *replyRecorder) OnStart(*tracer.Span) {}
+
+func (r *replyRecorder) OnFinish(s *tracer.Span) {
+	r.replies = append(r.replies, s.Tags()[ext.RedisReply])
+}
+
+func TestPayloadCapture(t *testing.T) {
+	r := new(replyRecorder)
+	tracer.Start(tracer.WithPayloadCapture(100), tracer.WithSpanProcessor(r), tracer.WithLogStartup(false))
+	defer tracer.Stop()
+
+	c, err := Dial("tcp", "127.0.0.1:6379")
+	require.NoError(t, err)
+	defer c.Close()
+	_, err = c.Do("DEL", "payloadList")
+	require.NoError(t, err)
+	_, err = c.Do("RPUSH", "payloadList", "a", "b")
+	require.NoError(t, err)
+	_, err = c.Do("LRANGE", "payloadList", 0, -1)
+	require.NoError(t, err)
+	_, err = c.Do("GET", "payloadMissing")
+	require.NoError(t, err)
+
+	require.Len(t, r.replies, 4)
+	assert.Equal(t, "2", r.replies[1])
+	assert.Equal(t, "[a b]", r.replies[2])
+	assert.Equal(t, "(nil)", r.replies[3])
+}
+
 func TestPool(t *testing.T) {
 	assert := assert.New(t)
 	mt := mocktracer.Start()
@@ -198,6 +234,84 @@ func TestTracingDialUrl(t *testing.T) {
 	assert.True(len(spans) > 0)
 }
 
+func TestSelectDB(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c, err := DialURL("redis://127.0.0.1:6379/1")
+	assert.NoError(t, err)
+	defer c.Close()
+	_, err = c.Do("GET", "key")
+	assert.NoError(t, err)
+	_, err = c.Do("SELECT", 2)
+	assert.NoError(t, err)
+	_, err = c.Do("GET", "key")
+	assert.NoError(t, err)
+	_, err = c.Do("SELECT", "notanumber")
+	assert.Error(t, err)
+	_, err = c.Do("GET", "key")
+	assert.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 5)
+	assert.Equal(t, "1", spans[0].Tag(ext.TargetDB))
+	assert.Equal(t, "1", spans[1].Tag(ext.TargetDB))
+	assert.Equal(t, "2", spans[2].Tag(ext.TargetDB))
+	assert.Equal(t, "2", spans[4].Tag(ext.TargetDB))
+
+	c, err = Dial("tcp", "127.0.0.1:6379")
+	assert.NoError(t, err)
+	defer c.Close()
+	_, err = c.Do("GET", "key")
+	assert.NoError(t, err)
+	assert.Nil(t, mt.FinishedSpans()[5].Tag(ext.TargetDB))
+}
+
+func TestScript(t *testing.T) {
+	const src = "return redis.call('GET', KEYS[1])"
+	sum := sha1.Sum([]byte(src))
+	sha := hex.EncodeToString(sum[:])
+
+	for _, tt := range []struct {
+		name       string
+		opts       []interface{}
+		evalTagged bool
+	}{
+		{name: "default"},
+		{name: "hashing", opts: []interface{}{WithScriptHashing(true)}, evalTagged: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			c, err := Dial("tcp", "127.0.0.1:6379", tt.opts...)
+			require.NoError(t, err)
+			defer c.Close()
+			_, err = c.Do("SCRIPT", "FLUSH")
+			require.NoError(t, err)
+			mt.Reset()
+
+			// The script isn't loaded yet, so redis.Script falls back from EVALSHA to EVAL.
+			script := redis.NewScript(1, src)
+			_, err = script.Do(c, "key")
+			require.NoError(t, err)
+
+			spans := mt.FinishedSpans()
+			require.Len(t, spans, 2)
+			assert.Equal(t, "EVALSHA", spans[0].Tag(ext.ResourceName))
+			assert.Equal(t, sha, spans[0].Tag("redis.script_sha"))
+			assert.Equal(t, "1", spans[0].Tag("redis.script_numkeys"))
+			assert.Equal(t, "EVAL", spans[1].Tag(ext.ResourceName))
+			assert.Equal(t, "1", spans[1].Tag("redis.script_numkeys"))
+			if tt.evalTagged {
+				assert.Equal(t, sha, spans[1].Tag("redis.script_sha"))
+			} else {
+				assert.Nil(t, spans[1].Tag("redis.script_sha"))
+			}
+		})
+	}
+}
+
 func TestTracingDialContext(t *testing.T) {
 	assert := assert.New(t)
 	mt := mocktracer.Start()
@@ -393,7 +507,9 @@ func TestDoContext(t *testing.T) {
 		assert.Equal(context.Canceled, err)
 
 		spans := mt.FinishedSpans()
-		assert.True(len(spans) > 0)
+		if assert.True(len(spans) > 0) {
+			assert.Equal("true", spans[0].Tag("redis.cancelled"))
+		}
 	})
 
 	t.Run("do context with timeout - deadline exceeded", func(t *testing.T) {
@@ -411,6 +527,8 @@ func TestDoContext(t *testing.T) {
 		assert.Equal(context.DeadlineExceeded, err)
 
 		spans := mt.FinishedSpans()
-		assert.True(len(spans) > 0)
+		if assert.True(len(spans) > 0) {
+			assert.Nil(spans[0].Tag("redis.cancelled"))
+		}
 	})
 }
@@ -20,6 +20,10 @@ import (
 	"encoding/json"
 	"math"
 	"net/http"
+	nethttptrace "net/http/httptrace"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/DataDog/dd-trace-go/contrib/google.golang.org/api/v2/internal/tree"
 	httptrace "github.com/DataDog/dd-trace-go/contrib/net/http/v2"
@@ -98,14 +102,60 @@ func WrapRoundTripper(transport http.RoundTripper, options ...Option) http.Round
 			}
 			span.SetTag(ext.Component, componentName)
 			span.SetTag(ext.SpanKind, ext.SpanKindClient)
+			setHeaderTags(span, req.Header, cfg.requestHeaderTags)
 		}),
 	}
+	if cfg.responseHeaderTags != nil {
+		rtOpts = append(rtOpts, httptrace.WithAfter(func(res *http.Response, span *tracer.Span) {
+			if res == nil {
+				return
+			}
+			setHeaderTags(span, res.Header, cfg.responseHeaderTags)
+		}))
+	}
 	if !math.IsNaN(cfg.analyticsRate) {
 		rtOpts = append(rtOpts, httptrace.WithAnalyticsRate(cfg.analyticsRate))
 	}
+	if cfg.slowDNSThreshold > 0 {
+		// the DNS timing transport runs within the request span, which it tags
+		transport = &dnsTimingTransport{base: transport, threshold: cfg.slowDNSThreshold}
+	}
 	return httptrace.WrapRoundTripper(transport, rtOpts...)
 }
 
+// dnsTimingTransport measures the DNS resolution of requests and tags the span found in
+// their context when it took at least threshold, see WithSlowDNSThreshold.
+type dnsTimingTransport struct {
+	base      http.RoundTripper
+	threshold time.Duration
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *dnsTimingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// the hooks may run in the goroutine dialing the connection
+	var start, dns atomic.Int64
+	ctx := nethttptrace.WithClientTrace(req.Context(), &nethttptrace.ClientTrace{
+		DNSStart: func(nethttptrace.DNSStartInfo) { start.Store(time.Now().UnixNano()) },
+		DNSDone: func(nethttptrace.DNSDoneInfo) {
+			if s := start.Load(); s != 0 {
+				dns.Store(time.Now().UnixNano() - s)
+			}
+		},
+	})
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	res, err := base.RoundTrip(req.WithContext(ctx))
+	if d := time.Duration(dns.Load()); d >= t.threshold {
+		if span, ok := tracer.SpanFromContext(req.Context()); ok {
+			span.SetTag("http.dns_slow", true)
+			span.SetTag("http.dns_duration_ms", float64(d)/float64(time.Millisecond))
+		}
+	}
+	return res, err
+}
+
 func setTagsWithEndpointMetadata(req *http.Request, span *tracer.Span) {
 	e, ok := apiEndpointsTree.Get(req.URL.Hostname(), req.Method, req.URL.Path)
 	if ok {
@@ -120,3 +170,15 @@ func setTagsWithoutEndpointMetadata(req *http.Request, span *tracer.Span) {
 	span.SetTag(ext.ServiceName, "google")
 	span.SetTag(ext.ResourceName, req.Method+" "+req.URL.Hostname())
 }
+
+// setHeaderTags sets the values of the headers found in tags as span tags.
+func setHeaderTags(span *tracer.Span, h http.Header, tags instrumentation.HeaderTags) {
+	if tags == nil {
+		return
+	}
+	tags.Iter(func(header, tag string) {
+		if vs := h.Values(header); len(vs) > 0 {
+			span.SetTag(tag, strings.Join(vs, ","))
+		}
+	})
+}
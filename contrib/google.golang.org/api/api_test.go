@@ -9,9 +9,11 @@ import (
 	"context"
 	"io"
 	"net/http"
+	nethttptrace "net/http/httptrace"
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
 	"github.com/DataDog/dd-trace-go/v2/ddtrace/mocktracer"
@@ -149,6 +151,63 @@ func TestWithEndpointMetadataDisabled(t *testing.T) {
 	assert.Equal(t, ext.SpanKindClient, s0.Tag(ext.SpanKind))
 }
 
+func TestHeaderTags(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	var rateLimitedTransport roundTripperFunc = func(req *http.Request) (*http.Response, error) {
+		res, err := badRequestTransport(req)
+		res.Header.Set("X-RateLimit-Remaining", "42")
+		return res, err
+	}
+	client := &http.Client{
+		Transport: WrapRoundTripper(rateLimitedTransport,
+			WithRequestHeaderTags([]string{"X-Goog-User-Project"}),
+			WithResponseHeaderTags([]string{"x-ratelimit-remaining", "x-not-present"}),
+		),
+	}
+	req, err := http.NewRequest(http.MethodGet, "https://civicinfo.googleapis.com/civicinfo/v2/elections", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Goog-User-Project", "my-project")
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+
+	s0 := spans[0]
+	assert.Equal(t, "my-project", s0.Tag("http.request.headers.x-goog-user-project"))
+	assert.Equal(t, "42", s0.Tag("http.response.headers.x-ratelimit-remaining"))
+	assert.Nil(t, s0.Tag("http.response.headers.x-not-present"))
+}
+
+func TestSlowDNS(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	var slowDNSTransport roundTripperFunc = func(req *http.Request) (*http.Response, error) {
+		trace := nethttptrace.ContextClientTrace(req.Context())
+		trace.DNSStart(nethttptrace.DNSStartInfo{Host: req.URL.Hostname()})
+		time.Sleep(20 * time.Millisecond)
+		trace.DNSDone(nethttptrace.DNSDoneInfo{})
+		return badRequestTransport(req)
+	}
+	for _, threshold := range []time.Duration{10 * time.Millisecond, time.Minute} {
+		client := &http.Client{Transport: WrapRoundTripper(slowDNSTransport, WithSlowDNSThreshold(threshold))}
+		res, err := client.Get("https://civicinfo.googleapis.com/civicinfo/v2/elections")
+		require.NoError(t, err)
+		res.Body.Close()
+	}
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "true", spans[0].Tag("http.dns_slow"))
+	assert.GreaterOrEqual(t, spans[0].Tag("http.dns_duration_ms"), 20.0)
+	assert.Nil(t, spans[1].Tag("http.dns_slow"))
+	assert.Nil(t, spans[1].Tag("http.dns_duration_ms"))
+}
+
 func TestAnalyticsSettings(t *testing.T) {
 	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...Option) {
 		svc, err := books.New(&http.Client{
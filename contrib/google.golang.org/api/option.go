@@ -8,14 +8,24 @@ package api
 import (
 	"context"
 	"math"
+	"time"
+
+	"github.com/DataDog/dd-trace-go/v2/instrumentation"
 )
 
+// defaultSlowDNSThreshold is the default duration from which DNS resolutions are tagged
+// as slow, see WithSlowDNSThreshold.
+const defaultSlowDNSThreshold = 100 * time.Millisecond
+
 type config struct {
 	serviceName              string
 	ctx                      context.Context
 	analyticsRate            float64
 	scopes                   []string
 	endpointMetadataDisabled bool
+	requestHeaderTags        instrumentation.HeaderTags
+	responseHeaderTags       instrumentation.HeaderTags
+	slowDNSThreshold         time.Duration // see WithSlowDNSThreshold
 }
 
 func newConfig(options ...Option) *config {
@@ -23,6 +33,7 @@ func newConfig(options ...Option) *config {
 		ctx:                      context.Background(),
 		analyticsRate:            instr.AnalyticsRate(false),
 		endpointMetadataDisabled: false,
+		slowDNSThreshold:         defaultSlowDNSThreshold,
 	}
 	for _, opt := range options {
 		opt.apply(cfg)
@@ -96,3 +107,34 @@ func WithEndpointMetadataDisabled() OptionFn {
 		cfg.endpointMetadataDisabled = true
 	}
 }
+
+// WithRequestHeaderTags sets the request headers which will be added as span tags,
+// in the form of "http.request.headers.<name>", or of the given tag for the headers
+// given as "header:tag". Header names are case insensitive.
+func WithRequestHeaderTags(headers []string) OptionFn {
+	return func(cfg *config) {
+		cfg.requestHeaderTags = instrumentation.NewHeaderTags(headers)
+	}
+}
+
+// WithResponseHeaderTags sets the response headers which will be added as span tags,
+// in the form of "http.response.headers.<name>", or of the given tag for the headers
+// given as "header:tag". Header names are case insensitive.
+// This can be used to report quota related headers such as X-RateLimit-Remaining.
+func WithResponseHeaderTags(headers []string) OptionFn {
+	return func(cfg *config) {
+		cfg.responseHeaderTags = instrumentation.NewResponseHeaderTags(headers)
+	}
+}
+
+// WithSlowDNSThreshold sets the duration from which the DNS resolution of a request is
+// considered slow. Spans of requests whose DNS resolution took at least d are tagged with
+// http.dns_slow:true and the duration of the resolution in milliseconds as
+// http.dns_duration_ms, so that latency spikes caused by DNS aren't attributed to Google
+// APIs. Resolutions only happen when new connections are dialed. It defaults to 100ms,
+// and a duration lower than or equal to zero disables the detection.
+func WithSlowDNSThreshold(d time.Duration) OptionFn {
+	return func(cfg *config) {
+		cfg.slowDNSThreshold = d
+	}
+}
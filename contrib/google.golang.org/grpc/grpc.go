@@ -68,7 +68,12 @@ func startSpanFromContext(
 		tracer.Tag(ext.RPCService, methodElements[0]),
 	)
 	md, _ := metadata.FromIncomingContext(ctx) // nil is ok
-	if sctx, err := tracer.Extract(grpcutil.MDCarrier(md)); err == nil {
+	sctx, err := tracer.Extract(grpcutil.MDCarrier(md))
+	if errors.Is(err, tracer.ErrSpanContextNotFound) {
+		// gRPC-Web clients may send the span context as binary headers.
+		sctx, err = tracer.Extract(grpcutil.WebMDCarrier(md))
+	}
+	if err == nil {
 		// If there are span links as a result of context extraction, add them as a StartSpanOption
 		if sctx != nil && sctx.SpanLinks() != nil {
 			opts = append(opts, tracer.WithSpanLinks(sctx.SpanLinks()))
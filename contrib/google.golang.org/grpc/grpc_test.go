@@ -491,6 +491,29 @@ func TestPreservesMetadata(t *testing.T) {
 	assert.Equal(t, "test-value", s.Tag(tagMetadataPrefix+"test-key.0"))
 }
 
+func TestExtractBinaryMetadata(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	// gRPC-Web clients may send the span context as binary headers.
+	md := metadata.Pairs(
+		"x-datadog-trace-id-bin", "9219028207762307503",
+		"x-datadog-parent-id-bin", "7525005002014855056",
+	)
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	info := &grpc.UnaryServerInfo{FullMethod: "/package.MyService/ExampleMethod"}
+	handler := func(_ context.Context, _ interface{}) (interface{}, error) {
+		return nil, nil
+	}
+	_, err := UnaryServerInterceptor()(ctx, "req", info, handler)
+	require.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, uint64(9219028207762307503), spans[0].TraceID())
+	assert.Equal(t, uint64(7525005002014855056), spans[0].ParentID())
+}
+
 func TestStreamSendsErrorCode(t *testing.T) {
 	wantCode := codes.InvalidArgument.String()
 
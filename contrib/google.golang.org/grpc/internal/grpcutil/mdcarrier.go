@@ -47,3 +47,30 @@ func (mdc MDCarrier) ForeachKey(handler func(key, val string) error) error {
 	}
 	return nil
 }
+
+// WebMDCarrier implements tracer.TextMapReader on top of the binary entries of gRPC's
+// metadata, whose keys end in "-bin", allowing the span context sent by gRPC-Web clients
+// as binary headers to be extracted. gRPC-Web carries binary headers base64 encoded, which
+// gRPC decodes into the metadata, so only the "-bin" suffix is stripped from the keys,
+// e.g. traceparent-bin is read as traceparent. The other entries are skipped; use MDCarrier
+// for those.
+type WebMDCarrier metadata.MD
+
+var _ tracer.TextMapReader = (*WebMDCarrier)(nil)
+
+// ForeachKey will iterate over the key/value pairs of the binary entries in the metadata,
+// with the "-bin" suffix removed from their keys.
+func (mdc WebMDCarrier) ForeachKey(handler func(key, val string) error) error {
+	for k, vs := range mdc {
+		key, ok := strings.CutSuffix(k, "-bin")
+		if !ok {
+			continue
+		}
+		for _, v := range vs {
+			if err := handler(key, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
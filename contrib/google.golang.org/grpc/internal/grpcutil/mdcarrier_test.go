@@ -60,3 +60,15 @@ func TestMDCarrierForeachKey(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, want, got)
 }
+
+func TestWebMDCarrierForeachKey(t *testing.T) {
+	md := metadata.Pairs("traceparent-bin", "v1", "tracestate-bin", "v2", "k3", "v3")
+	got := map[string]string{}
+	err := WebMDCarrier(md).ForeachKey(func(k, v string) error {
+		got[k] = v
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"traceparent": "v1", "tracestate": "v2"}, got)
+}
@@ -174,7 +174,7 @@ func after(db *gorm.DB, cfg *config) {
 		if cfg.errCheck(db.Error) {
 			dbErr = db.Error
 		}
-		span.SetTag(ext.ResourceName, db.Statement.SQL.String())
+		span.SetTag(ext.ResourceName, instr.ObfuscateSQL(db.Statement.SQL.String()))
 		span.Finish(tracer.WithError(dbErr))
 	}
 }
@@ -17,6 +17,7 @@ type config struct {
 	tracePrepare  bool
 	traceConnect  bool
 	traceAcquire  bool
+	acquireLinks  bool
 	poolStats     bool
 	statsdClient  instrumentation.StatsdClient
 }
@@ -85,6 +86,16 @@ func WithTraceAcquire(enabled bool) Option {
 	}
 }
 
+// WithAcquireSpanLinks links the span of the first operation run on a connection
+// acquired from a pgxpool to the span of that acquire call, so the time spent waiting
+// for the pool shows up as a distinct phase correlated with the operation which needed
+// the connection. It has no effect unless acquire tracing is enabled.
+func WithAcquireSpanLinks(enabled bool) Option {
+	return func(c *config) {
+		c.acquireLinks = enabled
+	}
+}
+
 // WithTracePrepare enables tracing prepared statements.
 func WithTracePrepare(enabled bool) Option {
 	return func(c *config) {
@@ -7,6 +7,7 @@ package pgx
 
 import (
 	"context"
+	"sync"
 
 	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
 	"github.com/DataDog/dd-trace-go/v2/ddtrace/tracer"
@@ -60,16 +61,19 @@ type wrappedPgxTracer struct {
 	prepare     pgx.PrepareTracer
 	copyFrom    pgx.CopyFromTracer
 	poolAcquire pgxpool.AcquireTracer
+	poolRelease pgxpool.ReleaseTracer
 }
 
 type pgxTracer struct {
 	cfg            *config
 	prevBatchQuery *tracedBatchQuery
 	wrapped        wrappedPgxTracer
+	acquired       sync.Map // *pgx.Conn -> *tracer.SpanContext of the acquire span, see WithAcquireSpanLinks
 }
 
 var (
-	_ allPgxTracers = (*pgxTracer)(nil)
+	_ allPgxTracers         = (*pgxTracer)(nil)
+	_ pgxpool.ReleaseTracer = (*pgxTracer)(nil)
 )
 
 func wrapPgxTracer(prev pgx.QueryTracer, opts ...Option) *pgxTracer {
@@ -96,6 +100,9 @@ func wrapPgxTracer(prev pgx.QueryTracer, opts ...Option) *pgxTracer {
 		if poolAcquireTr, ok := prev.(pgxpool.AcquireTracer); ok {
 			tr.wrapped.poolAcquire = poolAcquireTr
 		}
+		if poolReleaseTr, ok := prev.(pgxpool.ReleaseTracer); ok {
+			tr.wrapped.poolRelease = poolReleaseTr
+		}
 	}
 
 	return tr
@@ -108,7 +115,7 @@ func (t *pgxTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pg
 	if t.wrapped.query != nil {
 		ctx = t.wrapped.query.TraceQueryStart(ctx, conn, data)
 	}
-	opts := t.spanOptions(conn.Config(), operationTypeQuery, data.SQL)
+	opts := t.spanOptions(conn.Config(), operationTypeQuery, data.SQL, t.acquireLink(conn)...)
 	_, ctx = tracer.StartSpanFromContext(ctx, "pgx.query", opts...)
 	return ctx
 }
@@ -135,7 +142,7 @@ func (t *pgxTracer) TraceBatchStart(ctx context.Context, conn *pgx.Conn, data pg
 		ctx = t.wrapped.batch.TraceBatchStart(ctx, conn, data)
 	}
 	opts := t.spanOptions(conn.Config(), operationTypeBatch, "",
-		tracer.Tag(tagBatchNumQueries, data.Batch.Len()),
+		append(t.acquireLink(conn), tracer.Tag(tagBatchNumQueries, data.Batch.Len()))...,
 	)
 	_, ctx = tracer.StartSpanFromContext(ctx, "pgx.batch", opts...)
 	return ctx
@@ -186,8 +193,10 @@ func (t *pgxTracer) TraceCopyFromStart(ctx context.Context, conn *pgx.Conn, data
 		ctx = t.wrapped.copyFrom.TraceCopyFromStart(ctx, conn, data)
 	}
 	opts := t.spanOptions(conn.Config(), operationTypeCopyFrom, "",
-		tracer.Tag(tagCopyFromTables, data.TableName),
-		tracer.Tag(tagCopyFromColumns, data.ColumnNames),
+		append(t.acquireLink(conn),
+			tracer.Tag(tagCopyFromTables, data.TableName),
+			tracer.Tag(tagCopyFromColumns, data.ColumnNames),
+		)...,
 	)
 	_, ctx = tracer.StartSpanFromContext(ctx, "pgx.copy_from", opts...)
 	return ctx
@@ -210,7 +219,7 @@ func (t *pgxTracer) TracePrepareStart(ctx context.Context, conn *pgx.Conn, data
 	if t.wrapped.prepare != nil {
 		ctx = t.wrapped.prepare.TracePrepareStart(ctx, conn, data)
 	}
-	opts := t.spanOptions(conn.Config(), operationTypePrepare, data.SQL)
+	opts := t.spanOptions(conn.Config(), operationTypePrepare, data.SQL, t.acquireLink(conn)...)
 	_, ctx = tracer.StartSpanFromContext(ctx, "pgx.prepare", opts...)
 	return ctx
 }
@@ -266,9 +275,45 @@ func (t *pgxTracer) TraceAcquireEnd(ctx context.Context, pool *pgxpool.Pool, dat
 	if t.wrapped.poolAcquire != nil {
 		t.wrapped.poolAcquire.TraceAcquireEnd(ctx, pool, data)
 	}
+	if t.cfg.acquireLinks && data.Err == nil && data.Conn != nil {
+		if span, ok := tracer.SpanFromContext(ctx); ok {
+			t.acquired.Store(data.Conn, span.Context())
+		}
+	}
 	finishSpan(ctx, data.Err)
 }
 
+// TraceRelease forgets the acquire span of the released connection, if no operation
+// was run on it, so that it isn't linked to the operations run after the next acquire.
+func (t *pgxTracer) TraceRelease(pool *pgxpool.Pool, data pgxpool.TraceReleaseData) {
+	if t.wrapped.poolRelease != nil {
+		t.wrapped.poolRelease.TraceRelease(pool, data)
+	}
+	if t.cfg.acquireLinks {
+		t.acquired.Delete(data.Conn)
+	}
+}
+
+// acquireLink returns the options linking the span of an operation run on conn to the
+// span of the pool acquire call which returned conn, if it's the first operation run
+// since then.
+func (t *pgxTracer) acquireLink(conn *pgx.Conn) []tracer.StartSpanOption {
+	if !t.cfg.acquireLinks {
+		return nil
+	}
+	v, ok := t.acquired.LoadAndDelete(conn)
+	if !ok {
+		return nil
+	}
+	sc := v.(*tracer.SpanContext)
+	return []tracer.StartSpanOption{tracer.WithSpanLinks([]tracer.SpanLink{{
+		TraceID:     sc.TraceIDLower(),
+		TraceIDHigh: sc.TraceIDUpper(),
+		SpanID:      sc.SpanID(),
+		Attributes:  map[string]string{"link.kind": "pgx.pool.acquire"},
+	}})}
+}
+
 func (t *pgxTracer) spanOptions(connConfig *pgx.ConnConfig, op operationType, sqlStatement string, extraOpts ...tracer.StartSpanOption) []tracer.StartSpanOption {
 	opts := []tracer.StartSpanOption{
 		tracer.ServiceName(t.cfg.serviceName),
@@ -280,6 +325,7 @@ func (t *pgxTracer) spanOptions(connConfig *pgx.ConnConfig, op operationType, sq
 	}
 	opts = append(opts, extraOpts...)
 	if sqlStatement != "" {
+		sqlStatement = instr.ObfuscateSQL(sqlStatement)
 		opts = append(opts, tracer.Tag(ext.DBStatement, sqlStatement))
 		opts = append(opts, tracer.ResourceName(sqlStatement))
 	} else {
@@ -286,6 +286,47 @@ func TestAcquire(t *testing.T) {
 	assert.Equal(t, ps.SpanID(), s.ParentID())
 }
 
+func TestAcquireSpanLinks(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	opts := append(tracingAllDisabled(), WithTraceAcquire(true), WithTraceQuery(true), WithAcquireSpanLinks(true))
+	parent, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+	pool := newPoolCreator(nil, opts...)(t, ctx)
+
+	var x int
+	err := pool.QueryRow(ctx, `SELECT 1`).Scan(&x)
+	require.NoError(t, err)
+	parent.Finish()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 3)
+	acquire, query := spans[0], spans[1]
+	assert.Equal(t, "pgx.pool.acquire", acquire.OperationName())
+	assert.Equal(t, "pgx.query", query.OperationName())
+	require.Len(t, query.Links(), 1)
+	link := query.Links()[0]
+	assert.Equal(t, acquire.SpanID(), link.SpanID)
+	assert.Equal(t, acquire.TraceID(), link.TraceID)
+	assert.Equal(t, "pgx.pool.acquire", link.Attributes["link.kind"])
+
+	t.Run("released unused", func(t *testing.T) {
+		p := pool.(*pgxpool.Pool)
+		conn, err := p.Acquire(ctx)
+		require.NoError(t, err)
+		require.NoError(t, conn.Conn().Ping(ctx))
+		conn.Release()
+
+		tr := p.Config().ConnConfig.Tracer.(*pgxTracer)
+		var n int
+		tr.acquired.Range(func(_, _ any) bool {
+			n++
+			return true
+		})
+		assert.Zero(t, n)
+	})
+}
+
 // https://github.com/DataDog/dd-trace-go/issues/2908
 func TestWrapTracer(t *testing.T) {
 	testCases := []struct {
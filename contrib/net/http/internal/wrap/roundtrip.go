@@ -73,6 +73,11 @@ func ObserveRoundTrip(cfg *config.RoundTripperConfig, req *http.Request) (*http.
 
 	// Clone the request so we can modify it without causing visible side-effects to the caller...
 	req = req.Clone(ctx)
+	var reqBody *httptrace.BodyCapture
+	if span.ShouldCapturePayload() && req.Body != nil && req.Body != http.NoBody {
+		reqBody = new(httptrace.BodyCapture)
+		req.Body = reqBody.WrapBody(req.Body)
+	}
 	for k, v := range baggage.All(ctx) {
 		span.SetBaggageItem(k, v)
 	}
@@ -108,6 +113,17 @@ func ObserveRoundTrip(cfg *config.RoundTripperConfig, req *http.Request) (*http.
 			}
 		}
 
+		if reqBody != nil {
+			span.CapturePayload(ext.HTTPRequestBody, reqBody.Bytes())
+		}
+		if resp != nil && resp.ContentLength > 0 && span.ShouldCapturePayload() {
+			// The response body is read once the span is finished: only bodies of known
+			// length are peeked at, since streamed bodies may never end.
+			var respBody httptrace.BodyCapture
+			resp.Body = respBody.PeekBody(resp.Body, resp.ContentLength)
+			span.CapturePayload(ext.HTTPResponseBody, respBody.Bytes())
+		}
+
 		// Run the after hooks & finish the span
 		if cfg.After != nil {
 			cfg.After(resp, span)
@@ -9,6 +9,7 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -102,6 +103,31 @@ func TestRoundTripper(t *testing.T) {
 	assert.Equal(t, float64(wantPort), s1.Tag(ext.NetworkDestinationPort))
 }
 
+func TestRoundTripperPayloadCapture(t *testing.T) {
+	tracer.Start(tracer.WithPayloadCapture(100), tracer.WithLogStartup(false))
+	defer tracer.Stop()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte("pong"))
+	}))
+	defer s.Close()
+
+	var captured map[string]any
+	rt := WrapRoundTripper(http.DefaultTransport, WithAfter(func(_ *http.Response, span *tracer.Span) {
+		captured = span.AsMap()
+	}))
+	client := &http.Client{Transport: rt}
+	resp, err := client.Post(s.URL, "text/plain", strings.NewReader("ping"))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "pong", string(body))
+	assert.Equal(t, "ping", captured[ext.HTTPRequestBody])
+	assert.Equal(t, "pong", captured[ext.HTTPResponseBody])
+}
+
 func makeRequests(rt http.RoundTripper, url string, t *testing.T) {
 	client := &http.Client{
 		Transport: rt,
@@ -13,6 +13,7 @@ import (
 	"bufio"
 	"bytes"
 	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -20,6 +21,7 @@ import (
 	"net/http"
 	"regexp"
 	"strconv"
+	"sync"
 
 	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
 	"github.com/DataDog/dd-trace-go/v2/ddtrace/tracer"
@@ -47,6 +49,26 @@ func NewHTTPClient(opts ...ClientOption) *http.Client {
 	return &http.Client{Transport: &httpTransport{config: cfg}}
 }
 
+type retryTrackerKey struct{}
+
+// retryTracker counts the attempts of a request, see ContextWithRetryTracking.
+type retryTracker struct {
+	mu       sync.Mutex
+	attempts int
+	first    *tracer.SpanContext // the span context of the first attempt
+}
+
+// ContextWithRetryTracking returns a copy of ctx which tracks the attempts of the
+// Elasticsearch request it is given to. The elastic client retries failed requests
+// with the same context, so the spans of the retried attempts are tagged with
+// elasticsearch.retry and elasticsearch.retry_count, and linked to the span of the
+// first attempt. A new context must be used for each request, e.g.:
+//
+//	client.Search("twitter").Do(elastictrace.ContextWithRetryTracking(ctx))
+func ContextWithRetryTracking(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryTrackerKey{}, &retryTracker{})
+}
+
 // httpTransport is a traced HTTP transport that captures Elasticsearch spans.
 type httpTransport struct{ config *clientConfig }
 
@@ -66,7 +88,7 @@ func (t *httpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 		tracer.ResourceName(resource),
 		tracer.Tag("elasticsearch.method", method),
 		tracer.Tag("elasticsearch.url", url),
-		tracer.Tag("elasticsearch.params", req.URL.Query().Encode()),
+		tracer.Tag("elasticsearch.params", instr.SanitizeQuery(req.URL.Query().Encode())),
 		tracer.Tag(ext.Component, componentName),
 		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
 		tracer.Tag(ext.DBSystem, ext.DBSystemElasticsearch),
@@ -75,8 +97,44 @@ func (t *httpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	if !math.IsNaN(t.config.analyticsRate) {
 		opts = append(opts, tracer.Tag(ext.EventSampleRate, t.config.analyticsRate))
 	}
-	span, _ := tracer.StartSpanFromContext(req.Context(), t.config.spanName, opts...)
+	rt, _ := req.Context().Value(retryTrackerKey{}).(*retryTracker)
+	var (
+		attempt int
+		first   *tracer.SpanContext
+	)
+	if rt != nil {
+		rt.mu.Lock()
+		attempt, first = rt.attempts, rt.first
+		rt.attempts++
+		rt.mu.Unlock()
+		if attempt > 0 {
+			opts = append(opts,
+				tracer.Tag("elasticsearch.retry", true),
+				tracer.Tag("elasticsearch.retry_count", attempt),
+			)
+		}
+		if first != nil {
+			opts = append(opts, tracer.WithSpanLinks([]tracer.SpanLink{{
+				TraceID:     first.TraceIDLower(),
+				TraceIDHigh: first.TraceIDUpper(),
+				SpanID:      first.SpanID(),
+				Attributes:  map[string]string{"reason": "retry"},
+			}}))
+		}
+	}
+	spanName := t.config.spanName
+	if t.config.spanNamer != nil {
+		if name := t.config.spanNamer(req); name != "" {
+			spanName = name
+		}
+	}
+	span, _ := tracer.StartSpanFromContext(req.Context(), spanName, opts...)
 	defer span.Finish()
+	if rt != nil && attempt == 0 {
+		rt.mu.Lock()
+		rt.first = span.Context()
+		rt.mu.Unlock()
+	}
 
 	contentEncoding := req.Header.Get("Content-Encoding")
 	snip, rc, err := peek(req.Body, contentEncoding, int(req.ContentLength), bodyCutoff)
@@ -11,7 +11,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
@@ -423,3 +426,63 @@ func TestAnalyticsSettings(t *testing.T) {
 		assertRate(t, mt, 0.23, WithAnalyticsRate(0.23))
 	})
 }
+
+func TestRetryTracking(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	tc := NewHTTPClient(WithService("my-es-service"))
+	ctx := ContextWithRetryTracking(context.Background())
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/twitter/_search", nil)
+		assert.NoError(err)
+		res, err := tc.Do(req)
+		assert.NoError(err)
+		res.Body.Close()
+	}
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 3)
+	first := spans[0]
+	assert.Nil(first.Tag("elasticsearch.retry"))
+	assert.Empty(first.Links())
+	for i, span := range spans[1:] {
+		assert.Equal("true", span.Tag("elasticsearch.retry"))
+		assert.Equal(float64(i+1), span.Tag("elasticsearch.retry_count"))
+		if assert.Len(span.Links(), 1) {
+			assert.Equal(first.SpanID(), span.Links()[0].SpanID)
+		}
+	}
+}
+
+func TestSpanNameFormatter(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+
+	tc := NewHTTPClient(WithSpanNameFormatter(func(req *http.Request) string {
+		if strings.HasSuffix(req.URL.Path, "/_bulk") {
+			return "elasticsearch.bulk"
+		}
+		return ""
+	}))
+	for _, path := range []string{"/_bulk", "/twitter/_search"} {
+		res, err := tc.Post(srv.URL+path, "application/json", nil)
+		assert.NoError(err)
+		res.Body.Close()
+	}
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 2)
+	assert.Equal("elasticsearch.bulk", spans[0].OperationName())
+	assert.Equal("elasticsearch.query", spans[1].OperationName())
+}
@@ -18,6 +18,7 @@ type clientConfig struct {
 	transport     *http.Transport
 	analyticsRate float64
 	resourceNamer func(url, method string) string
+	spanNamer     func(req *http.Request) string
 }
 
 // ClientOption describes options for the Elastic integration.
@@ -86,3 +87,12 @@ func WithResourceNamer(namer func(url, method string) string) ClientOptionFn {
 		cfg.resourceNamer = namer
 	}
 }
+
+// WithSpanNameFormatter sets a function which returns the name of the span of the given
+// Elasticsearch request, e.g. to distinguish "elasticsearch.bulk" from "elasticsearch.query".
+// When the function returns an empty string, the default span name is used.
+func WithSpanNameFormatter(fn func(req *http.Request) string) ClientOptionFn {
+	return func(cfg *clientConfig) {
+		cfg.spanNamer = fn
+	}
+}
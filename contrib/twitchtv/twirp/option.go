@@ -15,6 +15,7 @@ type config struct {
 	serviceName   string
 	spanName      string
 	analyticsRate float64
+	recoverPanics bool
 }
 
 // Option describes options for the Twirp integration.
@@ -73,3 +74,13 @@ func WithAnalyticsRate(rate float64) OptionFn {
 		}
 	}
 }
+
+// WithRecoverPanics sets whether WrapServer recovers from panics in the wrapped handler to
+// mark the span as errored with the panic and its stack before finishing it. The panic is
+// then resumed, so that the recovery of the server still happens. It is disabled by default
+// and has no effect on clients and server hooks.
+func WithRecoverPanics(enabled bool) OptionFn {
+	return func(cfg *config) {
+		cfg.recoverPanics = enabled
+	}
+}
@@ -149,7 +149,20 @@ func WrapServer(h http.Handler, opts ...Option) http.Handler {
 			spanOpts = append(spanOpts, tracer.ChildOf(spanctx))
 		}
 		span, ctx := tracer.StartSpanFromContext(r.Context(), "twirp.handler", spanOpts...)
-		defer span.Finish()
+		defer func() {
+			if cfg.recoverPanics {
+				if p := recover(); p != nil {
+					var err error
+					// http.ErrAbortHandler is panicked to abort a response on purpose.
+					if p != http.ErrAbortHandler {
+						err = fmt.Errorf("panic: %v", p)
+					}
+					span.Finish(tracer.WithError(err))
+					panic(p)
+				}
+			}
+			span.Finish()
+		}()
 
 		r = r.WithContext(ctx)
 		h.ServeHTTP(w, r)
@@ -157,7 +170,17 @@ func WrapServer(h http.Handler, opts ...Option) http.Handler {
 }
 
 // NewServerHooks creates the callback hooks for a twirp server to perform tracing.
-// It is used in conjunction with WrapServer.
+// It is used in conjunction with WrapServer. When it is used alone, the hooks continue
+// the distributed trace of the incoming request from the request headers found in the
+// context, which a middleware can set with twirp.WithHTTPRequestHeaders:
+//
+//	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//		ctx, err := twirp.WithHTTPRequestHeaders(r.Context(), r.Header)
+//		if err != nil {
+//			ctx = r.Context()
+//		}
+//		server.ServeHTTP(w, r.WithContext(ctx))
+//	})
 func NewServerHooks(opts ...Option) *twirp.ServerHooks {
 	cfg := new(config)
 	serverDefaults(cfg)
@@ -209,6 +232,17 @@ func requestReceivedHook(cfg *config) func(context.Context) (context.Context, er
 		if !math.IsNaN(cfg.analyticsRate) {
 			opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
 		}
+		if _, ok := tracer.SpanFromContext(ctx); !ok {
+			// without WrapServer, continue the trace from the request headers, if exposed
+			if h, ok := twirp.HTTPRequestHeaders(ctx); ok {
+				if spanctx, err := tracer.Extract(tracer.HTTPHeadersCarrier(h)); err == nil {
+					if spanctx != nil && spanctx.SpanLinks() != nil {
+						opts = append(opts, tracer.WithSpanLinks(spanctx.SpanLinks()))
+					}
+					opts = append(opts, tracer.ChildOf(spanctx))
+				}
+			}
+		}
 		span, ctx := tracer.StartSpanFromContext(ctx, serverSpanName(ctx), opts...)
 		ctx = context.WithValue(ctx, twirpSpanKey{}, span)
 		return ctx, nil
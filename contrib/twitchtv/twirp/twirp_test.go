@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
@@ -271,6 +272,28 @@ func TestServerHooks(t *testing.T) {
 	})
 }
 
+func TestServerHooksExtract(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	hooks := NewServerHooks()
+
+	parent := tracer.StartSpan("upstream")
+	h := http.Header{}
+	require.NoError(t, tracer.Inject(parent.Context(), tracer.HTTPHeadersCarrier(h)))
+	ctx, err := twirp.WithHTTPRequestHeaders(context.Background(), h)
+	require.NoError(t, err)
+
+	ctx, err = hooks.RequestReceived(ctx)
+	require.NoError(t, err)
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	hooks.ResponseSent(ctx)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, parent.Context().TraceID(), spans[0].Context().TraceID())
+	assert.Equal(t, parent.Context().SpanID(), spans[0].ParentID())
+}
+
 func TestAnalyticsSettings(t *testing.T) {
 	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...Option) {
 		hooks := NewServerHooks(opts...)
@@ -321,6 +344,41 @@ func TestAnalyticsSettings(t *testing.T) {
 	})
 }
 
+func TestWrapServerRecoverPanics(t *testing.T) {
+	serve := func(h http.Handler) (p interface{}) {
+		defer func() { p = recover() }()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/twirp/Example/Method", nil))
+		return nil
+	}
+
+	for name, tt := range map[string]struct {
+		panicValue interface{}
+		opts       []Option
+		wantErr    interface{}
+	}{
+		"enabled":  {panicValue: "boom", opts: []Option{WithRecoverPanics(true)}, wantErr: "panic: boom"},
+		"disabled": {panicValue: "boom"},
+		"abort":    {panicValue: http.ErrAbortHandler, opts: []Option{WithRecoverPanics(true)}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			h := WrapServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				panic(tt.panicValue)
+			}), tt.opts...)
+			assert.Equal(t, tt.panicValue, serve(h))
+
+			spans := mt.FinishedSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tt.wantErr, spans[0].Tag(ext.ErrorMsg))
+			if tt.wantErr != nil {
+				assert.NotEmpty(t, spans[0].Tag(ext.ErrorStack))
+			}
+		})
+	}
+}
+
 func TestServiceNameSettings(t *testing.T) {
 	assertServiceName := func(t *testing.T, mt mocktracer.Tracer, serviceName string, opts ...Option) {
 		hooks := NewServerHooks(opts...)
@@ -8,4 +8,8 @@ package options
 type CheckpointParams struct {
 	PayloadSize     int64
 	ServiceOverride string
+	// ResourceEdgeTag adds a "resource:<name>" edge tag holding the resource name of the span
+	// found in the checkpoint context, if any, to give pathway stats request-level granularity.
+	// It is disabled by default since resource names may have a high cardinality.
+	ResourceEdgeTag bool
 }
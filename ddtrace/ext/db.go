@@ -60,6 +60,10 @@ const (
 	// RedisRawCommand allows to set the raw command for tags.
 	RedisRawCommand = "redis.raw_command"
 
+	// RedisReply holds a snippet of the reply to the Redis command, when payloads are
+	// captured, see tracer.WithPayloadCapture.
+	RedisReply = "redis.reply"
+
 	// RedisClientCacheHit is the remaining TTL in seconds of client side cache.
 	RedisClientCacheHit = "db.redis.client.cache.hit"
 
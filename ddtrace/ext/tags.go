@@ -61,6 +61,19 @@ const (
 	// See https://docs.datadoghq.com/tracing/trace_collection/tracing_naming_convention/#http-requests
 	HTTPRequestHeaders = "http.request.headers"
 
+	// HTTPResponseHeaders sets the HTTP response headers partial tag
+	// This tag is meant to be composed, i.e http.response.headers.headerX, http.response.headers.headerY, etc...
+	// See https://docs.datadoghq.com/tracing/trace_collection/tracing_naming_convention/#http-requests
+	HTTPResponseHeaders = "http.response.headers"
+
+	// HTTPRequestBody holds a snippet of the body of the HTTP request, when payloads are
+	// captured, see tracer.WithPayloadCapture.
+	HTTPRequestBody = "http.request.body"
+
+	// HTTPResponseBody holds a snippet of the body of the HTTP response, when payloads are
+	// captured, see tracer.WithPayloadCapture.
+	HTTPResponseBody = "http.response.body"
+
 	// SpanName is a pseudo-key for setting a span's operation name by means of
 	// a tag. It is mostly here to facilitate vendor-agnostic frameworks like Opentracing
 	// and OpenCensus.
@@ -50,8 +50,43 @@ func StartSpanFromContext(ctx context.Context, operationName string, opts ...Sta
 	}
 	optsLocal = append(optsLocal, withContext(ctx))
 	s := StartSpan(operationName, optsLocal...)
+	if keep, ok := ctx.Value(forcedDecisionKey{}).(bool); ok && s != nil {
+		s.context.trace.forceDecision(keep)
+	}
 	if s != nil && s.pprofCtxActive != nil {
 		ctx = s.pprofCtxActive
 	}
 	return s, ContextWithSpan(ctx, s)
 }
+
+// forcedDecisionKey is the context key of the sampling decision forced by WithForceKeep
+// and WithForceDrop.
+type forcedDecisionKey struct{}
+
+// WithForceKeep returns a copy of ctx which forces the trace of its span to be kept, with
+// the manual decision maker, when its local root span finishes. It overrides the decisions
+// of the sampling rules and rates, and also applies to the spans later started from the
+// returned context with StartSpanFromContext. It allows keeping a trace from deep in a call
+// stack, e.g. when application logic identifies a high-value request, without reaching for
+// the root span. When the trace context is propagated to another service, the forced
+// decision is applied right away, so that the services downstream share it; it can't be
+// changed anymore from then on.
+func WithForceKeep(ctx context.Context) context.Context {
+	return withForcedDecision(ctx, true)
+}
+
+// WithForceDrop returns a copy of ctx which forces the trace of its span to be dropped,
+// with the manual decision maker, when its local root span finishes. See WithForceKeep.
+func WithForceDrop(ctx context.Context) context.Context {
+	return withForcedDecision(ctx, false)
+}
+
+func withForcedDecision(ctx context.Context, keep bool) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if s, ok := SpanFromContext(ctx); ok {
+		s.context.trace.forceDecision(keep)
+	}
+	return context.WithValue(ctx, forcedDecisionKey{}, keep)
+}
@@ -11,6 +11,7 @@ import (
 	"encoding/hex"
 	"testing"
 
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
 	"github.com/DataDog/dd-trace-go/v2/internal"
 
 	"github.com/stretchr/testify/assert"
@@ -196,3 +197,45 @@ func TestStartSpanFromNilContext(t *testing.T) {
 	assert.True(ok)
 	assert.Equal(child, ctxSpan)
 }
+
+func TestForcedDecision(t *testing.T) {
+	tracer, transport, flush, stop, err := startTestTracer(t, WithSamplingRules(TraceSamplingRules(Rule{Rate: 0})))
+	assert.NoError(t, err)
+	defer stop()
+
+	t.Run("keep", func(t *testing.T) {
+		root, ctx := StartSpanFromContext(context.Background(), "root")
+		child, _ := StartSpanFromContext(WithForceKeep(ctx), "child")
+		child.Finish()
+		root.Finish()
+		flush(1)
+
+		ts := transport.Traces()
+		assert.Len(t, ts, 1)
+		assert.Equal(t, float64(ext.PriorityUserKeep), root.metrics[keySamplingPriority])
+		assert.Equal(t, "-4", root.context.trace.propagatingTag(keyDecisionMaker))
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		root := tracer.StartSpan("root", Tag(ext.ManualKeep, true))
+		child, _ := StartSpanFromContext(WithForceDrop(context.Background()), "child", ChildOf(root.Context()))
+		child.Finish()
+		root.Finish()
+
+		assert.Equal(t, float64(ext.PriorityUserReject), root.metrics[keySamplingPriority])
+	})
+
+	t.Run("propagated", func(t *testing.T) {
+		root, ctx := StartSpanFromContext(context.Background(), "root")
+		child, _ := StartSpanFromContext(WithForceKeep(ctx), "child")
+		carrier := TextMapCarrier{}
+		assert.NoError(t, tracer.Inject(child.Context(), carrier))
+		assert.Equal(t, "2", carrier[DefaultPriorityHeader])
+
+		// the decision propagated downstream doesn't change anymore
+		WithForceDrop(ContextWithSpan(context.Background(), child))
+		child.Finish()
+		root.Finish()
+		assert.Equal(t, float64(ext.PriorityUserKeep), root.metrics[keySamplingPriority])
+	})
+}
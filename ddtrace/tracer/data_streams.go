@@ -35,13 +35,33 @@ func SetDataStreamsCheckpoint(ctx context.Context, edgeTags ...string) (outCtx c
 func SetDataStreamsCheckpointWithParams(ctx context.Context, params options.CheckpointParams, edgeTags ...string) (outCtx context.Context, ok bool) {
 	if t, ok := getGlobalTracer().(dataStreamsContainer); ok {
 		if processor := t.GetDataStreamsProcessor(); processor != nil {
-			outCtx = processor.SetCheckpointWithParams(ctx, params, edgeTags...)
+			outCtx = processor.SetCheckpointWithParams(ctx, params, checkpointEdgeTags(ctx, params, edgeTags)...)
 			return outCtx, true
 		}
 	}
 	return ctx, false
 }
 
+// checkpointEdgeTags returns the edge tags of a checkpoint set in ctx, adding the ones derived
+// from the span found in ctx as requested by params.
+func checkpointEdgeTags(ctx context.Context, params options.CheckpointParams, edgeTags []string) []string {
+	if !params.ResourceEdgeTag {
+		return edgeTags
+	}
+	s, ok := SpanFromContext(ctx)
+	if !ok {
+		return edgeTags
+	}
+	s.mu.RLock()
+	resource := s.resource
+	s.mu.RUnlock()
+	if resource == "" {
+		return edgeTags
+	}
+	// copy the edge tags so as not to modify the array of the caller
+	return append(edgeTags[:len(edgeTags):len(edgeTags)], "resource:"+resource)
+}
+
 // TrackKafkaCommitOffset should be used in the consumer, to track when it acks offset.
 // if used together with TrackKafkaProduceOffset it can generate a Kafka lag in seconds metric.
 func TrackKafkaCommitOffset(group, topic string, partition int32, offset int64) {
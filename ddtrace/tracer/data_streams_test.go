@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/dd-trace-go/v2/datastreams/options"
+)
+
+func TestCheckpointEdgeTags(t *testing.T) {
+	tracer, _, _, stop, err := startTestTracer(t)
+	require.NoError(t, err)
+	defer stop()
+
+	span := tracer.StartSpan("http.request", ResourceName("GET /users"))
+	defer span.Finish()
+	ctx := ContextWithSpan(context.Background(), span)
+	edgeTags := make([]string, 2, 3)
+	copy(edgeTags, []string{"direction:out", "type:http"})
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.Equal(t, edgeTags, checkpointEdgeTags(ctx, options.CheckpointParams{}, edgeTags))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		params := options.CheckpointParams{ResourceEdgeTag: true}
+		got := checkpointEdgeTags(ctx, params, edgeTags)
+		assert.Equal(t, []string{"direction:out", "type:http", "resource:GET /users"}, got)
+		assert.Equal(t, "", edgeTags[:cap(edgeTags)][2]) // the array of the caller is left untouched
+	})
+
+	t.Run("no-span", func(t *testing.T) {
+		params := options.CheckpointParams{ResourceEdgeTag: true}
+		assert.Equal(t, edgeTags, checkpointEdgeTags(context.Background(), params, edgeTags))
+	})
+}
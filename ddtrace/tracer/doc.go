@@ -63,7 +63,9 @@
 // StartSpanOptions that can be used to configure the span. A span that is started
 // with no parent will begin a new trace. See the function documentation for details
 // on specific usage. Each trace has a hard limit of 100,000 spans, after which the
-// trace will be dropped and give a diagnostic log message. In practice users should
+// trace will be dropped and give a diagnostic log message. Once the root span of a
+// dropped trace finishes, the drop is also reported with the datadog.tracer.traces_truncated
+// health metric, tagged with the service and resource of the root. In practice users should
 // not approach this limit as traces of this size are not useful and impossible to
 // visualize.
 //
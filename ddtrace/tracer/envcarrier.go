@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/dd-trace-go/v2/internal/log"
+)
+
+// envCarrierPrefix prefixes the names of the environment variables which carry a
+// trace context across a process boundary, see InjectEnv.
+const envCarrierPrefix = "DD_TRACE_CONTEXT_"
+
+// envCarrier holds propagation headers as environment variables, keyed by variable name.
+// The variable of a header is its name in upper case, with dashes replaced by
+// underscores and prefixed with envCarrierPrefix, e.g. DD_TRACE_CONTEXT_TRACEPARENT.
+type envCarrier map[string]string
+
+var _ TextMapWriter = (*envCarrier)(nil)
+var _ TextMapReader = (*envCarrier)(nil)
+
+// Set implements TextMapWriter.
+func (c envCarrier) Set(key, val string) {
+	c[envCarrierPrefix+strings.ToUpper(strings.ReplaceAll(key, "-", "_"))] = val
+}
+
+// ForeachKey implements TextMapReader.
+func (c envCarrier) ForeachKey(handler func(key, val string) error) error {
+	for k, v := range c {
+		header := strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(k, envCarrierPrefix), "_", "-"))
+		if err := handler(header, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InjectEnv returns environment variables carrying the trace context of the span found
+// in ctx, as "KEY=value" entries which can be added to the environment of a subprocess,
+// so that it continues the trace by calling ExtractEnv at startup:
+//
+//	cmd := exec.CommandContext(ctx, "./worker")
+//	cmd.Env = append(os.Environ(), tracer.InjectEnv(ctx)...)
+//
+// The configured injection propagation styles are used, with one variable per propagation
+// header. Variables are named after the header, in upper case with dashes replaced by
+// underscores and prefixed with DD_TRACE_CONTEXT_, e.g. DD_TRACE_CONTEXT_TRACEPARENT.
+// It returns nil if ctx doesn't hold a span or if the tracer is not started.
+func InjectEnv(ctx context.Context) []string {
+	s, ok := SpanFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	carrier := envCarrier{}
+	if err := Inject(s.Context(), carrier); err != nil {
+		log.Debug("Failed to inject the trace context into environment variables: %s", err.Error())
+		return nil
+	}
+	if len(carrier) == 0 {
+		return nil
+	}
+	env := make([]string, 0, len(carrier))
+	for k, v := range carrier {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// ExtractEnv extracts the trace context set by InjectEnv from environ, which is usually
+// the result of os.Environ, using the configured extraction propagation styles. Entries
+// which weren't set by InjectEnv are ignored. It returns ErrSpanContextNotFound if environ
+// doesn't carry a trace context.
+//
+// Since underscores in variable names are read back as dashes, baggage keys holding
+// underscores are extracted with dashes instead.
+func ExtractEnv(environ []string) (*SpanContext, error) {
+	carrier := envCarrier{}
+	for _, kv := range environ {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(k, envCarrierPrefix) {
+			continue
+		}
+		carrier[k] = v
+	}
+	if len(carrier) == 0 {
+		return nil, ErrSpanContextNotFound
+	}
+	return Extract(carrier)
+}
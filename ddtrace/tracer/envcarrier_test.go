@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInjectExtractEnv(t *testing.T) {
+	tracer, _, _, stop, err := startTestTracer(t)
+	require.NoError(t, err)
+	defer stop()
+
+	root := tracer.StartSpan("build")
+	defer root.Finish()
+	root.SetBaggageItem("pipeline", "nightly")
+	env := InjectEnv(ContextWithSpan(context.Background(), root))
+
+	assert.Contains(t, env, "DD_TRACE_CONTEXT_X_DATADOG_PARENT_ID="+strconv.FormatUint(root.Context().SpanID(), 10))
+	assert.Contains(t, env, "DD_TRACE_CONTEXT_OT_BAGGAGE_PIPELINE=nightly")
+	assert.IsIncreasing(t, env)
+
+	environ := append([]string{"PATH=/usr/bin", "DD_TRACE_SAMPLE_RATE=1", "MALFORMED"}, env...)
+	sctx, err := ExtractEnv(environ)
+	require.NoError(t, err)
+	assert.Equal(t, root.Context().TraceID(), sctx.TraceID())
+	assert.Equal(t, root.Context().SpanID(), sctx.SpanID())
+	assert.Equal(t, "nightly", sctx.baggageItem("pipeline"))
+
+	child := tracer.StartSpan("compile", ChildOf(sctx))
+	defer child.Finish()
+	assert.Equal(t, root.Context().SpanID(), child.parentID)
+
+	t.Run("no-span", func(t *testing.T) {
+		assert.Nil(t, InjectEnv(context.Background()))
+	})
+
+	t.Run("no-context", func(t *testing.T) {
+		_, err := ExtractEnv([]string{"PATH=/usr/bin"})
+		assert.Equal(t, ErrSpanContextNotFound, err)
+	})
+}
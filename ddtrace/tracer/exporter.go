@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"sync"
+	"time"
+
+	globalinternal "github.com/DataDog/dd-trace-go/v2/internal"
+	"github.com/DataDog/dd-trace-go/v2/internal/log"
+)
+
+// SpanExporter exports finished spans to a backend other than the Datadog Agent,
+// e.g. a message queue, an OpenTelemetry collector or a file, see WithExporter.
+type SpanExporter interface {
+	// Export exports a batch of finished spans, in which the spans of a trace chunk are
+	// contiguous. It is called at every flush of the tracer, for one batch at a time and
+	// in order. The spans are finished and must not be modified; their properties can be
+	// read with accessors such as Span.OperationName, Span.Service, Span.Resource,
+	// Span.Type, Span.Tags and Span.IsError.
+	Export(spans []*Span) error
+
+	// Shutdown is called once when the tracer stops, after the last batch was exported.
+	Shutdown() error
+}
+
+// exporterMaxSpansPerBatch is the number of buffered spans after which the
+// exporterTraceWriter flushes, regardless of the flush interval.
+const exporterMaxSpansPerBatch = 1000
+
+// exporterTraceWriter hands the finished traces over to a SpanExporter.
+type exporterTraceWriter struct {
+	exporter SpanExporter
+	statsd   globalinternal.StatsdClient
+
+	// spans holds the buffered spans.
+	spans []*Span
+
+	// climit allows a single export at a time, so that batches are exported in order
+	climit chan struct{}
+
+	// wg waits for all exports to finish
+	wg sync.WaitGroup
+
+	// shutdownOnce shuts the exporter down once, as the writer may be stopped again
+	shutdownOnce sync.Once
+}
+
+func newExporterTraceWriter(exporter SpanExporter, statsdClient globalinternal.StatsdClient) *exporterTraceWriter {
+	return &exporterTraceWriter{
+		exporter: exporter,
+		statsd:   statsdClient,
+		climit:   make(chan struct{}, 1),
+	}
+}
+
+func (h *exporterTraceWriter) add(trace []*Span) {
+	if len(trace) == 0 {
+		return
+	}
+	// There is no agent to drop the traces which were not kept by sampling,
+	// so only keep them or their single-span sampled spans.
+	keep := true
+	if p, ok := trace[0].metrics[keySamplingPriority]; ok && p <= 0 {
+		keep = false
+	}
+	for _, s := range trace {
+		if !keep {
+			if _, ok := s.metrics[keySpanSamplingMechanism]; !ok {
+				continue
+			}
+		}
+		h.spans = append(h.spans, s)
+	}
+	if len(h.spans) >= exporterMaxSpansPerBatch {
+		h.statsd.Incr("datadog.tracer.flush_triggered", []string{"reason:size"}, 1)
+		h.flush()
+	}
+}
+
+func (h *exporterTraceWriter) stop() {
+	h.statsd.Incr("datadog.tracer.flush_triggered", []string{"reason:shutdown"}, 1)
+	h.flush()
+	h.wg.Wait()
+	h.shutdownOnce.Do(func() {
+		if err := h.exporter.Shutdown(); err != nil {
+			log.Error("Error shutting down the span exporter: %s", err.Error())
+		}
+	})
+}
+
+// flush hands the buffered spans over to the exporter.
+func (h *exporterTraceWriter) flush() {
+	if len(h.spans) == 0 {
+		return
+	}
+	h.wg.Add(1)
+	h.climit <- struct{}{}
+	spans := h.spans
+	h.spans = nil
+	go func() {
+		defer func(start time.Time) {
+			<-h.climit
+			h.statsd.Timing("datadog.tracer.flush_duration", time.Since(start), nil, 1)
+			h.wg.Done()
+		}(time.Now())
+
+		if err := h.exporter.Export(spans); err != nil {
+			h.statsd.Count("datadog.tracer.spans_dropped", int64(len(spans)), []string{"reason:export_failed"}, 1)
+			log.Error("lost %d spans: %v", len(spans), err.Error())
+		}
+	}()
+}
@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
+	"github.com/DataDog/dd-trace-go/v2/internal/statsdtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingExporter struct {
+	mu       sync.Mutex
+	batches  [][]*Span
+	err      error
+	shutdown int
+}
+
+func (e *recordingExporter) Export(spans []*Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.batches = append(e.batches, spans)
+	return e.err
+}
+
+func (e *recordingExporter) Shutdown() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.shutdown++
+	return nil
+}
+
+func TestExporterTraceWriter(t *testing.T) {
+	assert.Implements(t, (*traceWriter)(nil), &exporterTraceWriter{})
+
+	t.Run("export", func(t *testing.T) {
+		e := &recordingExporter{}
+		h := newExporterTraceWriter(e, &statsdtest.TestStatsdClient{})
+
+		root := newSpan("http.request", "web", "GET /", 1, 42, 0)
+		root.metrics[keySamplingPriority] = 1
+		child := newSpan("db.query", "db", "SELECT 1", 2, 42, 1)
+		h.add([]*Span{root, child})
+		dropped := newSpan("http.request", "web", "GET /health", 3, 43, 0)
+		dropped.metrics[keySamplingPriority] = 0
+		singleSpan := newSpan("db.query", "db", "SELECT 2", 4, 43, 3)
+		singleSpan.metrics[keySpanSamplingMechanism] = 8
+		h.add([]*Span{dropped, singleSpan})
+		h.flush()
+		h.add([]*Span{newSpan("http.request", "web", "GET /", 5, 44, 0)})
+		h.stop()
+		h.stop()
+
+		require.Len(t, e.batches, 2)
+		assert.Equal(t, []*Span{root, child, singleSpan}, e.batches[0])
+		assert.Len(t, e.batches[1], 1)
+		assert.Equal(t, 1, e.shutdown)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var tg statsdtest.TestStatsdClient
+		e := &recordingExporter{err: errors.New("boom")}
+		h := newExporterTraceWriter(e, &tg)
+		h.add([]*Span{newSpan("http.request", "web", "GET /", 1, 42, 0)})
+		h.stop()
+
+		assert.Equal(t, int64(1), tg.Counts()["datadog.tracer.spans_dropped"])
+	})
+}
+
+func TestTracerWithExporter(t *testing.T) {
+	e := &recordingExporter{}
+	tracer, err := newTracer(WithExporter(e))
+	require.NoError(t, err)
+	assert.False(t, tracer.config.canComputeStats())
+	setGlobalTracer(tracer)
+
+	root := tracer.StartSpan("web.request", ServiceName("web"), ResourceName("GET /"), SpanType(ext.SpanTypeWeb))
+	tracer.StartSpan("db.query", ChildOf(root.Context()), Tag("db.rows", 3), Tag(ext.DBSystem, "postgresql")).
+		Finish(WithError(errors.New("timeout")))
+	root.Finish()
+	setGlobalTracer(&NoopTracer{})
+	tracer.Stop()
+
+	require.Len(t, e.batches, 1)
+	require.Len(t, e.batches[0], 2)
+	assert.Equal(t, 1, e.shutdown)
+
+	// the exported spans are read through their accessors
+	assert.Same(t, root, e.batches[0][0])
+	child := e.batches[0][1]
+	assert.Equal(t, "web.request", root.OperationName())
+	assert.Equal(t, "web", root.Service())
+	assert.Equal(t, "GET /", root.Resource())
+	assert.Equal(t, ext.SpanTypeWeb, root.Type())
+	assert.Zero(t, root.ParentID())
+	assert.False(t, root.IsError())
+	assert.Equal(t, "db.query", child.OperationName())
+	assert.Equal(t, root.Context().SpanID(), child.ParentID())
+	assert.True(t, child.IsError())
+	assert.Equal(t, 3.0, child.Tags()["db.rows"])
+	assert.Equal(t, "postgresql", child.Tags()[ext.DBSystem])
+}
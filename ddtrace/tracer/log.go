@@ -8,9 +8,12 @@ package tracer
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"math"
 	"net/http"
+	"os"
 	"runtime"
 	"time"
 
@@ -65,6 +68,20 @@ type startupInfo struct {
 	DataStreamsEnabled          bool                         `json:"data_streams_enabled"`      // Whether Data Streams is enabled
 }
 
+// checkUnixSocket checks that the agent can be reached through the unix domain socket
+// at path, returning an error explaining why it can't be, e.g. when the socket exists
+// but the process isn't allowed to write to it.
+func checkUnixSocket(path string) error {
+	fi, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("socket %s does not exist", path)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot access socket %s: %s", path, err.Error())
+	}
+	return checkSocketAccess(path, fi)
+}
+
 // checkEndpoint tries to connect to the URL specified by endpoint.
 // If the endpoint is not reachable, checkEndpoint returns an error
 // explaining why.
@@ -161,7 +178,15 @@ func logStartup(t *tracer) {
 		info.SampleRateLimit = fmt.Sprintf("%v", limit)
 	}
 	if !t.config.logToStdout {
-		if err := checkEndpoint(t.config.httpClient, t.config.transport.endpoint()); err != nil {
+		var err error
+		if u := t.config.originalAgentURL; u != nil && u.Scheme == "unix" {
+			// diagnose the socket itself, as dialing it only reports a generic error
+			err = checkUnixSocket(u.Path)
+		}
+		if err == nil {
+			err = checkEndpoint(t.config.httpClient, t.config.transport.endpoint())
+		}
+		if err != nil {
 			info.AgentError = fmt.Sprintf("%s", err.Error())
 			log.Warn("DIAGNOSTICS Unable to reach agent intake: %s", err.Error())
 		}
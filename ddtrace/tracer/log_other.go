@@ -0,0 +1,16 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2025 Datadog, Inc.
+
+//go:build !unix
+
+package tracer
+
+import "io/fs"
+
+// checkSocketAccess is a no-op on platforms which don't report the type and the
+// permissions of unix domain sockets.
+func checkSocketAccess(_ string, _ fs.FileInfo) error {
+	return nil
+}
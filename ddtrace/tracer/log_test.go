@@ -8,7 +8,11 @@ package tracer
 import (
 	"fmt"
 	"math"
+	"net"
+	"os"
+	"path/filepath"
 	"regexp"
+	"runtime"
 	"testing"
 
 	"github.com/DataDog/dd-trace-go/v2/internal/globalconfig"
@@ -289,6 +293,50 @@ func TestAgentURL(t *testing.T) {
 	assert.Regexp(`"agent_url":"unix://var/run/datadog/apm.socket"`, logEntry)
 }
 
+func TestCheckUnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix socket permissions are not checked on windows")
+	}
+	dir := t.TempDir()
+
+	t.Run("missing", func(t *testing.T) {
+		err := checkUnixSocket(filepath.Join(dir, "missing.sock"))
+		assert.ErrorContains(t, err, "does not exist")
+	})
+
+	t.Run("not-a-socket", func(t *testing.T) {
+		path := filepath.Join(dir, "file.sock")
+		require.NoError(t, os.WriteFile(path, nil, 0o600))
+		assert.ErrorContains(t, checkUnixSocket(path), "is not a unix socket")
+	})
+
+	t.Run("socket", func(t *testing.T) {
+		path := filepath.Join(dir, "apm.sock")
+		ln, err := net.Listen("unix", path)
+		require.NoError(t, err)
+		defer ln.Close()
+		assert.NoError(t, checkUnixSocket(path))
+
+		if os.Getuid() == 0 {
+			t.Skip("permissions are not enforced for root")
+		}
+		require.NoError(t, os.Chmod(path, 0o400))
+		assert.ErrorContains(t, checkUnixSocket(path), "exists but is not writable by uid")
+	})
+
+	t.Run("startup-log", func(t *testing.T) {
+		tp := new(log.RecordLogger)
+		tracer, err := newTracer(WithLogger(tp), WithUDS(filepath.Join(dir, "missing.sock")))
+		require.NoError(t, err)
+		defer tracer.Stop()
+		tp.Reset()
+		tp.Ignore("appsec: ", "telemetry")
+		logStartup(tracer)
+		logEntry, found := findLogEntry(tp.Logs(), `"agent_error":"socket .*missing.sock does not exist"`)
+		assert.True(t, found, logEntry)
+	})
+}
+
 func TestAgentURLFromEnv(t *testing.T) {
 	assert := assert.New(t)
 	t.Setenv("DD_TRACE_AGENT_URL", "unix://var/run/datadog/apm.socket")
@@ -0,0 +1,29 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2025 Datadog, Inc.
+
+//go:build unix
+
+package tracer
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// checkSocketAccess checks that the file at path, described by fi, is a unix domain
+// socket which the process is allowed to connect to.
+func checkSocketAccess(path string, fi fs.FileInfo) error {
+	if fi.Mode().Type() != fs.ModeSocket {
+		return fmt.Errorf("%s is not a unix socket", path)
+	}
+	// connecting to a socket requires write permission on it
+	if err := unix.Access(path, unix.W_OK); err != nil {
+		return fmt.Errorf("socket %s exists but is not writable by uid %d: %s", path, os.Getuid(), err.Error())
+	}
+	return nil
+}
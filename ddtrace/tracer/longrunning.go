@@ -0,0 +1,158 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/DataDog/dd-trace-go/v2/internal/log"
+)
+
+// longRunningTracker keeps track of the open local traces and periodically sends
+// snapshots of their open spans, see WithLongRunningSpans.
+type longRunningTracker struct {
+	interval time.Duration
+
+	mu     sync.Mutex     // guards below fields
+	traces map[*trace]int // open traces, along with the number of snapshots sent so far
+}
+
+func newLongRunningTracker(interval time.Duration) *longRunningTracker {
+	return &longRunningTracker{
+		interval: interval,
+		traces:   make(map[*trace]int),
+	}
+}
+
+// add starts tracking the trace of a new local root span.
+func (lr *longRunningTracker) add(t *trace) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.traces[t] = 0
+}
+
+// remove stops tracking the trace t, once its local root span finished.
+func (lr *longRunningTracker) remove(t *trace) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	delete(lr.traces, t)
+}
+
+// run sends the snapshots at every interval, until tr is stopped.
+func (lr *longRunningTracker) run(tr *tracer) {
+	ticker := time.NewTicker(lr.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			lr.snapshot(tr, now())
+		case <-tr.stop:
+			return
+		}
+	}
+}
+
+// snapshot submits to tr a snapshot of the open spans of each kept trace which has been
+// running for longer than the interval. The traces are untracked when their root span
+// finishes; the ones found complete here, e.g. finished in the meantime, are untracked too.
+func (lr *longRunningTracker) snapshot(tr *tracer, now int64) {
+	lr.mu.Lock()
+	traces := make([]*trace, 0, len(lr.traces))
+	for t := range lr.traces {
+		traces = append(traces, t)
+	}
+	lr.mu.Unlock()
+
+	for _, t := range traces {
+		t.mu.RLock()
+		root := t.root
+		done := t.full || root == nil || root.finished
+		p, kept := t.samplingPriorityLocked()
+		kept = kept && p > 0
+		open := make([]*Span, 0, len(t.spans)-t.finished)
+		for _, s := range t.spans {
+			if !s.finished {
+				open = append(open, s)
+			}
+		}
+		t.mu.RUnlock()
+		if done {
+			lr.mu.Lock()
+			delete(lr.traces, t)
+			lr.mu.Unlock()
+			continue
+		}
+		if !kept || len(open) == 0 {
+			// snapshots are only sent for the traces which will be kept
+			continue
+		}
+		root.mu.RLock()
+		start := root.start
+		root.mu.RUnlock()
+		if now-start < int64(lr.interval) {
+			continue
+		}
+
+		lr.mu.Lock()
+		lr.traces[t]++
+		version := lr.traces[t]
+		lr.mu.Unlock()
+		spans := make([]*Span, 0, len(open))
+		for _, s := range open {
+			// spans are locked before their trace when finishing, so the trace can't be
+			// locked here; the span is skipped if it finished in the meantime.
+			s.mu.Lock()
+			if !s.finished {
+				// mark the span so that its final version replaces its snapshots
+				s.setMetric(keyWasLongRunning, 1)
+				spans = append(spans, s.snapshot(now, version))
+			}
+			s.mu.Unlock()
+		}
+		if len(spans) == 0 {
+			continue
+		}
+		spans[0].setMetric(keySamplingPriority, float64(p))
+		t.mu.RLock()
+		t.setTraceTags(spans[0])
+		t.mu.RUnlock()
+		select {
+		case tr.out <- &chunk{spans: spans, willSend: true}:
+		default:
+			log.Debug("payload queue full, dropped the snapshot of %d long-running spans", len(spans))
+		}
+	}
+}
+
+// snapshot returns a copy of the open span s, as it is at time now, holding the given
+// snapshot version. s must be locked.
+func (s *Span) snapshot(now int64, version int) *Span {
+	c := &Span{
+		name:       s.name,
+		service:    s.service,
+		resource:   s.resource,
+		spanType:   s.spanType,
+		start:      s.start,
+		duration:   now - s.start,
+		meta:       maps.Clone(s.meta),
+		metaStruct: maps.Clone(s.metaStruct),
+		metrics:    maps.Clone(s.metrics),
+		spanID:     s.spanID,
+		traceID:    s.traceID,
+		parentID:   s.parentID,
+		error:      s.error,
+		spanLinks:  slices.Clone(s.spanLinks),
+		spanEvents: slices.Clone(s.spanEvents),
+		context:    s.context,
+		finished:   true,
+	}
+	delete(c.metrics, keyWasLongRunning)
+	c.setMetric(keyPartialVersion, float64(version))
+	return c
+}
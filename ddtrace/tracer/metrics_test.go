@@ -6,11 +6,14 @@
 package tracer
 
 import (
+	"errors"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
 	globalinternal "github.com/DataDog/dd-trace-go/v2/internal"
@@ -275,6 +278,7 @@ func TestTracerMetrics(t *testing.T) {
 	assert.True(calls["datadog.tracer.flush_triggered"] >= 1)
 	assert.Equal(1, calls["datadog.tracer.flush_duration"])
 	assert.Equal(1, calls["datadog.tracer.flush_bytes"])
+	assert.Equal(1, calls["datadog.tracer.flush_bytes.distribution"])
 	assert.Equal(1, calls["datadog.tracer.flush_traces"])
 	assert.Equal(int64(1), counts["datadog.tracer.flush_traces"])
 	assert.False(tg.Closed())
@@ -306,3 +310,53 @@ func assertSpanMetricCountsAreZero(t *testing.T, metric globalinternal.XSyncMapC
 		assert.Equal(t, int64(0), v)
 	}
 }
+
+func TestTraceMetrics(t *testing.T) {
+	var tg statsdtest.TestStatsdClient
+	tracer, _, _, stop, err := startTestTracer(t, withStatsdClient(&tg), WithTraceMetrics(true), WithServiceVersion("1.2.3"))
+	require.NoError(t, err)
+	defer stop()
+
+	root := tracer.StartSpan("http.request", ResourceName("GET /users"), SpanType(ext.SpanTypeWeb), Tag(ext.HTTPCode, "200"))
+	tracer.StartSpan("internal", ChildOf(root.Context())).Finish()
+	measured := tracer.StartSpan("db.query", ChildOf(root.Context()), ResourceName("SELECT * FROM users WHERE id = 1"), SpanType(ext.SpanTypeSQL), Measured())
+	measured.Finish(WithError(errors.New("timeout")))
+	root.Finish()
+	// the trace metrics are emitted when the stats are flushed
+	tracer.stats.Stop()
+
+	assert.Equal(t, map[string]int{
+		"client.trace.http.request.hits": 1,
+		"client.trace.http.request":      1,
+		"client.trace.db.query.hits":     1,
+		"client.trace.db.query.errors":   1,
+		"client.trace.db.query":          1,
+	}, traceMetricsCalls(&tg))
+	assert.Equal(t, int64(1), tg.Counts()["client.trace.http.request.hits"])
+	assert.Equal(t, int64(1), tg.Counts()["client.trace.db.query.errors"])
+	calls := tg.GetCallsByName("client.trace.http.request")
+	assert.ElementsMatch(t, []string{
+		"service:tracer.test",
+		"span.type:web",
+		"version:1.2.3",
+		"http.status_code:200",
+	}, calls[0].Tags())
+	// the durations are approximated by the sketches of the stats
+	assert.InEpsilon(t, time.Duration(root.duration).Seconds(), calls[0].FloatVal(), 0.01)
+	assert.ElementsMatch(t, []string{
+		"service:tracer.test",
+		"span.type:sql",
+		"version:1.2.3",
+	}, tg.GetCallsByName("client.trace.db.query.errors")[0].Tags())
+}
+
+// traceMetricsCalls returns the number of calls of each trace metric found in tg.
+func traceMetricsCalls(tg *statsdtest.TestStatsdClient) map[string]int {
+	calls := make(map[string]int)
+	for name, n := range tg.CallsByName() {
+		if strings.HasPrefix(name, traceMetricsPrefix) {
+			calls[name] = n
+		}
+	}
+	return calls
+}
@@ -17,6 +17,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"runtime/debug"
 	"strconv"
@@ -124,6 +125,10 @@ type config struct {
 	// debug, when true, writes details to logs.
 	debug bool
 
+	// logLevel is the log level in effect before debug mode was enabled, which is restored
+	// when it is disabled with Reconfigure.
+	logLevel log.Level
+
 	// appsecStartOptions controls the options used when starting appsec features.
 	appsecStartOptions []appsecconfig.StartOption
 
@@ -142,6 +147,19 @@ type config struct {
 	// output instead of using the agent. This is used in Lambda environments.
 	logToStdout bool
 
+	// dryRun discards the finished traces instead of sending them, see WithDryRun.
+	dryRun bool
+
+	// otlpEndpoint, when set, is the OTLP traces endpoint to which finished
+	// spans are sent instead of the agent, using otlpProtocol.
+	otlpEndpoint string
+
+	// otlpProtocol is the protocol of otlpEndpoint, see WithOTLPEndpoint.
+	otlpProtocol OTLPProtocol
+
+	// exporter, when set, receives the finished spans instead of the agent, see WithExporter.
+	exporter SpanExporter
+
 	// sendRetries is the number of times a trace or CI Visibility payload send is retried upon
 	// failure.
 	sendRetries int
@@ -178,6 +196,17 @@ type config struct {
 	// serviceMappings holds a set of service mappings to dynamically rename services
 	serviceMappings map[string]string
 
+	// serviceMappingsByType holds the services of the spans of a given type which
+	// were not given a service, see WithServiceMappingByType.
+	serviceMappingsByType map[string]string
+
+	// measuredSpanTypes holds the span types whose spans are measured, see
+	// WithMeasuredSpanTypes.
+	measuredSpanTypes map[string]struct{}
+
+	// tagRemappings holds the new keys of the span tags to rename, see WithTagRemapper.
+	tagRemappings map[string]string
+
 	// globalTags holds a set of tags that will be automatically applied to
 	// all spans.
 	globalTags dynamicConfig[map[string]interface{}]
@@ -191,9 +220,19 @@ type config struct {
 	// propagator propagates span context cross-process
 	propagator Propagator
 
+	// correlationIDHeader is the correlation ID header injected by the default propagator,
+	// see WithCorrelationIDHeader.
+	correlationIDHeader string
+
 	// httpClient specifies the HTTP client to be used by the agent's transport.
 	httpClient *http.Client
 
+	// agentTransportTuned reports whether the tracer uses a dedicated HTTP transport tuned
+	// with agentMaxIdleConns and agentIdleConnTimeout, see WithAgentTransportTuning.
+	agentTransportTuned  bool
+	agentMaxIdleConns    int
+	agentIdleConnTimeout time.Duration
+
 	// hostname is automatically assigned when the DD_TRACE_REPORT_HOSTNAME is set to true,
 	// and is added as a special tag to the root span of traces.
 	hostname string
@@ -233,6 +272,39 @@ type config struct {
 	// errors will record a stack trace when this option is set.
 	noDebugStack bool
 
+	// errorClassifier decides whether the errors given to the WithError finish option
+	// mark spans as errored, and which message they are tagged with.
+	errorClassifier func(error) (isError bool, msg string)
+
+	// agentResponseHandler is called with the body of the agent responses to trace payloads,
+	// see WithAgentResponseHandler.
+	agentResponseHandler func(body []byte)
+
+	// errorCauseDepth is the number of wrapped error causes tagged on spans, see
+	// WithErrorCauseDepth.
+	errorCauseDepth int
+
+	// samplingDecider, if set, is consulted when local root spans finish and
+	// overrides the sampling priority computed by the samplers when it returns ok.
+	samplingDecider func(*Span) (keep bool, priority int, ok bool)
+
+	// tailSamplingRules make the sampling decision of the traces when their local root
+	// span finishes, see WithTailSamplingRules.
+	tailSamplingRules []tailSamplingRule
+
+	// payloadCaptureLimiter limits the spans whose payloads are captured, see
+	// WithPayloadCapture. Payloads aren't captured when it is nil.
+	payloadCaptureLimiter *rateLimiter
+
+	// payloadCaptureMaxSize is the maximum size of the captured payloads, in bytes.
+	payloadCaptureMaxSize int
+
+	// payloadRedactors redact the captured payloads, see WithPayloadRedactor.
+	payloadRedactors []func(tag string, payload []byte) []byte
+
+	// spanProcessors are notified of the spans started and finished, see WithSpanProcessor.
+	spanProcessors []SpanProcessor
+
 	// profilerHotspots specifies whether profiler Code Hotspots is enabled.
 	profilerHotspots bool
 
@@ -257,6 +329,10 @@ type config struct {
 	// debugAbandonedSpans controls if the tracer should log when old, open spans are found
 	debugAbandonedSpans bool
 
+	// samplingHistory records the sampling priority changes of traces on their root span,
+	// see WithSamplingHistory.
+	samplingHistory bool
+
 	// spanTimeout represents how old a span can be before it should be logged as a possible
 	// misconfiguration
 	spanTimeout time.Duration
@@ -270,12 +346,63 @@ type config struct {
 	// from DD_TRACE_PARTIAL_FLUSH_ENABLED, default false.
 	partialFlushEnabled bool
 
+	// completeTraceSampling delays partial flushes until the root span of the trace
+	// has finished, see WithCompleteTraceSampling.
+	completeTraceSampling bool
+
+	// maxSpanLinks is the maximum number of span links of a single span. Value from
+	// DD_TRACE_SPAN_LINKS_MAX, default 100.
+	maxSpanLinks int
+
+	// traceBufferSize is the number of finished traces which can be buffered before
+	// being handed to the trace writer.
+	traceBufferSize int
+
+	// bufferFullPolicy specifies what to do with finished traces when the buffer is full.
+	bufferFullPolicy BufferFullPolicy
+
+	// idleTimeout is the time without any new trace or data streams checkpoint after
+	// which background flushes are parked. Zero disables it.
+	idleTimeout time.Duration
+
+	// synchronousSubmission reports whether each span is flushed as soon as it finishes.
+	// It is meant for debugging only, see WithSynchronousSubmission.
+	synchronousSubmission bool
+
+	// origin is the origin of the traces started by the tracer, see WithDefaultOrigin.
+	origin string
+
+	// traceMetrics reports whether trace metrics are emitted client-side through
+	// DogStatsD, see WithTraceMetrics.
+	traceMetrics bool
+
+	// maxConcurrentFlushes is the maximum number of trace payloads being sent to the
+	// agent at the same time.
+	maxConcurrentFlushes int
+
+	// maxTraceDuration is the age after which the finished spans of a trace are flushed,
+	// even if the trace is still open. Zero disables it.
+	maxTraceDuration time.Duration
+
+	// longRunningInterval is the interval at which snapshots of the spans of long-running
+	// traces are sent. Zero disables it.
+	longRunningInterval time.Duration
+
 	// statsComputationEnabled enables client-side stats computation (aka trace metrics).
 	statsComputationEnabled bool
 
 	// dataStreamsMonitoringEnabled specifies whether the tracer should enable monitoring of data streams
 	dataStreamsMonitoringEnabled bool
 
+	// dataStreamsEndpointPath overrides the agent path which data streams stats are posted to.
+	dataStreamsEndpointPath string
+
+	// dataStreamsHeaders holds extra headers added to the data streams stats requests.
+	dataStreamsHeaders map[string]string
+
+	// dataStreamsCompressionDisabled reports whether data streams stats are sent uncompressed.
+	dataStreamsCompressionDisabled bool
+
 	// orchestrionCfg holds Orchestrion (aka auto-instrumentation) configuration.
 	// Only used for telemetry currently.
 	orchestrionCfg orchestrionConfig
@@ -289,6 +416,16 @@ type config struct {
 	// headerAsTags holds the header as tags configuration.
 	headerAsTags dynamicConfig[[]string]
 
+	// baggageTagKeys holds the baggage keys which are copied as span tags onto the local
+	// root span of a trace extracted from a carrier. "*" copies all of them.
+	baggageTagKeys []string
+
+	// processTagKeys holds the keys of the process tags copied as span tags onto local root spans.
+	processTagKeys []string
+
+	// ignoreResources holds the resource patterns of the root spans whose traces are dropped.
+	ignoreResources []*regexp.Regexp
+
 	// dynamicInstrumentationEnabled controls if the target application can be modified by Dynamic Instrumentation or not.
 	// Value from DD_DYNAMIC_INSTRUMENTATION_ENABLED, default false.
 	dynamicInstrumentationEnabled bool
@@ -342,6 +479,9 @@ const maxPropagatedTagsLength = 512
 // partialFlushMinSpansDefault is the default number of spans for partial flushing, if enabled.
 const partialFlushMinSpansDefault = 1000
 
+// defaultMaxSpanLinks is the default maximum number of span links of a single span.
+const defaultMaxSpanLinks = 100
+
 // newConfig renders the tracer configuration based on defaults, environment variables
 // and passed user opts.
 func newConfig(opts ...StartOption) (*config, error) {
@@ -370,6 +510,7 @@ func newConfig(opts ...StartOption) (*config, error) {
 	}
 	c.globalSampleRate = sampleRate
 	c.httpClientTimeout = time.Second * 10 // 10 seconds
+	c.payloadCaptureMaxSize = defaultPayloadCaptureMaxSize
 
 	c.traceRateLimitPerSecond = defaultRateLimit
 	origin := telemetry.OriginDefault
@@ -423,6 +564,12 @@ func newConfig(opts ...StartOption) (*config, error) {
 		internal.ForEachStringTag(v, internal.DDTagsDelimiter, func(key, val string) { WithServiceMapping(key, val)(c) })
 	}
 	c.headerAsTags = newDynamicConfig("trace_header_tags", nil, setHeaderTags, equalSlice[string])
+	if v := os.Getenv("DD_TRACE_BAGGAGE_TAG_KEYS"); v != "" {
+		c.baggageTagKeys = parseTagKeys(strings.Split(v, ","))
+	}
+	if v := os.Getenv("DD_TRACE_IGNORE_RESOURCES"); v != "" {
+		c.ignoreResources = parseIgnoreResources(strings.Split(v, ","))
+	}
 	if v := os.Getenv("DD_TRACE_HEADER_TAGS"); v != "" {
 		c.headerAsTags.update(strings.Split(v, ","), telemetry.OriginEnvVar)
 		// Required to ensure that the startup header tags are set on reset.
@@ -464,8 +611,16 @@ func newConfig(opts ...StartOption) (*config, error) {
 	if c.debugAbandonedSpans {
 		c.spanTimeout = internal.DurationEnv("DD_TRACE_ABANDONED_SPAN_TIMEOUT", 10*time.Minute)
 	}
+	c.samplingHistory = internal.BoolEnv("DD_TRACE_SAMPLING_HISTORY_ENABLED", false)
 	c.statsComputationEnabled = internal.BoolEnv("DD_TRACE_STATS_COMPUTATION_ENABLED", true)
 	c.dataStreamsMonitoringEnabled, _, _ = stableconfig.Bool("DD_DATA_STREAMS_ENABLED", false)
+	c.traceBufferSize = payloadQueueSize
+	c.maxConcurrentFlushes = concurrentConnectionLimit
+	c.maxSpanLinks = internal.IntEnv("DD_TRACE_SPAN_LINKS_MAX", defaultMaxSpanLinks)
+	if c.maxSpanLinks < 0 {
+		log.Warn("DD_TRACE_SPAN_LINKS_MAX=%d is not a valid value, setting to default %d", c.maxSpanLinks, defaultMaxSpanLinks)
+		c.maxSpanLinks = defaultMaxSpanLinks
+	}
 	c.partialFlushEnabled = internal.BoolEnv("DD_TRACE_PARTIAL_FLUSH_ENABLED", false)
 	c.partialFlushMinSpans = internal.IntEnv("DD_TRACE_PARTIAL_FLUSH_MIN_SPANS", partialFlushMinSpansDefault)
 	if c.partialFlushMinSpans <= 0 {
@@ -504,11 +659,13 @@ func newConfig(opts ...StartOption) (*config, error) {
 		c.agentURL = internal.AgentURLFromEnv()
 	}
 	c.originalAgentURL = c.agentURL // Preserve the original agent URL for logging
-	if c.httpClient == nil || orchestrion.Enabled() {
+	if c.httpClient == nil || orchestrion.Enabled() || c.agentTransportTuned {
 		if orchestrion.Enabled() && c.httpClient != nil {
 			// Make sure we don't create http client traces from inside the tracer by using our http client
 			// TODO(eliott.bouhana): remove once dd:no-span is implemented
 			log.Debug("Orchestrion is enabled, but a custom HTTP client was provided to tracer.Start. This is not supported and will be ignored.")
+		} else if c.httpClient != nil {
+			log.Warn("Both WithHTTPClient and WithAgentTransportTuning were provided to tracer.Start. The HTTP client will be ignored.")
 		}
 		if c.agentURL.Scheme == "unix" {
 			// If we're connecting over UDS we can just rely on the agent to provide the hostname
@@ -522,6 +679,13 @@ func newConfig(opts ...StartOption) (*config, error) {
 		} else {
 			c.httpClient = defaultHTTPClient(c.httpClientTimeout)
 		}
+		if c.agentTransportTuned {
+			t := c.httpClient.Transport.(*http.Transport)
+			// all the connections go to the agent, so they can all be kept for the same host
+			t.MaxIdleConns = c.agentMaxIdleConns
+			t.MaxIdleConnsPerHost = c.agentMaxIdleConns
+			t.IdleConnTimeout = c.agentIdleConnTimeout
+		}
 	}
 	WithGlobalTag(ext.RuntimeID, globalconfig.RuntimeID())(c)
 	globalTags := c.globalTags.get()
@@ -566,12 +730,14 @@ func newConfig(opts ...StartOption) (*config, error) {
 			maxLen = maxPropagatedTagsLength
 		}
 		c.propagator = NewPropagator(&PropagatorConfig{
-			MaxTagsHeaderLen: maxLen,
+			MaxTagsHeaderLen:    maxLen,
+			CorrelationIDHeader: c.correlationIDHeader,
 		})
 	}
 	if c.logger != nil {
 		log.UseLogger(c.logger)
 	}
+	c.logLevel = log.GetLevel()
 	if c.debug {
 		log.SetLevel(log.LevelDebug)
 	}
@@ -586,8 +752,8 @@ func newConfig(opts ...StartOption) (*config, error) {
 		c.ciVisibilityAgentless = ciTransport.agentless
 	}
 
-	// if using stdout or traces are disabled or we are in ci visibility agentless mode, agent is disabled
-	agentDisabled := c.logToStdout || !c.enabled.current || c.ciVisibilityAgentless
+	// if using stdout or an OTLP endpoint, or traces are disabled or we are in ci visibility agentless mode, agent is disabled
+	agentDisabled := c.logToStdout || c.otlpEndpoint != "" || c.exporter != nil || !c.enabled.current || c.ciVisibilityAgentless
 	c.agent = loadAgentFeatures(agentDisabled, c.agentURL, c.httpClient)
 	info, ok := debug.ReadBuildInfo()
 	if !ok {
@@ -686,8 +852,8 @@ func udsClient(socketPath string, timeout time.Duration) *http.Client {
 					Net:  "unix",
 				}).String())
 			},
-			MaxIdleConns:          100,
-			IdleConnTimeout:       90 * time.Second,
+			MaxIdleConns:          defaultAgentMaxIdleConns,
+			IdleConnTimeout:       defaultAgentIdleConnTimeout,
 			TLSHandshakeTimeout:   10 * time.Second,
 			ExpectContinueTimeout: 1 * time.Second,
 		},
@@ -697,6 +863,10 @@ func udsClient(socketPath string, timeout time.Duration) *http.Client {
 
 // defaultDogstatsdAddr returns the default connection address for Dogstatsd.
 func defaultDogstatsdAddr() string {
+	if socket := os.Getenv("DD_DOGSTATSD_SOCKET"); socket != "" {
+		// an explicitly configured socket takes precedence over the host and port
+		return "unix://" + socket
+	}
 	envHost, envPort := os.Getenv("DD_DOGSTATSD_HOST"), os.Getenv("DD_DOGSTATSD_PORT")
 	if envHost == "" {
 		envHost = os.Getenv("DD_AGENT_HOST")
@@ -828,6 +998,39 @@ func MarkIntegrationImported(integration string) bool {
 	return true
 }
 
+// IntegrationInfo describes a Datadog integration as seen by the running tracer.
+type IntegrationInfo struct {
+	// Name is the name of the integration, e.g. "Redigo".
+	Name string
+	// Version is the version of the instrumented library found in the binary, if any.
+	Version string
+	// Enabled reports whether the integration package was imported, and so can trace
+	// the library.
+	Enabled bool
+	// Available reports whether the instrumented library is part of the binary.
+	Available bool
+}
+
+// Integrations returns the Datadog integrations known to the running tracer, keyed by
+// name. It holds the same information as the one reported in the startup logs, and
+// returns nil if the tracer is not started.
+func Integrations() map[string]IntegrationInfo {
+	t, ok := getGlobalTracer().(*tracer)
+	if !ok {
+		return nil
+	}
+	infos := make(map[string]IntegrationInfo, len(t.config.integrations))
+	for name, conf := range t.config.integrations {
+		infos[name] = IntegrationInfo{
+			Name:      name,
+			Version:   conf.Version,
+			Enabled:   conf.Instrumented,
+			Available: conf.Available,
+		}
+	}
+	return infos
+}
+
 func (c *config) loadContribIntegrations(deps []*debug.Module) {
 	integrations := map[string]integrationConfig{}
 	for _, s := range contribIntegrations {
@@ -941,6 +1144,72 @@ func WithDebugStack(enabled bool) StartOption {
 	}
 }
 
+// WithErrorClassifier sets a function which decides, for every error given to the WithError
+// finish option, whether it marks the span as errored and which error message is tagged.
+// An empty msg keeps the message of the error. It allows a single policy to apply to all
+// the integrations, e.g. not reporting context.Canceled as an error:
+//
+//	tracer.WithErrorClassifier(func(err error) (bool, string) {
+//		return !errors.Is(err, context.Canceled), ""
+//	})
+func WithErrorClassifier(fn func(err error) (isError bool, msg string)) StartOption {
+	return func(c *config) {
+		c.errorClassifier = fn
+	}
+}
+
+// WithErrorCauseDepth makes spans finished with an error, or tagged with one as ext.Error,
+// record up to depth causes of the error chain built with fmt.Errorf and %w, or errors.Join,
+// so that the context of a generic top-level error message isn't lost. The message and type
+// of the Nth cause are tagged as error.cause.N and error.cause.N.type. It is disabled by
+// default, or when depth is lower than or equal to zero.
+func WithErrorCauseDepth(depth int) StartOption {
+	return func(c *config) {
+		c.errorCauseDepth = max(depth, 0)
+	}
+}
+
+// WithSamplingDecider sets a function which makes the sampling decision of a trace from
+// business logic which the sampling rules can't express. It is called when the local root
+// span finishes, so the tags set during the request are available. When it returns ok,
+// its decision takes precedence over the one of the samplers and rules: the trace is kept
+// with the given priority if keep is true, or dropped otherwise. A priority which doesn't
+// match keep, or an automatic one, is replaced by ext.PriorityUserKeep or
+// ext.PriorityUserReject. The decider isn't called for the traces kept or dropped manually,
+// e.g. with ext.ManualKeep. The decision can't be changed anymore once the trace context
+// was propagated to another service.
+func WithSamplingDecider(fn func(*Span) (keep bool, priority int, ok bool)) StartOption {
+	return func(c *config) {
+		c.samplingDecider = fn
+	}
+}
+
+// SpanProcessor is notified of the spans started and finished by the tracer, so that
+// spans can be enriched or redacted centrally, e.g. to add ownership tags or to remove
+// personal data, whichever integration started them. See WithSpanProcessor.
+type SpanProcessor interface {
+	// OnStart is called when a span starts, once the tracer has set its tags.
+	OnStart(s *Span)
+
+	// OnFinish is called when a span finishes, before the sampling decision of its
+	// trace is made and before it is sent, so that its tags can still be changed. The
+	// trace of the span can be dropped by setting the ext.ManualDrop tag on it.
+	OnFinish(s *Span)
+}
+
+// WithSpanProcessor registers a SpanProcessor, called synchronously from the goroutines
+// starting and finishing spans, so it must be fast and safe for concurrent use. It can be
+// used multiple times, the processors being called in the order they were registered.
+func WithSpanProcessor(p SpanProcessor) StartOption {
+	return func(c *config) {
+		if p == nil {
+			log.Warn("ignoring nil span processor")
+			return
+		}
+		c.spanProcessors = append(c.spanProcessors, p)
+	}
+}
+
 // WithDebugMode enables debug mode on the tracer, resulting in more verbose logging.
 func WithDebugMode(enabled bool) StartOption {
 	return func(c *config) {
@@ -949,6 +1218,102 @@ func WithDebugMode(enabled bool) StartOption {
 	}
 }
 
+// WithTailSamplingRules sets rules which make the sampling decision of a trace from the
+// whole trace, e.g. to keep all the traces with errors and 1% of the others:
+//
+//	tracer.WithTailSamplingRules(
+//		tracer.TailSamplingRule{Error: true, Rate: 1},
+//		tracer.TailSamplingRule{Rate: 0.01},
+//	)
+//
+// The rules are evaluated in order when the local root span finishes, on the spans of the
+// trace which have finished by then, and the first one matching the trace decides whether
+// it is kept, overriding the decision of the sampling rules and of the sample rate. No rate
+// limit applies. The decision can't be changed anymore once the trace context was propagated
+// to another service or once a part of the trace was flushed, in which case the rules are
+// skipped. They are skipped as well for the traces kept or dropped manually, e.g. with
+// ext.ManualKeep. A WithSamplingDecider decision takes precedence over the rules. It can
+// be used multiple times.
+func WithTailSamplingRules(rules ...TailSamplingRule) StartOption {
+	return func(c *config) {
+		for _, r := range rules {
+			if r.Rate < 0 || r.Rate > 1 {
+				log.Warn("ignoring tail sampling rule with rate %f: it must be between 0 and 1", r.Rate)
+				continue
+			}
+			c.tailSamplingRules = append(c.tailSamplingRules, newTailSamplingRule(r))
+		}
+	}
+}
+
+// WithPayloadCapture enables the capture of payload snippets, such as the bodies of HTTP
+// requests and responses or the replies to Redis commands, by the integrations supporting
+// it, for at most perSecond spans per second. Payloads may hold sensitive data: see
+// WithPayloadRedactor to redact them. It is a debugging aid, disabled by default. See
+// Span.CapturePayload.
+func WithPayloadCapture(perSecond float64) StartOption {
+	return func(c *config) {
+		if perSecond <= 0 {
+			log.Warn("ignoring payload capture rate %f: it must be positive", perSecond)
+			return
+		}
+		c.payloadCaptureLimiter = newRateLimiter(perSecond)
+	}
+}
+
+// WithPayloadCaptureMaxSize sets the maximum size in bytes of the payload snippets captured
+// when WithPayloadCapture is enabled; longer payloads are truncated. Defaults to 1024.
+func WithPayloadCaptureMaxSize(n int) StartOption {
+	return func(c *config) {
+		if n <= 0 {
+			log.Warn("ignoring payload capture maximum size %d: it must be positive", n)
+			return
+		}
+		c.payloadCaptureMaxSize = n
+	}
+}
+
+// WithPayloadRedactor adds a function which redacts the payloads captured when
+// WithPayloadCapture is enabled, before they are truncated and set on the span under tag.
+// It returns the redacted payload, and must not modify payload in place. It can be used multiple
+// times, the functions being called in the order they were added.
+func WithPayloadRedactor(fn func(tag string, payload []byte) []byte) StartOption {
+	return func(c *config) {
+		if fn == nil {
+			log.Warn("ignoring nil payload redactor")
+			return
+		}
+		c.payloadRedactors = append(c.payloadRedactors, fn)
+	}
+}
+
+// WithSamplingHistory enables the recording of every change of the sampling priority of a
+// trace, e.g. when the priority extracted from the traceparent header is overridden by the
+// one of the tracestate header, or when a sampling rule drops the trace. The changes are
+// tagged on the root span as _dd.sampling.history, a comma-separated list of entries of
+// the form "<priority>:<sampler>", where sampler is a samplernames.SamplerName value,
+// e.g. "1:-1,2:-1,-1:3". It is a debug mode, disabled by default. It can also be enabled
+// with the DD_TRACE_SAMPLING_HISTORY_ENABLED environment variable.
+func WithSamplingHistory(enabled bool) StartOption {
+	return func(c *config) {
+		c.samplingHistory = enabled
+	}
+}
+
+// WithDryRun enables the dry run mode, in which the tracer samples, propagates and
+// finishes traces as usual, but discards them instead of sending them. The sampling
+// decision of every trace which would be sent is counted in the datadog.tracer.dry_run.traces
+// health metric, tagged with its sampling_priority, and logged in debug mode. It is meant
+// to validate a new sampling or propagation configuration against real traffic without
+// sending data. The features of the agent and the sampling rates it computes are still
+// used, so that traces are sampled like they would be: the tracer keeps polling the agent
+// for the rates, with empty payloads, and computes client-side stats without sending them.
+func WithDryRun(enabled bool) StartOption {
+	return func(c *config) {
+		c.dryRun = enabled
+	}
+}
+
 // WithLambdaMode enables lambda mode on the tracer, for use with AWS Lambda.
 // This option is only required if the the Datadog Lambda Extension is not
 // running.
@@ -974,6 +1339,17 @@ func WithRetryInterval(interval int) StartOption {
 	}
 }
 
+// WithCorrelationIDHeader makes the default propagator inject a header with the given name,
+// e.g. "X-Correlation-ID", holding the hex encoded trace ID alongside the trace context
+// headers, so systems which only correlate on such an ID can be linked to the traces.
+// To choose another format, or to continue the trace of an incoming correlation ID,
+// configure a propagator with PropagatorConfig.CorrelationIDHeader and WithPropagator.
+func WithCorrelationIDHeader(name string) StartOption {
+	return func(c *config) {
+		c.correlationIDHeader = name
+	}
+}
+
 // WithPropagator sets an alternative propagator to be used by the tracer.
 func WithPropagator(p Propagator) StartOption {
 	return func(c *config) {
@@ -1041,6 +1417,17 @@ func WithAgentURL(agentURL string) StartOption {
 	}
 }
 
+// WithAgentResponseHandler sets a function which is called with the raw body of the response
+// of the agent to each trace payload sent successfully, before the tracer reads the sampling
+// rates it holds. It lets custom trace-routing agents and control planes push sampling
+// adjustments or other directives to the application. The function is called from the
+// goroutine flushing the payload, and must neither block nor retain body after returning.
+func WithAgentResponseHandler(fn func(body []byte)) StartOption {
+	return func(c *config) {
+		c.agentResponseHandler = fn
+	}
+}
+
 // WithAgentTimeout sets the timeout for the agent connection. Timeout is in seconds.
 func WithAgentTimeout(timeout int) StartOption {
 	return func(c *config) {
@@ -1067,6 +1454,64 @@ func WithServiceMapping(from, to string) StartOption {
 	}
 }
 
+// WithServiceMappingByType sets the service of the spans of the given types, e.g.
+// {ext.SpanTypeRedis: "redis"}, unless they were given a service explicitly, with
+// ServiceName or the ext.ServiceName tag. The spans of integrations are mapped as well
+// when they have the default service of their integration, such as "redis.client", or of
+// the tracer, so that the services of the integrations can be standardized. The mapping
+// is applied when the span finishes, so it takes into account span types set after the
+// span started. It can be used multiple times.
+func WithServiceMappingByType(mappings map[string]string) StartOption {
+	return func(c *config) {
+		if c.serviceMappingsByType == nil {
+			c.serviceMappingsByType = make(map[string]string, len(mappings))
+		}
+		for spanType, service := range mappings {
+			c.serviceMappingsByType[spanType] = service
+		}
+	}
+}
+
+// WithMeasuredSpanTypes marks the spans of the given types, e.g. ext.SpanTypeRedis, as
+// measured, so that trace metrics are computed for them as if they were started with the
+// Measured option. Like WithServiceMappingByType, it is applied when the span finishes, so
+// it takes into account span types set after the span started. It can be used multiple
+// times.
+func WithMeasuredSpanTypes(types ...string) StartOption {
+	return func(c *config) {
+		if c.measuredSpanTypes == nil {
+			c.measuredSpanTypes = make(map[string]struct{}, len(types))
+		}
+		for _, typ := range types {
+			c.measuredSpanTypes[typ] = struct{}{}
+		}
+	}
+}
+
+// WithTagRemapper renames the tags of every span when it finishes, from the keys of
+// mappings to their values, e.g. {"http.status": ext.HTTPCode}. It applies to the tags
+// set by integrations and by the application alike, so tag conventions can be migrated
+// without changing each call site. The tags are renamed like they would be set with
+// SetTag, so that e.g. a tag renamed to ext.ResourceName sets the resource of the span.
+// The mappings are resolved against the tags of the span before any of them is renamed,
+// so they don't chain: {"a": "b", "b": "a"} swaps the two tags. When a span holds both a
+// tag and its new key, the value already set under the new key is kept. Internal tags,
+// prefixed with "_dd.", are never renamed. It can be used multiple times.
+func WithTagRemapper(mappings map[string]string) StartOption {
+	return func(c *config) {
+		if c.tagRemappings == nil {
+			c.tagRemappings = make(map[string]string, len(mappings))
+		}
+		for from, to := range mappings {
+			if strings.HasPrefix(from, "_dd.") || strings.HasPrefix(to, "_dd.") {
+				log.Warn("ignoring tag remapping %q to %q: internal tags can't be renamed", from, to)
+				continue
+			}
+			c.tagRemappings[from] = to
+		}
+	}
+}
+
 // WithPeerServiceDefaults sets default calculation for peer.service.
 // Related documentation: https://docs.datadoghq.com/tracing/guide/inferred-service-opt-in/?tab=go#apm-tracer-configuration
 func WithPeerServiceDefaults(enabled bool) StartOption {
@@ -1098,6 +1543,44 @@ func WithGlobalTag(k string, v interface{}) StartOption {
 	}
 }
 
+// kubernetesMetadataEnv maps the environment variables commonly set from the Kubernetes
+// downward API to the tags they are reported as, see WithKubernetesMetadata.
+var kubernetesMetadataEnv = []struct{ env, tag string }{
+	{"POD_NAME", "kube_pod"},
+	{"POD_NAMESPACE", "kube_namespace"},
+	{"NODE_NAME", "node_name"},
+}
+
+// WithKubernetesMetadata enables tagging all spans with the pod name, pod namespace and node
+// name read from the POD_NAME, POD_NAMESPACE and NODE_NAME environment variables, which are
+// usually set from the Kubernetes downward API, e.g.:
+//
+//	env:
+//	- name: POD_NAME
+//	  valueFrom:
+//	    fieldRef:
+//	      fieldPath: metadata.name
+//
+// They are reported as the kube_pod, kube_namespace and node_name global tags, unless these
+// tags are already set.
+func WithKubernetesMetadata(enabled bool) StartOption {
+	return func(c *config) {
+		if !enabled {
+			return
+		}
+		for _, m := range kubernetesMetadataEnv {
+			v := os.Getenv(m.env)
+			if v == "" {
+				continue
+			}
+			if _, ok := c.globalTags.get()[m.tag]; ok {
+				continue
+			}
+			WithGlobalTag(m.tag, v)(c)
+		}
+	}
+}
+
 // initGlobalTags initializes the globalTags config with the provided init value
 func (c *config) initGlobalTags(init map[string]interface{}, origin telemetry.Origin) {
 	apply := func(map[string]interface{}) bool {
@@ -1126,6 +1609,58 @@ func WithSamplerRate(rate float64) StartOption {
 	}
 }
 
+// WithTraceRateLimit sets the maximum number of traces per second which are kept by the
+// sampling rules and DD_TRACE_SAMPLE_RATE, overriding DD_TRACE_RATE_LIMIT. It defaults to 100.
+// Negative values are ignored. See RateLimiterStats to monitor how often the limit is reached.
+func WithTraceRateLimit(perSecond float64) StartOption {
+	return func(c *config) {
+		if perSecond < 0.0 {
+			log.Warn("ignoring negative trace rate limit %f", perSecond)
+			return
+		}
+		telemetry.RegisterAppConfig("trace_rate_limit", perSecond, telemetry.OriginCode)
+		c.traceRateLimitPerSecond = perSecond
+	}
+}
+
+// WithOTLPExporter sends finished spans to the given OTLP/HTTP traces endpoint
+// (e.g. "http://localhost:4318/v1/traces") encoded as OTLP protobuf, instead of
+// sending them to the Datadog agent. Traces which were not kept by sampling are
+// dropped by the tracer, since there is no agent to do it. It is equivalent to
+// WithOTLPEndpoint(endpoint, OTLPProtocolHTTPProtobuf).
+func WithOTLPExporter(endpoint string) StartOption {
+	return WithOTLPEndpoint(endpoint, OTLPProtocolHTTPProtobuf)
+}
+
+// WithOTLPEndpoint sends finished spans to the given OTLP traces endpoint using the given
+// protocol, e.g. to an OpenTelemetry Collector, instead of sending them to the Datadog agent.
+// The Datadog span fields are mapped to OTLP span attributes, such as operation.name,
+// resource.name and sampling.priority, and the service, env and version to resource
+// attributes. Traces which were not kept by sampling are dropped by the tracer, since
+// there is no agent to do it. Unknown protocols fall back to OTLPProtocolHTTPProtobuf.
+func WithOTLPEndpoint(endpoint string, protocol OTLPProtocol) StartOption {
+	return func(c *config) {
+		switch protocol {
+		case OTLPProtocolHTTPProtobuf, OTLPProtocolGRPC:
+		default:
+			log.Warn("Unknown OTLP protocol %q, using %q.", protocol, OTLPProtocolHTTPProtobuf)
+			protocol = OTLPProtocolHTTPProtobuf
+		}
+		c.otlpEndpoint = endpoint
+		c.otlpProtocol = protocol
+	}
+}
+
+// WithExporter hands the finished spans over to the given exporter, instead of sending
+// them to the Datadog agent, so they can be shipped to alternative backends. Traces
+// which were not kept by sampling are dropped by the tracer, since there is no agent to
+// do it. The exporter is shut down when the tracer stops.
+func WithExporter(exporter SpanExporter) StartOption {
+	return func(c *config) {
+		c.exporter = exporter
+	}
+}
+
 // WithHTTPClient specifies the HTTP client to use when emitting spans to the agent.
 func WithHTTPClient(client *http.Client) StartOption {
 	return func(c *config) {
@@ -1133,6 +1668,27 @@ func WithHTTPClient(client *http.Client) StartOption {
 	}
 }
 
+// WithAgentTransportTuning makes the tracer communicate with the agent through a dedicated
+// HTTP transport, which keeps up to maxIdle idle connections to the agent and closes them
+// after being idle for idleTimeout. It isolates the submission of traces from the HTTP
+// client configuration of the application, and takes precedence over WithHTTPClient.
+// A maxIdle or idleTimeout lower than or equal to zero uses the default of 100 connections
+// or 90 seconds respectively. Unlike the default HTTP client, all the idle connections
+// can be kept for the agent, instead of 2 per host.
+func WithAgentTransportTuning(maxIdle int, idleTimeout time.Duration) StartOption {
+	return func(c *config) {
+		if maxIdle <= 0 {
+			maxIdle = defaultAgentMaxIdleConns
+		}
+		if idleTimeout <= 0 {
+			idleTimeout = defaultAgentIdleConnTimeout
+		}
+		c.agentTransportTuned = true
+		c.agentMaxIdleConns = maxIdle
+		c.agentIdleConnTimeout = idleTimeout
+	}
+}
+
 // WithUDS configures the HTTP client to dial the Datadog Agent via the specified Unix Domain Socket path.
 func WithUDS(socketPath string) StartOption {
 	return func(c *config) {
@@ -1177,6 +1733,7 @@ func WithRuntimeMetrics() StartOption {
 // WithDogstatsdAddr specifies the address to connect to for sending metrics to the Datadog
 // Agent. It should be a "host:port" string, or the path to a unix domain socket.If not set, it
 // attempts to determine the address of the statsd service according to the following rules:
+//  0. Use the unix domain socket path set by DD_DOGSTATSD_SOCKET, if any. IF NOT, continue to #1.
 //  1. Look for /var/run/datadog/dsd.socket and use it if present. IF NOT, continue to #2.
 //  2. The host is determined by DD_AGENT_HOST, and defaults to "localhost"
 //  3. The port is retrieved from the agent. If not present, it is determined by DD_DOGSTATSD_PORT, and defaults to 8125
@@ -1189,6 +1746,14 @@ func WithDogstatsdAddr(addr string) StartOption {
 	}
 }
 
+// WithDogstatsdUnixSocket specifies the path of the unix domain socket to connect to for
+// sending runtime and health metrics to the Datadog Agent, e.g. "/var/run/datadog/dsd.socket".
+// It is a shorthand for WithDogstatsdAddr("unix://" + path), and takes precedence over the
+// DD_DOGSTATSD_SOCKET environment variable.
+func WithDogstatsdUnixSocket(path string) StartOption {
+	return WithDogstatsdAddr("unix://" + path)
+}
+
 // WithSamplingRules specifies the sampling rates to apply to spans based on the
 // provided rules.
 func WithSamplingRules(rules []SamplingRule) StartOption {
@@ -1285,6 +1850,64 @@ func WithDebugSpansMode(timeout time.Duration) StartOption {
 	}
 }
 
+// WithIdleTimeout parks the tracer's trace flushing loop and the data streams processor
+// once they haven't seen any new trace or checkpoint for d, instead of waking up at every
+// flush interval. They resume on the next trace or checkpoint, and only park after all the
+// data they buffered was flushed, so nothing is lost. It is meant for serverless and
+// scale-to-zero environments, where these wake-ups keep idle containers busy.
+// It is disabled by default; values lower than zero are ignored.
+func WithIdleTimeout(d time.Duration) StartOption {
+	return func(c *config) {
+		if d < 0 {
+			log.Warn("ignoring idle timeout %s: it must not be negative", d)
+			return
+		}
+		c.idleTimeout = d
+	}
+}
+
+// WithSynchronousSubmission makes every finished span be flushed right away, together with
+// the other finished spans of its trace, instead of being buffered until its trace completes
+// and the next periodic flush happens. Span.Finish only returns once the flush was handed
+// over to the trace writer, which makes span-content issues easy to reproduce and inspect
+// locally or in tests. The sampling decision of a trace is still made when its local root
+// finishes, from all of its spans, as it is without this option.
+//
+// This option is meant for debugging only and must not be used in production: it sends one
+// payload per span and adds the latency of a flush to every call to Span.Finish.
+func WithSynchronousSubmission(enabled bool) StartOption {
+	return func(c *config) {
+		if enabled {
+			log.Warn("Synchronous span submission is enabled: it is meant for debugging only and slows down every span.Finish call")
+		}
+		c.synchronousSubmission = enabled
+	}
+}
+
+// WithDefaultOrigin sets the origin of the traces started by the tracer, e.g. "synthetics",
+// which the backend uses to route them. It doesn't apply to traces continued from an extracted
+// span context, which keep their own origin, and it is overridden by WithOrigin. Characters
+// which can't be propagated are replaced with underscores.
+func WithDefaultOrigin(origin string) StartOption {
+	return func(c *config) {
+		c.origin = sanitizeOrigin(origin)
+	}
+}
+
+// WithTraceMetrics enables emitting trace metrics client-side through DogStatsD, before
+// spans are dropped by client-side sampling, so that the metrics account for 100% of the
+// traffic even when traces are sampled. They are computed from the stats aggregated by the
+// tracer for the top level or measured spans: every time the stats are flushed, it emits the
+// client.trace.<operation>.hits and client.trace.<operation>.errors counts and the
+// client.trace.<operation> distribution of durations in seconds, tagged with the service,
+// span.type, version and http.status_code of the spans. They mirror the trace.<operation>
+// metrics computed by the agent from the spans it receives. It is disabled by default.
+func WithTraceMetrics(enabled bool) StartOption {
+	return func(c *config) {
+		c.traceMetrics = enabled
+	}
+}
+
 // WithPartialFlushing enables flushing of partially finished traces.
 // This is done after "numSpans" have finished in a single local trace at
 // which point all finished spans in that trace will be flushed, freeing up
@@ -1292,6 +1915,10 @@ func WithDebugSpansMode(timeout time.Duration) StartOption {
 // DD_TRACE_PARTIAL_FLUSH_ENABLED to true, which will default to 1000 spans
 // unless overriden with DD_TRACE_PARTIAL_FLUSH_MIN_SPANS. Partial flushing
 // is disabled by default.
+//
+// The first partial flush of a trace locks down its sampling priority, so that all of its
+// chunks carry the same sampling decision. See WithCompleteTraceSampling to delay partial
+// flushes until the decision is final.
 func WithPartialFlushing(numSpans int) StartOption {
 	return func(c *config) {
 		c.partialFlushEnabled = true
@@ -1299,6 +1926,124 @@ func WithPartialFlushing(numSpans int) StartOption {
 	}
 }
 
+// WithCompleteTraceSampling delays the partial flushes of a trace, triggered by
+// WithPartialFlushing or WithMaxTraceDuration, until its root span has finished. This lets
+// the sampling decision change until the root finishes, e.g. when the root is tagged with
+// ext.ManualKeep at the end of a request, instead of being locked down by the first partial
+// flush. The tradeoff is memory: the finished spans of a trace are held until its root
+// finishes, as if partial flushing was disabled. It is disabled by default.
+func WithCompleteTraceSampling(enabled bool) StartOption {
+	return func(c *config) {
+		c.completeTraceSampling = enabled
+	}
+}
+
+// WithMaxSpanLinks sets the maximum number of span links a single span can hold,
+// whether they are set when starting the span or added later with Span.AddLink. The
+// links in excess are dropped and counted in the "_dd.span_links.dropped" metric of
+// the span, preventing a runaway loop from bloating the payloads. It can also be set
+// with DD_TRACE_SPAN_LINKS_MAX. Defaults to 100.
+func WithMaxSpanLinks(n int) StartOption {
+	return func(c *config) {
+		if n < 0 {
+			log.Warn("ignoring maximum span links %d: it must not be negative", n)
+			return
+		}
+		c.maxSpanLinks = n
+	}
+}
+
+// WithTraceBufferSize sets the number of finished traces the tracer can buffer while
+// they wait to be encoded and sent to the agent. Once the buffer is full, traces are
+// handled according to the BufferFullPolicy. A bigger buffer trades memory for fewer
+// dropped traces under bursts of load. Defaults to 1000.
+func WithTraceBufferSize(n int) StartOption {
+	return func(c *config) {
+		if n <= 0 {
+			log.Warn("ignoring trace buffer size %d: it must be positive", n)
+			return
+		}
+		c.traceBufferSize = n
+	}
+}
+
+// WithMaxConcurrentFlushes sets the maximum number of trace payloads which are sent to the
+// agent at the same time, bounding the connections used against a slow agent during traffic
+// spikes. Beyond the limit, flushes wait for an in-flight one to complete while finished
+// traces queue up in the trace buffer (see WithTraceBufferSize and WithBufferFullPolicy).
+// It defaults to 100; values lower than 1 are ignored.
+func WithMaxConcurrentFlushes(n int) StartOption {
+	return func(c *config) {
+		if n <= 0 {
+			log.Warn("ignoring maximum concurrent flushes %d: it must be positive", n)
+			return
+		}
+		c.maxConcurrentFlushes = n
+	}
+}
+
+// BufferFullPolicy specifies what the tracer does with a finished trace when its
+// trace buffer is full. See WithBufferFullPolicy.
+type BufferFullPolicy struct {
+	// timeout is the time to wait for room in the buffer; zero drops right away.
+	timeout time.Duration
+}
+
+// BufferFullDropNewest drops the traces which finish while the buffer is full. It
+// is the default policy, which never slows down the application.
+var BufferFullDropNewest = BufferFullPolicy{}
+
+// BufferFullBlock makes the goroutine finishing a trace wait up to timeout for room
+// in the buffer, before dropping the trace. It favors completeness over latency.
+func BufferFullBlock(timeout time.Duration) BufferFullPolicy {
+	return BufferFullPolicy{timeout: max(timeout, 0)}
+}
+
+// WithBufferFullPolicy sets what the tracer does with finished traces when its trace
+// buffer is full. Dropped traces are reported with the datadog.tracer.traces_dropped
+// health metric, tagged with reason:buffer_full. Defaults to BufferFullDropNewest.
+func WithBufferFullPolicy(policy BufferFullPolicy) StartOption {
+	return func(c *config) {
+		c.bufferFullPolicy = policy
+	}
+}
+
+// WithMaxTraceDuration forces the finished spans of a local trace to be flushed once
+// the trace has been open for longer than d, even if its root span hasn't finished yet.
+// This protects long-running jobs, such as workers whose root span lasts for hours,
+// from losing all of their spans if the process crashes before the root finishes.
+//
+// The flush is a partial flush: it is triggered when a span of the trace finishes at
+// least d after the start of the trace or after its previous partial flush, whether
+// or not WithPartialFlushing is enabled, and it sends all the spans of the trace which
+// have finished so far. The spans still open are kept and flushed later. The backend
+// reassembles the chunks of a trace by trace ID, but until the root span is received,
+// the trace is shown as incomplete and trace-level metrics can't be computed. It is
+// disabled by default.
+func WithMaxTraceDuration(d time.Duration) StartOption {
+	return func(c *config) {
+		c.maxTraceDuration = d
+	}
+}
+
+// WithLongRunningSpans makes the tracer send, every interval, snapshots of the spans which
+// are still open in the local traces that have been running for longer than interval, so
+// that long-running jobs, such as batch jobs lasting for hours, show up in the UI before
+// their root span finishes. A snapshot holds the span as it is at that time, with its
+// duration so far; it is replaced by the next snapshot, and by the span itself once it
+// finishes. The spans which have finished are not part of the snapshots, see
+// WithMaxTraceDuration to flush them early. Snapshots are only sent for the traces kept by
+// sampling. It is disabled by default.
+func WithLongRunningSpans(interval time.Duration) StartOption {
+	return func(c *config) {
+		if interval < 0 {
+			log.Warn("ignoring long-running spans interval %s: it must not be negative", interval)
+			return
+		}
+		c.longRunningInterval = interval
+	}
+}
+
 // WithStatsComputation enables client-side stats computation, allowing
 // the tracer to compute stats from traces. This can reduce network traffic
 // to the Datadog Agent, and produce more accurate stats data.
@@ -1310,6 +2055,36 @@ func WithStatsComputation(enabled bool) StartOption {
 	}
 }
 
+// WithDataStreamsEndpointPath sets the path, relative to the agent URL, which data streams
+// monitoring stats are posted to. It is useful when the agent is reached through a proxy
+// which expects a different route. Defaults to "/v0.1/pipeline_stats".
+func WithDataStreamsEndpointPath(path string) StartOption {
+	return func(c *config) {
+		c.dataStreamsEndpointPath = path
+	}
+}
+
+// WithDataStreamsHeaders adds the given headers, such as the authentication headers of an
+// agent proxy, to the requests sending data streams monitoring stats.
+func WithDataStreamsHeaders(headers map[string]string) StartOption {
+	return func(c *config) {
+		if c.dataStreamsHeaders == nil {
+			c.dataStreamsHeaders = make(map[string]string, len(headers))
+		}
+		for k, v := range headers {
+			c.dataStreamsHeaders[k] = v
+		}
+	}
+}
+
+// WithDataStreamsCompression sets whether data streams monitoring stats are gzip
+// compressed before being sent to the agent. It is enabled by default.
+func WithDataStreamsCompression(enabled bool) StartOption {
+	return func(c *config) {
+		c.dataStreamsCompressionDisabled = !enabled
+	}
+}
+
 // Tag sets the given key/value pair as a tag on the started Span.
 func Tag(k string, v interface{}) StartSpanOption {
 	return func(cfg *StartSpanConfig) {
@@ -1352,6 +2127,39 @@ func Measured() StartSpanOption {
 	return measuredTag
 }
 
+// WithoutBaggageInheritance starts the span with empty baggage, even if its parent carries
+// some. It is meant for in-process security boundaries, where a subsystem must not see the
+// baggage of the request it handles. The span is still part of its parent's trace, and
+// baggage set on it afterwards is inherited by its own children as usual.
+func WithoutBaggageInheritance() StartSpanOption {
+	return func(cfg *StartSpanConfig) {
+		cfg.NoBaggageInheritance = true
+	}
+}
+
+// WithOrigin sets the origin of the trace started by the span, e.g. "synthetics", overriding
+// the one set with WithDefaultOrigin. It only applies to spans starting a new trace, spans
+// with a parent keep the origin of their trace. Characters which can't be propagated are
+// replaced with underscores.
+func WithOrigin(origin string) StartSpanOption {
+	return func(cfg *StartSpanConfig) {
+		cfg.Origin = sanitizeOrigin(origin)
+	}
+}
+
+// WithAlwaysKeep sets the sampling priority of the trace of the started span to user-keep
+// when it starts, and keeps it so when the local root span finishes, overriding the samplers,
+// the rate limiter and the decision of WithSamplingDecider. It is meant for spans which must
+// always be delivered, e.g. security audit events. Unlike tagging the span with ext.ManualKeep,
+// it takes effect at start, so the decision is already set when the trace context is
+// propagated. The trace of a span continuing a context which was already propagated with
+// a different priority keeps the priority it was propagated with.
+func WithAlwaysKeep() StartSpanOption {
+	return func(cfg *StartSpanConfig) {
+		cfg.AlwaysKeep = true
+	}
+}
+
 // WithSpanID sets the SpanID on the started span, instead of using a random number.
 // If there is no parent Span (eg from ChildOf), then the TraceID will also be set to the
 // value given here.
@@ -1362,6 +2170,9 @@ func WithSpanID(id uint64) StartSpanOption {
 }
 
 // ChildOf tells StartSpan to use the given span context as a parent for the created span.
+// If ctx only holds baggage, e.g. when it was extracted by the baggage propagator from a
+// carrier without trace headers, the created span is the root of a new trace which
+// carries forward the baggage items of ctx.
 //
 // Deprecated: Use [Span.StartChild] instead.
 func ChildOf(ctx *SpanContext) StartSpanOption {
@@ -1439,6 +2250,71 @@ func WithHeaderTags(headerAsTags []string) StartOption {
 	}
 }
 
+// WithBaggageTagKeys copies the baggage items with the given keys as "baggage.<key>" tags
+// onto the local root span of traces which are continued from an extracted context.
+// The "*" key copies all the baggage items. It replaces the keys configured with
+// DD_TRACE_BAGGAGE_TAG_KEYS, which is a comma-separated list of keys.
+// Warning:
+// Baggage is set by upstream services, so the tags may contain data the application
+// doesn't control.
+func WithBaggageTagKeys(keys ...string) StartOption {
+	return func(c *config) {
+		c.baggageTagKeys = parseTagKeys(keys)
+	}
+}
+
+// WithProcessTags copies the process tags with the given keys, such as "entrypoint.name"
+// or "entrypoint.workdir", as span tags onto the local root span of every trace. It helps
+// identify which binary produced a trace when several services run from the same image.
+// Process tags are only collected when DD_EXPERIMENTAL_PROPAGATE_PROCESS_TAGS_ENABLED is
+// set to true.
+func WithProcessTags(keys ...string) StartOption {
+	return func(c *config) {
+		c.processTagKeys = parseTagKeys(keys)
+	}
+}
+
+// WithIgnoreResources drops the traces whose root span has a resource name matching
+// one of the given glob patterns, in which '*' matches any sequence of characters and
+// '?' any single character, e.g. "GET /healthz". The matching is case-insensitive.
+// Unlike sampling, it deterministically drops known noise such as health checks,
+// whichever integration creates the spans. Spans flushed before their root finished,
+// through partial flushing, are still sent. It replaces the patterns configured with
+// DD_TRACE_IGNORE_RESOURCES, which is a comma-separated list of patterns.
+func WithIgnoreResources(patterns ...string) StartOption {
+	return func(c *config) {
+		c.ignoreResources = parseIgnoreResources(patterns)
+	}
+}
+
+// parseIgnoreResources compiles the given resource glob patterns, skipping the empty
+// ones and the ones matching every resource.
+func parseIgnoreResources(patterns []string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		if p == "*" {
+			log.Warn("Ignoring resource pattern %q: it would drop all the traces.", p)
+			continue
+		}
+		compiled = append(compiled, globMatch(p))
+	}
+	return compiled
+}
+
+// parseTagKeys trims the given keys and drops the empty ones.
+func parseTagKeys(keys []string) []string {
+	parsed := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if k = strings.TrimSpace(k); k != "" {
+			parsed = append(parsed, k)
+		}
+	}
+	return parsed
+}
+
 // WithTestDefaults configures the tracer to not send spans to the agent, and to not collect metrics.
 // Warning:
 // This option should only be used in tests, as it will prevent the tracer from sending spans to the agent.
@@ -346,6 +346,22 @@ func TestAgentIntegration(t *testing.T) {
 	t.Run("default_after", defaultUninstrumentedTest)
 }
 
+func TestIntegrations(t *testing.T) {
+	defer clearIntegrationsForTests()
+	assert.True(t, MarkIntegrationImported("github.com/go-chi/chi"))
+	_, _, _, stop, err := startTestTracer(t)
+	require.NoError(t, err)
+	defer stop()
+
+	infos := Integrations()
+	for _, s := range contribIntegrations {
+		assert.Contains(t, infos, s.name)
+	}
+	assert.Equal(t, "chi", infos["chi"].Name)
+	assert.True(t, infos["chi"].Enabled)
+	assert.False(t, infos["Redigo"].Enabled)
+}
+
 type contribPkg struct {
 	Dir        string
 	Root       string
@@ -466,6 +482,24 @@ func TestTracerOptionsDefaults(t *testing.T) {
 		assert.Equal(t, client, c.httpClient)
 	})
 
+	t.Run("agent-transport-tuning", func(t *testing.T) {
+		client := &http.Client{}
+		c, err := newConfig(WithHTTPClient(client), WithAgentTransportTuning(16, time.Minute))
+		assert.NoError(t, err)
+		assert.NotEqual(t, client, c.httpClient)
+		x := c.httpClient.Transport.(*http.Transport)
+		assert.Equal(t, 16, x.MaxIdleConns)
+		assert.Equal(t, 16, x.MaxIdleConnsPerHost)
+		assert.Equal(t, time.Minute, x.IdleConnTimeout)
+		assert.True(t, getFuncName(x.DialContext) == getFuncName(defaultDialer.DialContext))
+
+		c, err = newConfig(WithAgentTransportTuning(0, -1))
+		assert.NoError(t, err)
+		x = c.httpClient.Transport.(*http.Transport)
+		assert.Equal(t, defaultAgentMaxIdleConns, x.MaxIdleConns)
+		assert.Equal(t, defaultAgentIdleConnTimeout, x.IdleConnTimeout)
+	})
+
 	t.Run("analytics", func(t *testing.T) {
 		t.Run("option", func(t *testing.T) {
 			defer globalconfig.SetAnalyticsRate(math.NaN())
@@ -683,6 +717,14 @@ func TestTracerOptionsDefaults(t *testing.T) {
 			assert.Equal("unix://"+addr, c.dogstatsdAddr)
 			assert.Equal("unix://"+addr, globalconfig.DogstatsdAddr())
 		})
+
+		t.Run("uds-option", func(t *testing.T) {
+			t.Setenv("DD_DOGSTATSD_SOCKET", "/var/run/other/dsd.socket")
+			c, err := newConfig(WithDogstatsdUnixSocket("/var/run/datadog/custom.socket"), WithAgentTimeout(2))
+			assert.NoError(t, err)
+			assert.Equal(t, "unix:///var/run/datadog/custom.socket", c.dogstatsdAddr)
+			assert.Equal(t, "unix:///var/run/datadog/custom.socket", globalconfig.DogstatsdAddr())
+		})
 	})
 
 	t.Run("env-env", func(t *testing.T) {
@@ -1078,6 +1120,13 @@ func TestDefaultDogstatsdAddr(t *testing.T) {
 		assert.Equal(t, "111.111.1.1:8888", defaultDogstatsdAddr())
 	})
 
+	t.Run("socket-env", func(t *testing.T) {
+		t.Setenv("DD_DOGSTATSD_SOCKET", "/var/run/datadog/custom.socket")
+		t.Setenv("DD_DOGSTATSD_HOST", "111.111.1.1")
+		t.Setenv("DD_DOGSTATSD_PORT", "8888")
+		assert.Equal(t, "unix:///var/run/datadog/custom.socket", defaultDogstatsdAddr())
+	})
+
 	t.Run("host-env+socket", func(t *testing.T) {
 		t.Setenv("DD_DOGSTATSD_HOST", "111.111.1.1")
 		assert.Equal(t, "111.111.1.1:8125", defaultDogstatsdAddr())
@@ -1821,6 +1870,43 @@ func TestWithStatsComputation(t *testing.T) {
 	})
 }
 
+func TestWithKubernetesMetadata(t *testing.T) {
+	t.Setenv("POD_NAME", "web-5d8f9")
+	t.Setenv("POD_NAMESPACE", "shop")
+	t.Setenv("NODE_NAME", "")
+
+	t.Run("enabled", func(t *testing.T) {
+		c, err := newConfig(WithGlobalTag("kube_namespace", "custom"), WithKubernetesMetadata(true))
+		assert.NoError(t, err)
+		tags := c.globalTags.get()
+		assert.Equal(t, "web-5d8f9", tags["kube_pod"])
+		assert.Equal(t, "custom", tags["kube_namespace"])
+		assert.NotContains(t, tags, "node_name")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		c, err := newConfig(WithKubernetesMetadata(false))
+		assert.NoError(t, err)
+		assert.NotContains(t, c.globalTags.get(), "kube_pod")
+	})
+}
+
+func TestWithDataStreamsEndpoint(t *testing.T) {
+	assert := assert.New(t)
+	c, err := newConfig(
+		WithDataStreamsEndpointPath("/proxy/pipeline_stats"),
+		WithDataStreamsHeaders(map[string]string{"Authorization": "Bearer token"}),
+	)
+	assert.NoError(err)
+	assert.Equal("/proxy/pipeline_stats", c.dataStreamsEndpointPath)
+	assert.Equal(map[string]string{"Authorization": "Bearer token"}, c.dataStreamsHeaders)
+	assert.False(c.dataStreamsCompressionDisabled)
+
+	c, err = newConfig(WithDataStreamsCompression(false))
+	assert.NoError(err)
+	assert.True(c.dataStreamsCompressionDisabled)
+}
+
 func TestWithStartSpanConfig(t *testing.T) {
 	var (
 		assert  = assert.New(t)
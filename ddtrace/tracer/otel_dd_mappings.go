@@ -86,6 +86,7 @@ var propagationMapping = map[string]string{
 	"b3":           "b3 single header",
 	"b3multi":      "b3multi",
 	"datadog":      "datadog",
+	"xray":         "xray",
 	"none":         "none",
 }
 
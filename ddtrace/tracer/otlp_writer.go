@@ -0,0 +1,322 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
+	globalinternal "github.com/DataDog/dd-trace-go/v2/internal"
+	"github.com/DataDog/dd-trace-go/v2/internal/log"
+	"github.com/DataDog/dd-trace-go/v2/internal/version"
+)
+
+// OTLPProtocol specifies the protocol of an OTLP traces endpoint, see WithOTLPEndpoint.
+type OTLPProtocol string
+
+const (
+	// OTLPProtocolHTTPProtobuf posts protobuf-encoded OTLP payloads to an OTLP/HTTP
+	// traces endpoint, e.g. "http://localhost:4318/v1/traces".
+	OTLPProtocolHTTPProtobuf OTLPProtocol = "http/protobuf"
+	// OTLPProtocolGRPC exports OTLP payloads to an OTLP/gRPC endpoint, e.g. "localhost:4317".
+	// The connection uses TLS when the endpoint has the https scheme.
+	OTLPProtocolGRPC OTLPProtocol = "grpc"
+)
+
+// otlpMaxSpansPerRequest is the number of buffered spans after which the
+// otlpTraceWriter flushes, regardless of the flush interval.
+const otlpMaxSpansPerRequest = 1000
+
+// otlpTraceWriter converts traces to OTLP and sends them as protobuf to an
+// OTLP/HTTP or OTLP/gRPC traces endpoint, for setups where no Datadog agent is available.
+type otlpTraceWriter struct {
+	config *config
+	client *http.Client
+	statsd globalinternal.StatsdClient
+
+	// grpc exports the traces when the protocol is OTLPProtocolGRPC, over conn.
+	// grpcErr holds the error which prevented creating the connection, if any.
+	grpc    ptraceotlp.GRPCClient
+	conn    *grpc.ClientConn
+	grpcErr error
+
+	// traces holds the buffered spans, grouped into one resource per service.
+	traces    ptrace.Traces
+	resources map[string]ptrace.SpanSlice
+
+	// climit limits the number of concurrent outgoing connections
+	climit chan struct{}
+
+	// wg waits for all uploads to finish
+	wg sync.WaitGroup
+}
+
+func newOTLPTraceWriter(c *config, statsdClient globalinternal.StatsdClient) *otlpTraceWriter {
+	w := &otlpTraceWriter{
+		config: c,
+		client: defaultHTTPClient(c.httpClientTimeout),
+		statsd: statsdClient,
+		climit: make(chan struct{}, c.maxConcurrentFlushes),
+	}
+	if c.otlpProtocol == OTLPProtocolGRPC {
+		w.conn, w.grpcErr = newOTLPGRPCConn(c.otlpEndpoint)
+		if w.grpcErr != nil {
+			log.Error("Error connecting to the OTLP/gRPC endpoint %q: %s", c.otlpEndpoint, w.grpcErr.Error())
+		} else {
+			w.grpc = ptraceotlp.NewGRPCClient(w.conn)
+		}
+	}
+	w.reset()
+	return w
+}
+
+// newOTLPGRPCConn returns a gRPC client connection to the given OTLP/gRPC endpoint,
+// which is either a host:port target, or a URL whose https scheme enables TLS.
+func newOTLPGRPCConn(endpoint string) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	target := endpoint
+	if rest, ok := strings.CutPrefix(endpoint, "https://"); ok {
+		creds = credentials.NewTLS(nil)
+		target = rest
+	} else if rest, ok := strings.CutPrefix(endpoint, "http://"); ok {
+		target = rest
+	}
+	return grpc.NewClient(strings.TrimSuffix(target, "/"), grpc.WithTransportCredentials(creds))
+}
+
+func (h *otlpTraceWriter) reset() {
+	h.traces = ptrace.NewTraces()
+	h.resources = make(map[string]ptrace.SpanSlice)
+}
+
+func (h *otlpTraceWriter) add(trace []*Span) {
+	if len(trace) == 0 {
+		return
+	}
+	// There is no agent to drop the traces which were not kept by sampling,
+	// so only keep them or their single-span sampled spans.
+	keep := true
+	if p, ok := trace[0].metrics[keySamplingPriority]; ok && p <= 0 {
+		keep = false
+	}
+	for _, s := range trace {
+		if !keep {
+			if _, ok := s.metrics[keySpanSamplingMechanism]; !ok {
+				continue
+			}
+		}
+		otlpSpan(h.spansFor(s.service).AppendEmpty(), s)
+	}
+	if h.traces.SpanCount() >= otlpMaxSpansPerRequest {
+		h.statsd.Incr("datadog.tracer.flush_triggered", []string{"reason:size"}, 1)
+		h.flush()
+	}
+}
+
+// spansFor returns the span slice of the resource for the given service,
+// creating it if needed.
+func (h *otlpTraceWriter) spansFor(service string) ptrace.SpanSlice {
+	if spans, ok := h.resources[service]; ok {
+		return spans
+	}
+	rs := h.traces.ResourceSpans().AppendEmpty()
+	attrs := rs.Resource().Attributes()
+	attrs.PutStr("service.name", service)
+	if h.config.env != "" {
+		attrs.PutStr("deployment.environment", h.config.env)
+	}
+	if h.config.version != "" {
+		attrs.PutStr("service.version", h.config.version)
+	}
+	attrs.PutStr("telemetry.sdk.name", "datadog")
+	attrs.PutStr("telemetry.sdk.language", "go")
+	attrs.PutStr("telemetry.sdk.version", version.Tag)
+	ss := rs.ScopeSpans().AppendEmpty()
+	ss.Scope().SetName("github.com/DataDog/dd-trace-go/v2/ddtrace/tracer")
+	ss.Scope().SetVersion(version.Tag)
+	h.resources[service] = ss.Spans()
+	return ss.Spans()
+}
+
+func (h *otlpTraceWriter) stop() {
+	h.statsd.Incr("datadog.tracer.flush_triggered", []string{"reason:shutdown"}, 1)
+	h.flush()
+	h.wg.Wait()
+	if h.conn != nil {
+		h.conn.Close()
+	}
+}
+
+// flush will push any currently buffered traces to the OTLP endpoint.
+func (h *otlpTraceWriter) flush() {
+	count := h.traces.SpanCount()
+	if count == 0 {
+		return
+	}
+	// the export request is the same protobuf message as the traces
+	req := ptraceotlp.NewExportRequestFromTraces(h.traces)
+	body, err := req.MarshalProto()
+	h.reset()
+	if err != nil {
+		h.statsd.Incr("datadog.tracer.traces_dropped", []string{"reason:encoding_error"}, 1)
+		log.Error("Error encoding OTLP traces: %s", err.Error())
+		return
+	}
+	h.wg.Add(1)
+	h.climit <- struct{}{}
+	go func() {
+		defer func(start time.Time) {
+			<-h.climit
+			h.statsd.Timing("datadog.tracer.flush_duration", time.Since(start), nil, 1)
+			h.wg.Done()
+		}(time.Now())
+
+		var err error
+		for attempt := 0; attempt <= h.config.sendRetries; attempt++ {
+			if err = h.send(req, body); err == nil {
+				log.Debug("sent %d spans to OTLP endpoint after %d attempts", count, attempt+1)
+				h.statsd.Count("datadog.tracer.flush_bytes", int64(len(body)), nil, 1)
+				// the distribution of the payload sizes, as reported by agentTraceWriter
+				h.statsd.DistributionSamples("datadog.tracer.flush_bytes.distribution", []float64{float64(len(body))}, nil, 1)
+				return
+			}
+			log.Error("failure sending OTLP traces (attempt %d of %d): %v", attempt+1, h.config.sendRetries+1, err.Error())
+			time.Sleep(h.config.retryInterval)
+		}
+		h.statsd.Count("datadog.tracer.spans_dropped", int64(count), []string{"reason:send_failed"}, 1)
+		log.Error("lost %d spans: %v", count, err.Error())
+	}()
+}
+
+// send sends the given OTLP export request, encoded as protobuf in body, to the
+// configured endpoint.
+func (h *otlpTraceWriter) send(exportReq ptraceotlp.ExportRequest, body []byte) error {
+	if h.config.otlpProtocol == OTLPProtocolGRPC {
+		return h.sendGRPC(exportReq)
+	}
+	req, err := http.NewRequest("POST", h.config.otlpEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("cannot create http request: %s", err.Error())
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if code := resp.StatusCode; code >= 400 {
+		return fmt.Errorf("%s", http.StatusText(code))
+	}
+	return nil
+}
+
+// sendGRPC exports the given OTLP export request to the configured OTLP/gRPC endpoint.
+func (h *otlpTraceWriter) sendGRPC(req ptraceotlp.ExportRequest) error {
+	if h.grpcErr != nil {
+		return h.grpcErr
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), h.config.httpClientTimeout)
+	defer cancel()
+	_, err := h.grpc.Export(ctx, req)
+	return err
+}
+
+// otlpSpan fills dst with the OTLP representation of the finished span s.
+func otlpSpan(dst ptrace.Span, s *Span) {
+	var tid [16]byte
+	if s.context != nil {
+		tid = s.context.traceID
+	} else {
+		binary.BigEndian.PutUint64(tid[8:], s.traceID)
+	}
+	dst.SetTraceID(pcommon.TraceID(tid))
+	dst.SetSpanID(otlpSpanID(s.spanID))
+	if s.parentID != 0 {
+		dst.SetParentSpanID(otlpSpanID(s.parentID))
+	}
+	dst.SetName(s.resource)
+	dst.SetKind(otlpSpanKind(s.meta[ext.SpanKind]))
+	dst.SetStartTimestamp(pcommon.Timestamp(s.start))
+	dst.SetEndTimestamp(pcommon.Timestamp(s.start + s.duration))
+
+	attrs := dst.Attributes()
+	attrs.PutStr("operation.name", s.name)
+	attrs.PutStr("resource.name", s.resource)
+	if s.spanType != "" {
+		attrs.PutStr("span.type", s.spanType)
+	}
+	for k, v := range s.meta {
+		attrs.PutStr(k, v)
+	}
+	for k, v := range s.metrics {
+		attrs.PutDouble(k, v)
+	}
+	if s.context != nil {
+		if p, ok := s.context.SamplingPriority(); ok {
+			// sampling.priority is honored by the Datadog OTLP intake
+			attrs.PutInt("sampling.priority", int64(p))
+			if p > 0 {
+				dst.SetFlags(1) // W3C sampled flag
+			}
+		}
+	}
+	if s.error != 0 {
+		dst.Status().SetCode(ptrace.StatusCodeError)
+		dst.Status().SetMessage(s.meta[ext.ErrorMsg])
+	}
+
+	for _, l := range s.spanLinks {
+		link := dst.Links().AppendEmpty()
+		var ltid [16]byte
+		binary.BigEndian.PutUint64(ltid[:8], l.TraceIDHigh)
+		binary.BigEndian.PutUint64(ltid[8:], l.TraceID)
+		link.SetTraceID(pcommon.TraceID(ltid))
+		link.SetSpanID(otlpSpanID(l.SpanID))
+		link.TraceState().FromRaw(l.Tracestate)
+		link.SetFlags(l.Flags)
+		for k, v := range l.Attributes {
+			link.Attributes().PutStr(k, v)
+		}
+	}
+}
+
+func otlpSpanID(id uint64) pcommon.SpanID {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], id)
+	return pcommon.SpanID(b)
+}
+
+// otlpSpanKind maps the value of the span.kind tag to an OTLP span kind.
+func otlpSpanKind(kind string) ptrace.SpanKind {
+	switch kind {
+	case ext.SpanKindServer:
+		return ptrace.SpanKindServer
+	case ext.SpanKindClient:
+		return ptrace.SpanKindClient
+	case ext.SpanKindProducer:
+		return ptrace.SpanKindProducer
+	case ext.SpanKindConsumer:
+		return ptrace.SpanKindConsumer
+	default:
+		return ptrace.SpanKindInternal
+	}
+}
@@ -0,0 +1,162 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	"google.golang.org/grpc"
+
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
+	"github.com/DataDog/dd-trace-go/v2/internal/samplernames"
+	"github.com/DataDog/dd-trace-go/v2/internal/statsdtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTLPTraceWriter(t *testing.T) {
+	assert.Implements(t, (*traceWriter)(nil), &otlpTraceWriter{})
+
+	var (
+		received    ptrace.Traces
+		contentType string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		received, err = (&ptrace.ProtoUnmarshaler{}).UnmarshalTraces(body)
+		require.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	cfg, err := newConfig(WithOTLPExporter(srv.URL), WithEnv("test-env"), WithServiceVersion("1.2.3"))
+	require.NoError(t, err)
+	h := newOTLPTraceWriter(cfg, &statsdtest.TestStatsdClient{})
+
+	root := newSpan("http.request", "web", "GET /", 1, 42, 0)
+	root.duration = 1000
+	root.meta[ext.SpanKind] = ext.SpanKindServer
+	root.metrics[keySamplingPriority] = 1
+	child := newSpan("db.query", "db", "SELECT 1", 2, 42, 1)
+	child.error = 1
+	child.meta[ext.ErrorMsg] = "boom"
+	child.spanLinks = []SpanLink{{TraceID: 7, TraceIDHigh: 8, SpanID: 9, Tracestate: "dd=s:1", Attributes: map[string]string{"k": "v"}}}
+	h.add([]*Span{root, child})
+
+	dropped := newSpan("http.request", "web", "GET /health", 3, 43, 0)
+	dropped.metrics[keySamplingPriority] = 0
+	h.add([]*Span{dropped})
+	h.stop()
+
+	assert := assert.New(t)
+	assert.Equal("application/x-protobuf", contentType)
+	assert.Equal(2, received.SpanCount())
+	spans := make(map[string]ptrace.Span)
+	for i := 0; i < received.ResourceSpans().Len(); i++ {
+		rs := received.ResourceSpans().At(i)
+		attrs := rs.Resource().Attributes()
+		env, _ := attrs.Get("deployment.environment")
+		assert.Equal("test-env", env.Str())
+		version, _ := attrs.Get("service.version")
+		assert.Equal("1.2.3", version.Str())
+		service, _ := attrs.Get("service.name")
+		ss := rs.ScopeSpans().At(0).Spans()
+		require.Equal(t, 1, ss.Len())
+		spans[service.Str()] = ss.At(0)
+	}
+
+	web := spans["web"]
+	tid := web.TraceID()
+	assert.Equal(uint64(42), binary.BigEndian.Uint64(tid[8:]))
+	sid := web.SpanID()
+	assert.Equal(uint64(1), binary.BigEndian.Uint64(sid[:]))
+	assert.True(web.ParentSpanID().IsEmpty())
+	assert.Equal("GET /", web.Name())
+	assert.Equal(ptrace.SpanKindServer, web.Kind())
+	assert.Equal(uint64(1000), uint64(web.EndTimestamp()-web.StartTimestamp()))
+	op, _ := web.Attributes().Get("operation.name")
+	assert.Equal("http.request", op.Str())
+
+	db := spans["db"]
+	pid := db.ParentSpanID()
+	assert.Equal(uint64(1), binary.BigEndian.Uint64(pid[:]))
+	assert.Equal(ptrace.SpanKindInternal, db.Kind())
+	assert.Equal(ptrace.StatusCodeError, db.Status().Code())
+	assert.Equal("boom", db.Status().Message())
+	require.Equal(t, 1, db.Links().Len())
+	link := db.Links().At(0)
+	ltid := link.TraceID()
+	assert.Equal(uint64(8), binary.BigEndian.Uint64(ltid[:8]))
+	assert.Equal(uint64(7), binary.BigEndian.Uint64(ltid[8:]))
+	assert.Equal("dd=s:1", link.TraceState().AsRaw())
+	v, _ := link.Attributes().Get("k")
+	assert.Equal("v", v.Str())
+}
+
+type otlpGRPCServer struct {
+	ptraceotlp.UnimplementedGRPCServer
+	received chan ptrace.Traces
+}
+
+func (s *otlpGRPCServer) Export(_ context.Context, req ptraceotlp.ExportRequest) (ptraceotlp.ExportResponse, error) {
+	s.received <- req.Traces()
+	return ptraceotlp.NewExportResponse(), nil
+}
+
+func TestOTLPTraceWriterGRPC(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	otlpSrv := &otlpGRPCServer{received: make(chan ptrace.Traces, 1)}
+	ptraceotlp.RegisterGRPCServer(srv, otlpSrv)
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	cfg, err := newConfig(WithOTLPEndpoint("http://"+ln.Addr().String(), OTLPProtocolGRPC))
+	require.NoError(t, err)
+	h := newOTLPTraceWriter(cfg, &statsdtest.TestStatsdClient{})
+
+	root := newSpan("http.request", "web", "GET /", 1, 42, 0)
+	root.metrics[keySamplingPriority] = 2
+	root.context = newSpanContext(root, nil)
+	root.context.setSamplingPriority(2, samplernames.Manual)
+	h.add([]*Span{root})
+	h.stop()
+
+	received := <-otlpSrv.received
+	require.Equal(t, 1, received.SpanCount())
+	span := received.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	assert.Equal(t, "GET /", span.Name())
+	p, ok := span.Attributes().Get("sampling.priority")
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), p.Int())
+	assert.Equal(t, uint32(1), span.Flags())
+}
+
+func TestWithOTLPEndpoint(t *testing.T) {
+	cfg, err := newConfig(WithOTLPExporter("http://localhost:4318/v1/traces"))
+	require.NoError(t, err)
+	assert.Equal(t, OTLPProtocolHTTPProtobuf, cfg.otlpProtocol)
+
+	cfg, err = newConfig(WithOTLPEndpoint("localhost:4317", OTLPProtocolGRPC))
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:4317", cfg.otlpEndpoint)
+	assert.Equal(t, OTLPProtocolGRPC, cfg.otlpProtocol)
+
+	cfg, err = newConfig(WithOTLPEndpoint("localhost:4317", "thrift"))
+	require.NoError(t, err)
+	assert.Equal(t, OTLPProtocolHTTPProtobuf, cfg.otlpProtocol)
+}
@@ -72,7 +72,12 @@ func newPayload() *payload {
 
 // push pushes a new item into the stream.
 func (p *payload) push(t spanList) error {
-	p.buf.Grow(t.Msgsize())
+	return p.pushSized(t, t.Msgsize())
+}
+
+// pushSized pushes a new item into the stream, given its encoded size as returned by Msgsize.
+func (p *payload) pushSized(t spanList, size int) error {
+	p.buf.Grow(size)
 	if err := msgp.Encode(&p.buf, t); err != nil {
 		return err
 	}
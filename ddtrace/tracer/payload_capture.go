@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"strings"
+	"time"
+)
+
+// defaultPayloadCaptureMaxSize is the default maximum size of the captured payloads,
+// see WithPayloadCaptureMaxSize.
+const defaultPayloadCaptureMaxSize = 1024
+
+// payloadCaptureDecision is the decision of capturing the payloads of a span.
+type payloadCaptureDecision int8
+
+const (
+	payloadCaptureNone payloadCaptureDecision = iota
+	payloadCaptureKeep
+	payloadCaptureDrop
+)
+
+// ShouldCapturePayload reports whether the payloads of s, such as the bodies of an HTTP
+// request and response, are captured by CapturePayload. It is the case when payload capture
+// is enabled with WithPayloadCapture and s is within the capture rate; the decision is made
+// on the first call and kept for the lifetime of s. Integrations use it to find out whether
+// they need to buffer a payload before passing it to CapturePayload.
+func (s *Span) ShouldCapturePayload() bool {
+	if s == nil {
+		return false
+	}
+	tr, ok := getGlobalTracer().(*tracer)
+	if !ok || tr.config.payloadCaptureLimiter == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.payloadCapture == payloadCaptureNone {
+		s.payloadCapture = payloadCaptureDrop
+		if allowed, _ := tr.config.payloadCaptureLimiter.allowOne(time.Now()); allowed {
+			s.payloadCapture = payloadCaptureKeep
+		}
+	}
+	return s.payloadCapture == payloadCaptureKeep
+}
+
+// CapturePayload sets the tag of s to a snippet of payload, if ShouldCapturePayload reports
+// that the payloads of s are captured. The payload is passed through the WithPayloadRedactor
+// functions, and then truncated to the size set with WithPayloadCaptureMaxSize. It is meant
+// to be used by integrations, so that payloads are captured the same way by all of them.
+func (s *Span) CapturePayload(tag string, payload []byte) {
+	if !s.ShouldCapturePayload() {
+		return
+	}
+	tr, ok := getGlobalTracer().(*tracer)
+	if !ok {
+		return
+	}
+	for _, redact := range tr.config.payloadRedactors {
+		payload = redact(tag, payload)
+	}
+	if n := tr.config.payloadCaptureMaxSize; len(payload) > n {
+		payload = payload[:n]
+	}
+	s.SetTag(tag, strings.ToValidUTF8(string(payload), ""))
+}
@@ -332,6 +332,83 @@ func SpanSamplingRules(rules ...Rule) []SamplingRule {
 	return samplingRules
 }
 
+// SpanSamplingRuleBuilder builds a span sampling rule out of glob patterns, with the
+// same semantics as the rules found in DD_SPAN_SAMPLING_RULES. Use NewSpanSamplingRule
+// to create one.
+type SpanSamplingRuleBuilder struct {
+	rule jsonRule
+}
+
+// NewSpanSamplingRule returns a builder for a span sampling rule. Unless specified
+// otherwise, the rule matches all spans and keeps all of them.
+//
+//	rule, err := tracer.NewSpanSamplingRule().Service("db-*").Name("postgres.query").Rate(0.5).MaxPerSecond(10).Build()
+func NewSpanSamplingRule() *SpanSamplingRuleBuilder {
+	return &SpanSamplingRuleBuilder{}
+}
+
+// Service sets the glob pattern that the span service name must match.
+func (b *SpanSamplingRuleBuilder) Service(glob string) *SpanSamplingRuleBuilder {
+	b.rule.Service = glob
+	return b
+}
+
+// Name sets the glob pattern that the span operation name must match.
+func (b *SpanSamplingRuleBuilder) Name(glob string) *SpanSamplingRuleBuilder {
+	b.rule.Name = glob
+	return b
+}
+
+// Resource sets the glob pattern that the span resource must match.
+func (b *SpanSamplingRuleBuilder) Resource(glob string) *SpanSamplingRuleBuilder {
+	b.rule.Resource = glob
+	return b
+}
+
+// Tag sets the glob pattern that the value of the span tag key must match.
+func (b *SpanSamplingRuleBuilder) Tag(key, glob string) *SpanSamplingRuleBuilder {
+	if b.rule.Tags == nil {
+		b.rule.Tags = make(map[string]string)
+	}
+	b.rule.Tags[key] = glob
+	return b
+}
+
+// Rate sets the rate at which the matching spans are kept. It must be within [0, 1].
+func (b *SpanSamplingRuleBuilder) Rate(rate float64) *SpanSamplingRuleBuilder {
+	b.rule.Rate = json.Number(strconv.FormatFloat(rate, 'f', -1, 64))
+	return b
+}
+
+// MaxPerSecond sets the maximum number of matching spans kept per second. Zero, the
+// default, means no limit.
+func (b *SpanSamplingRuleBuilder) MaxPerSecond(n float64) *SpanSamplingRuleBuilder {
+	b.rule.MaxPerSecond = n
+	return b
+}
+
+// Build validates the rule and returns it, ready to be passed to WithSamplingRules.
+func (b *SpanSamplingRuleBuilder) Build() (SamplingRule, error) {
+	if b.rule.MaxPerSecond < 0 {
+		return SamplingRule{}, fmt.Errorf("invalid span sampling rule %s: max per second must not be negative", b.rule.String())
+	}
+	rule := b.rule
+	if len(rule.Tags) != 0 {
+		// don't share the tags with the builder, which may be reused
+		rule.Tags = make(map[string]string, len(b.rule.Tags))
+		for k, v := range b.rule.Tags {
+			rule.Tags[k] = v
+		}
+	}
+	var typ SamplingRuleType = SamplingRuleSpan
+	rule.Type = &typ
+	rules, err := validateRules([]jsonRule{rule}, SamplingRuleSpan)
+	if err != nil {
+		return SamplingRule{}, fmt.Errorf("invalid span sampling rule: %w", err)
+	}
+	return rules[0], nil
+}
+
 // traceRulesSampler allows a user-defined list of rules to apply to traces.
 // These rules can match based on the span's Service, Name or both.
 // When making a sampling decision, the rules are checked in order until
@@ -453,33 +530,33 @@ func (rs *traceRulesSampler) sampleRules(span *Span) bool {
 		return false
 	}
 
-	var matched bool
-	rs.m.RLock()
-	rate := rs.globalRate
-	rs.m.RUnlock()
-	sampler := samplernames.RuleRate
-	for _, rule := range rs.rules {
-		if rule.match(span) {
-			matched = true
-			rate = rule.Rate
-			if rule.Provenance == Customer {
-				sampler = samplernames.RemoteUserRule
-			} else if rule.Provenance == Dynamic {
-				sampler = samplernames.RemoteDynamicRule
-			}
-			break
-		}
-	}
+	rule, matched := rs.matchingRule(span)
 	if !matched {
 		// no matching rule or global rate, so we want to fall back
 		// to priority sampling
 		return false
 	}
+	sampler := samplernames.RuleRate
+	if rule.Provenance == Customer {
+		sampler = samplernames.RemoteUserRule
+	} else if rule.Provenance == Dynamic {
+		sampler = samplernames.RemoteDynamicRule
+	}
 
-	rs.applyRate(span, rate, time.Now(), sampler)
+	rs.applyRate(span, rule.Rate, time.Now(), sampler)
 	return true
 }
 
+// matchingRule returns the first rule which matches span, if any.
+func (rs *traceRulesSampler) matchingRule(span *Span) (rule SamplingRule, matched bool) {
+	for _, rule := range rs.rules {
+		if rule.match(span) {
+			return rule, true
+		}
+	}
+	return SamplingRule{}, false
+}
+
 func (rs *traceRulesSampler) applyRate(span *Span, rate float64, now time.Time, sampler samplernames.SamplerName) {
 	span.mu.Lock()
 	defer span.mu.Unlock()
@@ -629,6 +706,66 @@ func (r *rateLimiter) allowOne(now time.Time) (bool, float64) {
 	return sampled, er
 }
 
+// effectiveRate returns the ratio of spans allowed over spans seen in the current and previous
+// periods at the time it is called, the same way as allowOne does, without counting a new span.
+// It returns 1 if no span was seen.
+func (r *rateLimiter) effectiveRate(now time.Time) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	allowed, seen := r.prevAllowed+r.allowed, r.prevSeen+r.seen
+	if d := now.Sub(r.prevTime); d >= time.Second {
+		if d.Truncate(time.Second) == time.Second {
+			// the current period becomes the previous one
+			allowed, seen = r.allowed, r.seen
+		} else {
+			allowed, seen = 0, 0
+		}
+	}
+	if seen == 0 {
+		return 1
+	}
+	return allowed / seen
+}
+
+// RateLimiterStats returns the trace rate limit of the running tracer, set with WithTraceRateLimit
+// or DD_TRACE_RATE_LIMIT, and the rate of the traces it let through over the last one or two
+// seconds, among the ones kept by the sampling rules or DD_TRACE_SAMPLE_RATE. An effective rate
+// below 1 means the limit is clipping traces. The limit only applies when sampling rules or
+// DD_TRACE_SAMPLE_RATE are set. It returns NaN values if the tracer is not started.
+func RateLimiterStats() (limit float64, effectiveRate float64) {
+	t, ok := getGlobalTracer().(*tracer)
+	if !ok || t.rulesSampling == nil {
+		return math.NaN(), math.NaN()
+	}
+	l := t.rulesSampling.traces.limiter
+	return float64(l.limiter.Limit()), l.effectiveRate(nowTime())
+}
+
+// MatchSamplingRule returns the first trace sampling rule of the running tracer which
+// matches a hypothetical span with the given service, operation name, resource and tags,
+// along with the rate the rule samples it with. It doesn't sample anything, and allows
+// tests to validate the sampling rule configuration, e.g. that GET /healthz spans of the
+// api service are matched by the expected rule:
+//
+//	rule, rate, ok := tracer.MatchSamplingRule("api", "http.request", "GET /healthz", nil)
+//
+// When no rule matches, matched is false and rate is the global sample rate, which is NaN
+// when it isn't set. It returns a NaN rate if the tracer is not started.
+func MatchSamplingRule(service, name, resource string, tags map[string]string) (rule SamplingRule, rate float64, matched bool) {
+	t, ok := getGlobalTracer().(*tracer)
+	if !ok || t.rulesSampling == nil {
+		return SamplingRule{}, math.NaN(), false
+	}
+	span := &Span{service: service, name: name, resource: resource, meta: tags}
+	rs := t.rulesSampling.traces
+	rs.m.RLock()
+	defer rs.m.RUnlock()
+	if rule, matched = rs.matchingRule(span); matched {
+		return rule, rule.Rate, true
+	}
+	return SamplingRule{}, rs.globalRate, false
+}
+
 // newSingleSpanRateLimiter returns a rate limiter which restricts the number of single spans sampled per second.
 // This defaults to infinite, allow all behaviour. The MaxPerSecond value of the rule may override the default.
 func newSingleSpanRateLimiter(mps float64) *rateLimiter {
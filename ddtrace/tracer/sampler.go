@@ -132,14 +132,19 @@ func newPrioritySampler() *prioritySampler {
 }
 
 // readRatesJSON will try to read the rates as JSON from the given io.ReadCloser.
+// The reader is always closed. If the response doesn't carry any rates, the
+// rates currently in use are kept.
 func (ps *prioritySampler) readRatesJSON(rc io.ReadCloser) error {
+	defer rc.Close()
 	var payload struct {
 		Rates map[string]float64 `json:"rate_by_service"`
 	}
 	if err := json.NewDecoder(rc).Decode(&payload); err != nil {
 		return err
 	}
-	rc.Close()
+	if payload.Rates == nil {
+		return nil
+	}
 	const defaultRateKey = "service:,env:"
 	ps.mu.Lock()
 	defer ps.mu.Unlock()
@@ -20,9 +20,21 @@ import (
 	"github.com/DataDog/dd-trace-go/v2/internal/samplernames"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/time/rate"
 )
 
+// closeRecorder is an io.ReadCloser which records whether it was closed.
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
 func TestPrioritySampler(t *testing.T) {
 	// create a new span with given service/env
 	mkSpan := func(svc, env string) *Span {
@@ -100,6 +112,25 @@ func TestPrioritySampler(t *testing.T) {
 		}
 	})
 
+	t.Run("missing-rates", func(t *testing.T) {
+		ps := newPrioritySampler()
+		assert := assert.New(t)
+		assert.NoError(ps.readRatesJSON(io.NopCloser(strings.NewReader(
+			`{"rate_by_service":{"service:,env:":0.3,"service:my-service,env:":0.2}}`,
+		))))
+		assert.NoError(ps.readRatesJSON(io.NopCloser(strings.NewReader(`{}`))))
+		assert.Equal(0.2, ps.getRate(mkSpan("my-service", "")))
+		assert.Equal(0.3, ps.getRate(mkSpan("other-service", "")))
+	})
+
+	t.Run("closes-body", func(t *testing.T) {
+		ps := newPrioritySampler()
+		assert := assert.New(t)
+		rc := &closeRecorder{Reader: strings.NewReader("OK")}
+		assert.Error(ps.readRatesJSON(rc))
+		assert.True(rc.closed)
+	})
+
 	t.Run("race", func(t *testing.T) {
 		ps := newPrioritySampler()
 		assert := assert.New(t)
@@ -271,6 +302,17 @@ func TestRuleEnvVars(t *testing.T) {
 		}
 	})
 
+	t.Run("rate-limit-option", func(t *testing.T) {
+		t.Setenv("DD_TRACE_RATE_LIMIT", "42.0")
+		c, err := newConfig(WithTraceRateLimit(5))
+		assert.NoError(t, err)
+		assert.Equal(t, 5.0, c.traceRateLimitPerSecond)
+
+		c, err = newConfig(WithTraceRateLimit(-1))
+		assert.NoError(t, err)
+		assert.Equal(t, 42.0, c.traceRateLimitPerSecond)
+	})
+
 	t.Run("trace-sampling-rules", func(t *testing.T) {
 		assert := assert.New(t)
 
@@ -1383,6 +1425,67 @@ func TestSamplingLimiter(t *testing.T) {
 		assert.Equal(1.0, sl.seen)
 		assert.Equal(1.0, sl.allowed)
 	})
+
+	t.Run("effective-rate", func(t *testing.T) {
+		assert := assert.New(t)
+		sl := newRateLimiter(defaultRateLimit)
+		assert.Equal(1.0, sl.effectiveRate(sl.prevTime))
+
+		sl.prevSeen = 100
+		sl.prevAllowed = 42
+		sl.allowed = 8
+		sl.seen = 100
+		assert.Equal(0.25, sl.effectiveRate(sl.prevTime))
+		assert.Equal(0.08, sl.effectiveRate(sl.prevTime.Add(time.Second)))
+		assert.Equal(1.0, sl.effectiveRate(sl.prevTime.Add(2*time.Second)))
+		assert.Equal(100.0, sl.seen) // nothing is counted
+	})
+}
+
+func TestRateLimiterStats(t *testing.T) {
+	limit, rate := RateLimiterStats()
+	assert.True(t, math.IsNaN(limit))
+	assert.True(t, math.IsNaN(rate))
+
+	t.Setenv("DD_TRACE_SAMPLE_RATE", "1")
+	tracer, _, _, stop, err := startTestTracer(t, WithTraceRateLimit(1))
+	require.NoError(t, err)
+	defer stop()
+
+	for i := 0; i < 4; i++ {
+		tracer.StartSpan("request").Finish()
+	}
+	limit, rate = RateLimiterStats()
+	assert.Equal(t, 1.0, limit)
+	assert.Equal(t, 0.25, rate)
+}
+
+func TestMatchSamplingRule(t *testing.T) {
+	_, rate, ok := MatchSamplingRule("api", "http.request", "GET /healthz", nil)
+	assert.False(t, ok)
+	assert.True(t, math.IsNaN(rate))
+
+	t.Setenv("DD_TRACE_SAMPLE_RATE", "0.5")
+	_, _, _, stop, err := startTestTracer(t, WithSamplingRules(TraceSamplingRules(
+		Rule{ServiceGlob: "api", ResourceGlob: "GET /healthz", Rate: 0},
+		Rule{ServiceGlob: "api", Tags: map[string]string{"tier": "gold"}, Rate: 1},
+	)))
+	require.NoError(t, err)
+	defer stop()
+
+	rule, rate, ok := MatchSamplingRule("api", "http.request", "GET /healthz", nil)
+	assert.True(t, ok)
+	assert.Equal(t, 0.0, rate)
+	assert.Equal(t, "(?i)^GET /healthz$", rule.Resource.String())
+
+	rule, rate, ok = MatchSamplingRule("api", "http.request", "GET /users", map[string]string{"tier": "gold"})
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, rate)
+	assert.Contains(t, rule.Tags, "tier")
+
+	_, rate, ok = MatchSamplingRule("web", "http.request", "GET /healthz", nil)
+	assert.False(t, ok)
+	assert.Equal(t, 0.5, rate)
 }
 
 func BenchmarkRulesSampler(b *testing.B) {
@@ -1575,6 +1678,42 @@ func TestSamplingRuleMarshall(t *testing.T) {
 	}
 }
 
+func TestNewSpanSamplingRule(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		assert := assert.New(t)
+		sr, err := NewSpanSamplingRule().Service("srv.*").Name("ops.*").Resource("GET /*").Tag("tag_key", "hn-*").Rate(0.5).MaxPerSecond(10).Build()
+		assert.NoError(err)
+		assert.Equal(SamplingRuleType(SamplingRuleSpan), sr.ruleType)
+		assert.Equal(0.5, sr.Rate)
+		assert.NotNil(sr.limiter)
+		m, err := sr.MarshalJSON()
+		assert.NoError(err)
+		assert.Equal(`{"service":"srv.*","name":"ops.*","resource":"GET /*","sample_rate":0.5,"tags":{"tag_key":"hn-*"},"max_per_second":10}`, string(m))
+
+		s := newBasicSpan("ops.x")
+		s.service = "srv.a"
+		s.resource = "GET /users"
+		s.SetTag("tag_key", "hn-1")
+		assert.True(sr.match(s))
+		s.SetTag("tag_key", "other")
+		assert.False(sr.match(s))
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		sr, err := NewSpanSamplingRule().Build()
+		assert.NoError(t, err)
+		assert.Equal(t, 1.0, sr.Rate)
+		assert.True(t, sr.match(newBasicSpan("anything")))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewSpanSamplingRule().Rate(1.5).Build()
+		assert.Error(t, err)
+		_, err = NewSpanSamplingRule().MaxPerSecond(-1).Build()
+		assert.Error(t, err)
+	})
+}
+
 func TestSamplingRuleMarshallGlob(t *testing.T) {
 	for i, tt := range []struct {
 		pattern string
@@ -17,6 +17,7 @@ import (
 	"runtime"
 	"runtime/pprof"
 	rt "runtime/trace"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -26,6 +27,8 @@ import (
 	sharedinternal "github.com/DataDog/dd-trace-go/v2/internal"
 	"github.com/DataDog/dd-trace-go/v2/internal/globalconfig"
 	"github.com/DataDog/dd-trace-go/v2/internal/log"
+	"github.com/DataDog/dd-trace-go/v2/internal/namingschema"
+	"github.com/DataDog/dd-trace-go/v2/internal/obfuscation"
 	"github.com/DataDog/dd-trace-go/v2/internal/orchestrion"
 	"github.com/DataDog/dd-trace-go/v2/internal/samplernames"
 	"github.com/DataDog/dd-trace-go/v2/internal/traceprof"
@@ -55,6 +58,17 @@ type errorConfig struct {
 	noDebugStack bool
 	stackFrames  uint
 	stackSkip    uint
+	message      string // overrides the error message when not empty
+	causeDepth   int    // number of wrapped causes to tag, see WithErrorCauseDepth
+}
+
+// errorCauseDepth returns the number of wrapped error causes tagged on spans by the
+// global tracer, see WithErrorCauseDepth.
+func errorCauseDepth() int {
+	if tr, ok := getGlobalTracer().(*tracer); ok {
+		return tr.config.errorCauseDepth
+	}
+	return 0
 }
 
 // AsMap places tags and span properties into a map and returns it.
@@ -134,8 +148,11 @@ type Span struct {
 	finished       bool         `msg:"-"` // true if the span has been submitted to a tracer. Can only be read/modified if the trace is locked.
 	context        *SpanContext `msg:"-"` // span propagation context
 	integration    string       `msg:"-"` // where the span was started from, such as a specific contrib or "manual"
+	serviceSet     bool         `msg:"-"` // the service was set explicitly, see WithServiceMappingByType
 	supportsEvents bool         `msg:"-"` // whether the span supports native span events or not
 
+	payloadCapture payloadCaptureDecision `msg:"-"` // whether payloads are captured, see ShouldCapturePayload
+
 	pprofCtxActive  context.Context `msg:"-"` // contains pprof.WithLabel labels to tell the profiler more about this span
 	pprofCtxRestore context.Context `msg:"-"` // contains pprof.WithLabel labels of the parent span (if any) that need to be restored when this span finishes
 
@@ -152,6 +169,112 @@ func (s *Span) Context() *SpanContext {
 	return s.context
 }
 
+// IsSampled reports whether the trace of the span is currently set to be kept.
+// See SpanContext.IsSampled.
+func (s *Span) IsSampled() bool {
+	return s.Context().IsSampled()
+}
+
+// StartTime returns the time at which the span started.
+func (s *Span) StartTime() time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Unix(0, s.start)
+}
+
+// Duration returns the duration of the span. It is only valid once the span
+// is finished, and it is zero before.
+func (s *Span) Duration() time.Duration {
+	if s == nil {
+		return 0
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.finished {
+		return 0
+	}
+	return time.Duration(s.duration)
+}
+
+// OperationName returns the operation name of the span.
+func (s *Span) OperationName() string {
+	if s == nil {
+		return ""
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.name
+}
+
+// Service returns the service of the span.
+func (s *Span) Service() string {
+	if s == nil {
+		return ""
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.service
+}
+
+// Resource returns the resource of the span.
+func (s *Span) Resource() string {
+	if s == nil {
+		return ""
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.resource
+}
+
+// Type returns the type of the span, such as ext.SpanTypeWeb.
+func (s *Span) Type() string {
+	if s == nil {
+		return ""
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.spanType
+}
+
+// ParentID returns the ID of the parent of the span, which is zero for root spans.
+func (s *Span) ParentID() uint64 {
+	if s == nil {
+		return 0
+	}
+	return s.parentID
+}
+
+// IsError reports whether the span is flagged as an error.
+func (s *Span) IsError() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.error != 0
+}
+
+// Tags returns a copy of the tags of the span: the string tags as strings and the
+// numeric tags as float64.
+func (s *Span) Tags() map[string]interface{} {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tags := make(map[string]interface{}, len(s.meta)+len(s.metrics))
+	for k, v := range s.meta {
+		tags[k] = v
+	}
+	for k, v := range s.metrics {
+		tags[k] = v
+	}
+	return tags
+}
+
 // SetBaggageItem sets a key/value pair as baggage on the span. Baggage items
 // are propagated down to descendant spans and injected cross-process. Use with
 // care as it adds extra load onto your tracing layer.
@@ -192,6 +315,7 @@ func (s *Span) SetTag(key string, value interface{}) {
 	case ext.Error:
 		s.setTagError(value, errorConfig{
 			noDebugStack: s.noDebugStack,
+			causeDepth:   errorCauseDepth(),
 		})
 		return
 	case ext.Component:
@@ -298,16 +422,22 @@ func (s *Span) setSamplingPriority(priority int, sampler samplernames.SamplerNam
 	s.setSamplingPriorityLocked(priority, sampler)
 }
 
-// root returns the root span of the span's trace. The return value shouldn't be
-// nil as long as the root span is valid and not finished.
+// Root returns the local root span of the span's trace, that is the first span
+// of the trace started in this process, which has no local parent. When the trace
+// continues a context extracted from a carrier, it is the first span started from
+// that context rather than the remote parent. The return value shouldn't be nil
+// as long as the root span is valid and not finished.
 func (s *Span) Root() *Span {
 	if s == nil || s.context == nil {
 		return nil
 	}
-	if s.context.trace == nil {
+	t := s.context.trace
+	if t == nil {
 		return nil
 	}
-	return s.context.trace.root
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.root
 }
 
 // SetUser associates user information to the current trace which the
@@ -326,6 +456,10 @@ func (s *Span) SetUser(id string, opts ...UserMonitoringOption) {
 		fn(&cfg)
 	}
 	root := s.Root()
+	if root == nil {
+		// the span isn't part of a trace, e.g. it was not started by a tracer
+		return
+	}
 	trace := root.context.trace
 	root.mu.Lock()
 	defer root.mu.Unlock()
@@ -393,6 +527,12 @@ func (s *Span) setSamplingPriorityLocked(priority int, sampler samplernames.Samp
 	s.context.setSamplingPriority(priority, sampler)
 }
 
+// setOrigin sets the origin of the trace started by s.
+func (s *Span) setOrigin(origin string) {
+	s.context.origin = origin
+	s.setMeta(keyOrigin, origin)
+}
+
 // setTagError sets the error tag. It accounts for various valid scenarios.
 // This method is not safe for concurrent use.
 func (s *Span) setTagError(value interface{}, cfg errorConfig) {
@@ -425,7 +565,11 @@ func (s *Span) setTagError(value interface{}, cfg errorConfig) {
 		// if anyone sets an error value as the tag, be nice here
 		// and provide all the benefits.
 		setError(true)
-		s.setMeta(ext.ErrorMsg, v.Error())
+		if cfg.message != "" {
+			s.setMeta(ext.ErrorMsg, cfg.message)
+		} else {
+			s.setMeta(ext.ErrorMsg, v.Error())
+		}
 		s.setMeta(ext.ErrorType, reflect.TypeOf(v).String())
 		if !cfg.noDebugStack {
 			s.setMeta(ext.ErrorStack, takeStacktrace(cfg.stackFrames, cfg.stackSkip))
@@ -437,6 +581,9 @@ func (s *Span) setTagError(value interface{}, cfg errorConfig) {
 			// pkg/errors approach
 			s.setMeta(ext.ErrorDetails, fmt.Sprintf("%+v", v))
 		}
+		if cfg.causeDepth > 0 {
+			s.setErrorCauses(v, cfg.causeDepth)
+		}
 	case nil:
 		// no error
 		setError(false)
@@ -447,6 +594,35 @@ func (s *Span) setTagError(value interface{}, cfg errorConfig) {
 	}
 }
 
+// setErrorCauses tags the messages and types of the first depth causes wrapped by err, as
+// error.cause.N and error.cause.N.type, N starting at 1 for the cause err wraps directly.
+// Of the errors joined by errors.Join, only the first one is followed.
+func (s *Span) setErrorCauses(err error, depth int) {
+	for n := 1; n <= depth; n++ {
+		key := "error.cause." + strconv.Itoa(n)
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			err = u.Unwrap()
+		case interface{ Unwrap() []error }:
+			if errs := u.Unwrap(); len(errs) > 0 {
+				err = errs[0]
+			} else {
+				err = nil
+			}
+		default:
+			err = nil
+		}
+		if err == nil {
+			// remove the causes of an error previously set on the span
+			delete(s.meta, key)
+			delete(s.meta, key+".type")
+			continue
+		}
+		s.setMeta(key, err.Error())
+		s.setMeta(key+".type", reflect.TypeOf(err).String())
+	}
+}
+
 // defaultStackLength specifies the default maximum size of a stack trace.
 const defaultStackLength = 32
 
@@ -494,6 +670,7 @@ func (s *Span) setMeta(key, v string) {
 		s.name = v
 	case ext.ServiceName:
 		s.service = v
+		s.serviceSet = true
 	case ext.ResourceName:
 		s.resource = v
 	case ext.SpanType:
@@ -572,6 +749,22 @@ func (s *Span) AddLink(link SpanLink) {
 		return
 	}
 	s.spanLinks = append(s.spanLinks, link)
+	limit := defaultMaxSpanLinks
+	if tr, ok := getGlobalTracer().(*tracer); ok {
+		limit = tr.config.maxSpanLinks
+	}
+	s.capLinks(limit)
+}
+
+// capLinks drops the span links in excess of limit, counting them in the
+// keySpanLinksDropped metric. s must already be locked.
+func (s *Span) capLinks(limit int) {
+	if len(s.spanLinks) <= limit {
+		return
+	}
+	dropped := len(s.spanLinks) - limit
+	s.spanLinks = s.spanLinks[:limit]
+	s.setMetric(keySpanLinksDropped, s.metrics[keySpanLinksDropped]+float64(dropped))
 }
 
 // serializeSpanLinksInMeta saves span links as a JSON string under `Span[meta][_dd.span_links]`.
@@ -620,6 +813,7 @@ func (s *Span) Finish(opts ...FinishOption) {
 	}
 
 	t := now()
+	tr, _ := getGlobalTracer().(*tracer)
 	if len(opts) > 0 {
 		cfg := FinishConfig{
 			NoDebugStack: s.noDebugStack,
@@ -634,13 +828,21 @@ func (s *Span) Finish(opts ...FinishOption) {
 			t = cfg.FinishTime.UnixNano()
 		}
 		if cfg.Error != nil {
-			s.mu.Lock()
-			s.setTagError(cfg.Error, errorConfig{
-				noDebugStack: cfg.NoDebugStack,
-				stackFrames:  cfg.StackFrames,
-				stackSkip:    cfg.SkipStackFrames,
-			})
-			s.mu.Unlock()
+			isError, msg := true, ""
+			if tr != nil && tr.config.errorClassifier != nil {
+				isError, msg = tr.config.errorClassifier(cfg.Error)
+			}
+			if isError {
+				s.mu.Lock()
+				s.setTagError(cfg.Error, errorConfig{
+					noDebugStack: cfg.NoDebugStack,
+					stackFrames:  cfg.StackFrames,
+					stackSkip:    cfg.SkipStackFrames,
+					message:      msg,
+					causeDepth:   errorCauseDepth(),
+				})
+				s.mu.Unlock()
+			}
 		}
 	}
 
@@ -661,18 +863,185 @@ func (s *Span) Finish(opts ...FinishOption) {
 		s.SetTag("go_execution_traced", "partial")
 	}
 
-	if s.Root() == s {
-		if tr, ok := getGlobalTracer().(*tracer); ok && tr.rulesSampling.traces.enabled() {
-			if !s.context.trace.isLocked() && s.context.trace.propagatingTag(keyDecisionMaker) != "-4" {
-				tr.rulesSampling.SampleTrace(s)
-			}
-		}
+	if tr != nil {
+		tr.beforeFinish(s, t)
 	}
 
 	s.finish(t)
+	if tr != nil && tr.config.synchronousSubmission {
+		tr.flushTrace(s)
+	}
 	orchestrion.GLSPopValue(sharedinternal.ActiveSpanKey)
 }
 
+// beforeFinish applies the span hooks configured on t to s, which is about to finish
+// at finishTime, and makes the sampling decision of its trace if s is its root.
+func (t *tracer) beforeFinish(s *Span, finishTime int64) {
+	if len(t.config.serviceMappingsByType) > 0 {
+		s.mapServiceByType(t.config.serviceMappingsByType, t.config.serviceName)
+	}
+	if len(t.config.measuredSpanTypes) > 0 {
+		s.measureByType(t.config.measuredSpanTypes)
+	}
+	if len(t.config.tagRemappings) > 0 {
+		s.remapTags(t.config.tagRemappings)
+	}
+	if len(t.config.spanProcessors) > 0 && !s.isFinished() {
+		for _, p := range t.config.spanProcessors {
+			p.OnFinish(s)
+		}
+	}
+
+	if s.Root() != s {
+		return
+	}
+	trace := s.context.trace
+	if t.rulesSampling.traces.enabled() && !trace.isLocked() && !trace.isManual() {
+		t.rulesSampling.SampleTrace(s)
+	}
+	if len(t.config.tailSamplingRules) > 0 && !trace.isLocked() && !trace.isManual() {
+		t.sampleTail(s, finishTime)
+	}
+	if decide := t.config.samplingDecider; decide != nil && !trace.isLocked() && !trace.isManual() {
+		if keep, priority, ok := decide(s); ok {
+			s.setSamplingPriority(deciderPriority(keep, priority), samplernames.Manual)
+		}
+	}
+	if p, ok := trace.forcedPriority(); ok && !trace.isLocked() {
+		s.setSamplingPriority(p, samplernames.Manual)
+	}
+}
+
+// mapServiceByType sets the service of s from the service of its span type in mappings,
+// see mappedService, and updates whether s is top level, since it may no longer share the
+// service of its parent, or now share it.
+func (s *Span) mapServiceByType(mappings map[string]string, defaultService string) {
+	var parent *Span
+	if s.parentID != 0 && s.context != nil && s.context.trace != nil {
+		parent = s.context.trace.span(s.parentID)
+	}
+	var parentService string
+	parentMapped := false
+	if parent != nil {
+		parent.mu.RLock()
+		parentService = parent.mappedService(mappings, defaultService)
+		parentMapped = parentService != parent.service
+		parent.mu.RUnlock()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return
+	}
+	service := s.mappedService(mappings, defaultService)
+	if service == s.service && !parentMapped {
+		return
+	}
+	s.service = service
+	if parent == nil {
+		// local roots and children of remote spans are always top level
+		return
+	}
+	if service != parentService {
+		s.setMetric(keyTopLevel, 1)
+	} else {
+		delete(s.metrics, keyTopLevel)
+	}
+}
+
+// mappedService returns the service of the span type of s in mappings, unless s was
+// given a service explicitly, other than the default service of its integration or
+// defaultService for the spans of integrations. s must be locked.
+func (s *Span) mappedService(mappings map[string]string, defaultService string) string {
+	service, ok := mappings[s.spanType]
+	if !ok {
+		return s.service
+	}
+	if s.serviceSet && (s.integration == "manual" ||
+		(s.service != defaultService && !namingschema.IsIntegrationService(s.service))) {
+		return s.service
+	}
+	return service
+}
+
+// measureByType marks s as measured if its span type is one of types. Top level
+// spans are skipped, since they are always measured.
+func (s *Span) measureByType(types map[string]struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished || s.metrics[keyTopLevel] == 1 {
+		return
+	}
+	if _, ok := types[s.spanType]; ok {
+		s.setMetric(keyMeasured, 1)
+	}
+}
+
+// isFinished reports whether s has already finished.
+func (s *Span) isFinished() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.finished
+}
+
+// remapTags renames the tags of s from the keys of mappings to their values, keeping
+// the tags already set under the new keys. The mappings are resolved against the tags
+// of s before any of them is renamed, so that they don't chain, and applied in the
+// order of their keys.
+func (s *Span) remapTags(mappings map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return
+	}
+	type tag struct {
+		from    string
+		meta    string
+		metric  float64
+		numeric bool
+	}
+	var tags []tag
+	for from := range mappings {
+		if v, ok := s.meta[from]; ok {
+			tags = append(tags, tag{from: from, meta: v})
+		} else if v, ok := s.metrics[from]; ok {
+			tags = append(tags, tag{from: from, metric: v, numeric: true})
+		}
+	}
+	if len(tags) == 0 {
+		return
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].from < tags[j].from })
+	for _, t := range tags {
+		delete(s.meta, t.from)
+		delete(s.metrics, t.from)
+	}
+	for _, t := range tags {
+		to := mappings[t.from]
+		if _, ok := s.meta[to]; ok {
+			continue
+		}
+		if _, ok := s.metrics[to]; ok {
+			continue
+		}
+		if t.numeric {
+			s.setMetric(to, t.metric)
+		} else {
+			s.setMeta(to, t.meta)
+		}
+	}
+}
+
+// deciderPriority returns the sampling priority matching the decision of
+// a sampling decider, see WithSamplingDecider.
+func deciderPriority(keep bool, priority int) int {
+	if keep {
+		return max(priority, ext.PriorityUserKeep)
+	}
+	return min(priority, ext.PriorityUserReject)
+}
+
 // SetOperationName sets or changes the operation name.
 func (s *Span) SetOperationName(operationName string) {
 	if s == nil {
@@ -755,10 +1124,6 @@ func (s *Span) finish(finishTime int64) {
 	}
 }
 
-// textNonParsable specifies the text that will be assigned to resources for which the resource
-// can not be parsed due to an obfuscation error.
-const textNonParsable = "Non-parsable SQL query"
-
 // obfuscatedResource returns the obfuscated version of the given resource. It is
 // obfuscated using the given obfuscator for the given span type typ.
 func obfuscatedResource(o *obfuscate.Obfuscator, typ, resource string) string {
@@ -770,7 +1135,7 @@ func obfuscatedResource(o *obfuscate.Obfuscator, typ, resource string) string {
 		oq, err := o.ObfuscateSQLString(resource)
 		if err != nil {
 			log.Error("Error obfuscating stats group resource %q: %v", resource, err.Error())
-			return textNonParsable
+			return obfuscation.NonParsableSQL
 		}
 		return oq.Query
 	case "redis":
@@ -929,16 +1294,27 @@ func getMetric(s *Span, key string) (float64, bool) {
 }
 
 const (
-	keySamplingPriority     = "_sampling_priority_v1"
+	keySamplingPriority = "_sampling_priority_v1"
+	// keySamplingPriorityRate holds the rate received from the agent which was used to
+	// sample the trace. It is only set on local root spans sampled by the priority
+	// sampler, i.e. when no sampling rule matched.
 	keySamplingPriorityRate = "_dd.agent_psr"
 	keyDecisionMaker        = "_dd.p.dm"
 	keyServiceHash          = "_dd.dm.service_hash"
 	keyOrigin               = "_dd.origin"
 	keyReparentID           = "_dd.parent_id"
+	// keySamplingHistory holds the successive sampling priorities of the trace, along with
+	// the samplers which set them, see WithSamplingHistory.
+	keySamplingHistory = "_dd.sampling.history"
 	// keyHostname can be used to override the agent's hostname detection when using `WithHostname`.
 	// which is set via auto-detection.
-	keyHostname                = "_dd.hostname"
+	keyHostname = "_dd.hostname"
+	// keyRulesSamplerAppliedRate holds the rate of the sampling rule, or of the global
+	// sample rate, which matched the local root span, whether the trace was kept or not.
+	// It replaces keySamplingPriorityRate.
 	keyRulesSamplerAppliedRate = "_dd.rule_psr"
+	// keyRulesSamplerLimiterRate holds the effective rate of the rate limiter. It is only
+	// set when the limiter was consulted, i.e. when the trace was kept by the rule rate.
 	keyRulesSamplerLimiterRate = "_dd.limit_psr"
 	keyMeasured                = "_dd.measured"
 	// keyTopLevel is the key of top level metric indicating if a span is top level.
@@ -970,6 +1346,15 @@ const (
 	keyBaseService = "_dd.base_service"
 	// keyProcessTags contains a list of process tags to indentify the service.
 	keyProcessTags = "_dd.tags.process"
+	// keySpanLinksDropped holds the number of span links dropped because the span reached
+	// the maximum number of links.
+	keySpanLinksDropped = "_dd.span_links.dropped"
+	// keyPartialVersion holds the version of the snapshot of a span which is still running,
+	// see WithLongRunningSpans.
+	keyPartialVersion = "_dd.partial_version"
+	// keyWasLongRunning marks the final version of a span which was sent as snapshots while
+	// it was running, see WithLongRunningSpans.
+	keyWasLongRunning = "_dd.was_long_running"
 )
 
 // The following set of tags is used for user monitoring and set through calls to span.SetUser().
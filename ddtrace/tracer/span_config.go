@@ -39,6 +39,16 @@ type StartSpanConfig struct {
 
 	// SpanLink represents a causal relationship between two spans. A span can have multiple links.
 	SpanLinks []SpanLink
+
+	// NoBaggageInheritance starts the span with empty baggage, instead of the baggage
+	// of its parent.
+	NoBaggageInheritance bool
+
+	// Origin sets the origin of the trace started by the span, see WithOrigin.
+	Origin string
+
+	// AlwaysKeep forces the trace of the span to be kept, see WithAlwaysKeep.
+	AlwaysKeep bool
 }
 
 // NewStartSpanConfig allows to build a base config struct. It accepts the same options as StartSpan.
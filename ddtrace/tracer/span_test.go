@@ -6,6 +6,8 @@
 package tracer
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -18,6 +20,7 @@ import (
 	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
 	sharedinternal "github.com/DataDog/dd-trace-go/v2/internal"
 	"github.com/DataDog/dd-trace-go/v2/internal/log"
+	"github.com/DataDog/dd-trace-go/v2/internal/namingschema"
 	"github.com/DataDog/dd-trace-go/v2/internal/samplernames"
 	"github.com/DataDog/dd-trace-go/v2/internal/traceprof"
 
@@ -325,6 +328,63 @@ func TestSpanFinishWithNegativeDuration(t *testing.T) {
 	assert.Equal(int64(0), span.duration)
 }
 
+func TestSpanStartTimeAndDuration(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tracer, _, _, stop, err := startTestTracer(t)
+	require.NoError(t, err)
+	defer stop()
+
+	span := tracer.StartSpan("web.request", StartTime(start))
+	assert.True(t, start.Equal(span.StartTime()))
+	assert.Zero(t, span.Duration())
+
+	span.Finish(FinishTime(start.Add(3 * time.Second)))
+	assert.True(t, start.Equal(span.StartTime()))
+	assert.Equal(t, 3*time.Second, span.Duration())
+
+	var nilSpan *Span
+	assert.True(t, nilSpan.StartTime().IsZero())
+	assert.Zero(t, nilSpan.Duration())
+}
+
+func TestSpanMaxLinks(t *testing.T) {
+	tracer, _, _, stop, err := startTestTracer(t, WithMaxSpanLinks(3))
+	require.NoError(t, err)
+	defer stop()
+
+	links := make([]SpanLink, 4)
+	for i := range links {
+		links[i] = SpanLink{TraceID: uint64(i + 1), SpanID: uint64(i + 1)}
+	}
+
+	t.Run("start", func(t *testing.T) {
+		span := tracer.StartSpan("op", WithSpanLinks(links))
+		assert.Equal(t, links[:3], span.spanLinks)
+		assert.Equal(t, 1.0, span.metrics[keySpanLinksDropped])
+		span.AddLink(SpanLink{TraceID: 10, SpanID: 10})
+		assert.Equal(t, links[:3], span.spanLinks)
+		assert.Equal(t, 2.0, span.metrics[keySpanLinksDropped])
+		span.Finish()
+	})
+
+	t.Run("add", func(t *testing.T) {
+		span := tracer.StartSpan("op")
+		for _, l := range links {
+			span.AddLink(l)
+		}
+		assert.Equal(t, links[:3], span.spanLinks)
+		assert.Equal(t, 1.0, span.metrics[keySpanLinksDropped])
+		span.Finish()
+	})
+
+	t.Run("under-limit", func(t *testing.T) {
+		span := tracer.StartSpan("op", WithSpanLinks(links[:2]))
+		assert.Len(t, span.spanLinks, 2)
+		assert.NotContains(t, span.metrics, keySpanLinksDropped)
+		span.Finish()
+	})
+}
+
 func TestSpanFinishWithError(t *testing.T) {
 	assert := assert.New(t)
 
@@ -351,6 +411,46 @@ func TestSpanFinishWithErrorNoDebugStack(t *testing.T) {
 	assert.Empty(span.meta[ext.ErrorStack])
 }
 
+func TestSpanFinishWithErrorClassifier(t *testing.T) {
+	tracer, _, _, stop, err := startTestTracer(t, WithErrorClassifier(func(err error) (bool, string) {
+		if errors.Is(err, context.Canceled) {
+			return false, ""
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return true, "request timed out"
+		}
+		return true, ""
+	}))
+	require.NoError(t, err)
+	defer stop()
+
+	t.Run("ignored", func(t *testing.T) {
+		span := tracer.StartSpan("web.request")
+		span.Finish(WithError(fmt.Errorf("query: %w", context.Canceled)))
+
+		assert.Equal(t, int32(0), span.error)
+		assert.NotContains(t, span.meta, ext.ErrorMsg)
+		assert.NotContains(t, span.meta, ext.ErrorType)
+	})
+
+	t.Run("message", func(t *testing.T) {
+		span := tracer.StartSpan("web.request")
+		span.Finish(WithError(context.DeadlineExceeded))
+
+		assert.Equal(t, int32(1), span.error)
+		assert.Equal(t, "request timed out", span.meta[ext.ErrorMsg])
+		assert.Equal(t, "context.deadlineExceededError", span.meta[ext.ErrorType])
+	})
+
+	t.Run("default", func(t *testing.T) {
+		span := tracer.StartSpan("web.request")
+		span.Finish(WithError(errors.New("test error")))
+
+		assert.Equal(t, int32(1), span.error)
+		assert.Equal(t, "test error", span.meta[ext.ErrorMsg])
+	})
+}
+
 func TestSpanFinishWithErrorStackFrames(t *testing.T) {
 	assert := assert.New(t)
 
@@ -1185,6 +1285,384 @@ func TestRootSpanAccessor(t *testing.T) {
 		require.Equal(t, root, child21.Root())
 		require.Equal(t, root, child211.Root())
 	})
+
+	t.Run("remote-parent", func(t *testing.T) {
+		carrier := TextMapCarrier{
+			DefaultTraceIDHeader:  "1",
+			DefaultParentIDHeader: "2",
+			DefaultPriorityHeader: "1",
+		}
+		sctx, err := tracer.Extract(carrier)
+		require.NoError(t, err)
+		require.NotNil(t, sctx.trace, "the local spans must share the extracted trace")
+
+		var wg sync.WaitGroup
+		spans := make([]*Span, 10)
+		for i := range spans {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				spans[i] = tracer.StartSpan("local", ChildOf(sctx))
+			}(i)
+		}
+		wg.Wait()
+		root := spans[0].Root()
+		require.NotNil(t, root)
+		require.Contains(t, spans, root)
+		for _, sp := range spans {
+			require.Equal(t, root, sp.Root())
+		}
+		for _, sp := range spans {
+			sp.Finish()
+		}
+	})
+}
+
+func TestSpanServiceMappingByType(t *testing.T) {
+	tracer, _, _, stop, err := startTestTracer(t,
+		WithService("svc"),
+		WithServiceMappingByType(map[string]string{ext.SpanTypeRedis: "redis-svc"}),
+	)
+	require.NoError(t, err)
+	defer stop()
+
+	namingschema.RecordIntegrationService("redis.client")
+
+	root := tracer.StartSpan("http.request", SpanType(ext.SpanTypeWeb))
+	mapped := tracer.StartSpan("redis.command", ChildOf(root.Context()), Measured())
+	mapped.SetTag(ext.SpanType, ext.SpanTypeRedis)
+	nested := tracer.StartSpan("redis.command", ChildOf(mapped.Context()), SpanType(ext.SpanTypeRedis))
+	explicit := tracer.StartSpan("redis.command", ChildOf(root.Context()), ServiceName("cache"), SpanType(ext.SpanTypeRedis))
+	explicitDefault := tracer.StartSpan("redis.command", ChildOf(root.Context()), ServiceName("svc"), SpanType(ext.SpanTypeRedis))
+	integration := tracer.StartSpan("redis.command", ChildOf(root.Context()), ServiceName("redis.client"),
+		SpanType(ext.SpanTypeRedis), Tag(ext.Component, "gomodule/redigo"))
+	for _, s := range []*Span{nested, mapped, explicit, explicitDefault, integration} {
+		s.Finish()
+	}
+	root.Finish()
+
+	assert := assert.New(t)
+	assert.Equal("svc", root.service)
+	assert.Equal("redis-svc", mapped.service)
+	assert.Equal(1.0, mapped.metrics[keyTopLevel])
+	assert.Equal(1.0, mapped.metrics[keyMeasured])
+	// the parent of nested is mapped to the same service
+	assert.Equal("redis-svc", nested.service)
+	assert.NotContains(nested.metrics, keyTopLevel)
+	assert.Equal("cache", explicit.service)
+	assert.Equal("svc", explicitDefault.service)
+	assert.Equal("redis-svc", integration.service)
+	assert.Equal(1.0, integration.metrics[keyTopLevel])
+}
+
+func TestSpanMeasuredSpanTypes(t *testing.T) {
+	tracer, _, _, stop, err := startTestTracer(t, WithMeasuredSpanTypes(ext.SpanTypeRedis, ext.SpanTypeSQL))
+	require.NoError(t, err)
+	defer stop()
+
+	root := tracer.StartSpan("http.request", SpanType(ext.SpanTypeRedis))
+	redis := tracer.StartSpan("redis.command", ChildOf(root.Context()))
+	redis.SetTag(ext.SpanType, ext.SpanTypeRedis)
+	sql := tracer.StartSpan("sql.query", ChildOf(root.Context()), SpanType(ext.SpanTypeSQL))
+	web := tracer.StartSpan("http.request", ChildOf(root.Context()), SpanType(ext.SpanTypeWeb))
+	redis.Finish()
+	sql.Finish()
+	web.Finish()
+	root.Finish()
+
+	assert.Equal(t, 1.0, redis.metrics[keyMeasured])
+	assert.Equal(t, 1.0, sql.metrics[keyMeasured])
+	assert.NotContains(t, web.metrics, keyMeasured)
+	// top level spans are always measured
+	assert.NotContains(t, root.metrics, keyMeasured)
+	assert.Equal(t, 1.0, root.metrics[keyTopLevel])
+}
+
+func TestSpanErrorCauses(t *testing.T) {
+	tracer, _, _, stop, err := startTestTracer(t, WithErrorCauseDepth(2))
+	require.NoError(t, err)
+	defer stop()
+
+	root := errors.New("connection refused")
+	wrapped := fmt.Errorf("query users: %w", fmt.Errorf("dial db: %w", root))
+
+	t.Run("finish", func(t *testing.T) {
+		span := tracer.StartSpan("op")
+		span.Finish(WithError(fmt.Errorf("request failed: %w", wrapped)))
+		assert.Equal(t, "query users: dial db: connection refused", span.meta["error.cause.1"])
+		assert.Equal(t, "*fmt.wrapError", span.meta["error.cause.1.type"])
+		assert.Equal(t, "dial db: connection refused", span.meta["error.cause.2"])
+		assert.NotContains(t, span.meta, "error.cause.3")
+	})
+
+	t.Run("tag", func(t *testing.T) {
+		span := tracer.StartSpan("op")
+		span.SetTag(ext.Error, wrapped)
+		assert.Equal(t, "dial db: connection refused", span.meta["error.cause.1"])
+		assert.Equal(t, "connection refused", span.meta["error.cause.2"])
+		assert.Equal(t, "*errors.errorString", span.meta["error.cause.2.type"])
+
+		span.SetTag(ext.Error, errors.Join(root))
+		assert.Equal(t, "connection refused", span.meta["error.cause.1"])
+		assert.NotContains(t, span.meta, "error.cause.2")
+		span.Finish()
+	})
+}
+
+func TestSpanTagRemapper(t *testing.T) {
+	tracer, _, _, stop, err := startTestTracer(t, WithTagRemapper(map[string]string{
+		"http.status":  ext.HTTPCode,
+		"retries":      "http.retries",
+		"legacy.route": ext.HTTPRoute,
+		"route":        ext.ResourceName,
+		"src":          "dst",
+		"dst":          "src",
+	}))
+	require.NoError(t, err)
+	defer stop()
+
+	span := tracer.StartSpan("http.request", Tag("http.status", "200"), Tag("retries", 2))
+	span.SetTag("legacy.route", "/users/{id}")
+	span.SetTag(ext.HTTPRoute, "/users/:id")
+	span.SetTag("route", "GET /users/:id")
+	span.SetTag("src", "a")
+	span.SetTag("dst", "b")
+	span.Finish()
+
+	// the mappings don't chain
+	assert.Equal(t, "b", span.meta["src"])
+	assert.Equal(t, "a", span.meta["dst"])
+	assert.Equal(t, "GET /users/:id", span.resource)
+	assert.NotContains(t, span.meta, "route")
+
+	assert.Equal(t, "200", span.meta[ext.HTTPCode])
+	assert.Equal(t, 2.0, span.metrics["http.retries"])
+	assert.Equal(t, "/users/:id", span.meta[ext.HTTPRoute])
+	assert.NotContains(t, span.meta, "http.status")
+	assert.NotContains(t, span.metrics, "retries")
+	assert.NotContains(t, span.meta, "legacy.route")
+}
+
+func TestSpanFinishSynchronousSubmission(t *testing.T) {
+	tracer, transport, _, stop, err := startTestTracer(t, WithSynchronousSubmission(true))
+	require.NoError(t, err)
+	defer stop()
+
+	root := tracer.StartSpan("http.request")
+	child := tracer.StartSpan("db.query", ChildOf(root.Context()))
+	child.Finish()
+	assert.Eventually(t, func() bool { return transport.Len() == 1 }, time.Second*timeMultiplicator, time.Millisecond)
+	traces := transport.Traces()
+	require.Len(t, traces[0], 1)
+	assert.Equal(t, "db.query", traces[0][0].name)
+
+	root.Finish()
+	assert.Eventually(t, func() bool { return transport.Len() == 1 }, time.Second*timeMultiplicator, time.Millisecond)
+	traces = transport.Traces()
+	require.Len(t, traces[0], 1)
+	assert.Equal(t, "http.request", traces[0][0].name)
+}
+
+func TestSpanFinishSynchronousSubmissionSampling(t *testing.T) {
+	// sample returns the sampling priority of a trace made of a root and a child, the
+	// child finishing first, with or without synchronous submission.
+	sample := func(t *testing.T, synchronous bool, opt StartOption, childOpts []FinishOption, rootTag string) float64 {
+		tracer, _, _, stop, err := startTestTracer(t, WithSynchronousSubmission(synchronous), opt)
+		require.NoError(t, err)
+		defer stop()
+
+		root := tracer.StartSpan("http.request")
+		child := tracer.StartSpan("db.query", ChildOf(root.Context()))
+		child.Finish(childOpts...)
+		if rootTag != "" {
+			root.SetTag(rootTag, true)
+		}
+		root.Finish()
+		p, _ := root.Context().SamplingPriority()
+		return float64(p)
+	}
+	tailRules := WithTailSamplingRules(TailSamplingRule{Error: true, Rate: 1}, TailSamplingRule{Rate: 0})
+	for name, tt := range map[string]struct {
+		opt       StartOption
+		childOpts []FinishOption
+		rootTag   string
+		priority  float64
+	}{
+		"manual-drop": {opt: WithSamplingRules(TraceSamplingRules(Rule{Rate: 1})), rootTag: ext.ManualDrop, priority: ext.PriorityUserReject},
+		"decider":     {opt: WithSamplingDecider(func(*Span) (bool, int, bool) { return false, 0, true }), priority: ext.PriorityUserReject},
+		"tail-error":  {opt: tailRules, childOpts: []FinishOption{WithError(errors.New("boom"))}, priority: ext.PriorityUserKeep},
+		"tail-other":  {opt: tailRules, priority: ext.PriorityUserReject},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.priority, sample(t, false, tt.opt, tt.childOpts, tt.rootTag))
+			assert.Equal(t, tt.priority, sample(t, true, tt.opt, tt.childOpts, tt.rootTag))
+		})
+	}
+}
+
+func TestSpanFinishWithSamplingDecider(t *testing.T) {
+	decider := func(s *Span) (bool, int, bool) {
+		s.mu.RLock()
+		account, ok := s.meta["account"]
+		s.mu.RUnlock()
+		if !ok {
+			return false, 0, false
+		}
+		return account == "vip", 0, true
+	}
+	tracer, _, _, stop, err := startTestTracer(t,
+		WithSamplingRules(TraceSamplingRules(Rule{Rate: 0})),
+		WithSamplingDecider(decider),
+	)
+	require.NoError(t, err)
+	defer stop()
+
+	for _, tt := range []struct {
+		account  string
+		priority float64
+	}{
+		{"vip", ext.PriorityUserKeep},
+		{"free", ext.PriorityUserReject},
+		{"", ext.PriorityUserReject}, // the rule sampler decides
+	} {
+		t.Run(tt.account, func(t *testing.T) {
+			root := tracer.StartSpan("http.request")
+			child := tracer.StartSpan("db.query", ChildOf(root.Context()))
+			if tt.account != "" {
+				child.SetTag("account", tt.account)
+				root.SetTag("account", tt.account)
+			}
+			child.Finish()
+			root.Finish()
+			assert.Equal(t, tt.priority, root.metrics[keySamplingPriority])
+		})
+	}
+
+	t.Run("manual", func(t *testing.T) {
+		root := tracer.StartSpan("http.request")
+		root.SetTag("account", "vip")
+		root.SetTag(ext.ManualDrop, true)
+		root.Finish()
+		assert.Equal(t, float64(ext.PriorityUserReject), root.metrics[keySamplingPriority])
+	})
+
+	assert.Equal(t, ext.PriorityUserKeep, deciderPriority(true, -1))
+	assert.Equal(t, ext.PriorityUserKeep, deciderPriority(true, ext.PriorityAutoKeep))
+	assert.Equal(t, ext.PriorityUserReject, deciderPriority(false, 2))
+	assert.Equal(t, ext.PriorityUserReject, deciderPriority(false, ext.PriorityAutoReject))
+	assert.Equal(t, 2, deciderPriority(true, 2))
+}
+
+func TestSpanFinishWithTailSamplingRules(t *testing.T) {
+	tracer, _, _, stop, err := startTestTracer(t,
+		WithSamplingRules(TraceSamplingRules(Rule{Rate: 1})),
+		WithTailSamplingRules(
+			TailSamplingRule{Error: true, Rate: 1},
+			TailSamplingRule{MinDuration: time.Minute, Rate: 1},
+			TailSamplingRule{Tags: map[string]string{"account": "vip-*"}, Rate: 1},
+			TailSamplingRule{Rate: 2}, // ignored
+			TailSamplingRule{Rate: 0},
+		),
+	)
+	require.NoError(t, err)
+	defer stop()
+
+	for name, tt := range map[string]struct {
+		childOpts []FinishOption
+		account   string
+		start     time.Time
+		priority  float64
+	}{
+		"error":    {childOpts: []FinishOption{WithError(errors.New("boom"))}, priority: ext.PriorityUserKeep},
+		"slow":     {start: time.Now().Add(-time.Hour), priority: ext.PriorityUserKeep},
+		"tags":     {account: "vip-1", priority: ext.PriorityUserKeep},
+		"other":    {account: "free", priority: ext.PriorityUserReject},
+		"no-match": {priority: ext.PriorityUserReject},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var rootOpts []StartSpanOption
+			if !tt.start.IsZero() {
+				rootOpts = append(rootOpts, StartTime(tt.start))
+			}
+			root := tracer.StartSpan("http.request", rootOpts...)
+			child := tracer.StartSpan("db.query", ChildOf(root.Context()))
+			if tt.account != "" {
+				child.SetTag("account", tt.account)
+			}
+			child.Finish(tt.childOpts...)
+			root.Finish()
+			assert.Equal(t, tt.priority, root.metrics[keySamplingPriority])
+			assert.Contains(t, root.metrics, keyRulesSamplerAppliedRate)
+		})
+	}
+
+	t.Run("propagated", func(t *testing.T) {
+		root := tracer.StartSpan("http.request")
+		require.NoError(t, tracer.Inject(root.Context(), TextMapCarrier{}))
+		root.Finish()
+		// the decision sent downstream can't be changed
+		assert.Equal(t, float64(ext.PriorityUserKeep), root.metrics[keySamplingPriority])
+	})
+
+	t.Run("manual", func(t *testing.T) {
+		for tag, priority := range map[string]float64{
+			ext.ManualKeep: ext.PriorityUserKeep,
+			ext.ManualDrop: ext.PriorityUserReject,
+		} {
+			root := tracer.StartSpan("http.request")
+			child := tracer.StartSpan("db.query", ChildOf(root.Context()))
+			root.SetTag(tag, true)
+			// the error rule keeps the trace, and the catch-all rule drops it
+			if tag == ext.ManualDrop {
+				child.Finish(WithError(errors.New("boom")))
+			} else {
+				child.Finish()
+			}
+			root.Finish()
+			// the manual decision isn't overridden by the tail rules
+			assert.Equal(t, priority, root.metrics[keySamplingPriority], tag)
+		}
+	})
+}
+
+func TestSpanCapturePayload(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		tracer, _, _, stop, err := startTestTracer(t)
+		require.NoError(t, err)
+		defer stop()
+
+		s := tracer.StartSpan("http.request")
+		assert.False(t, s.ShouldCapturePayload())
+		s.CapturePayload("http.request.body", []byte("hello"))
+		assert.NotContains(t, s.meta, "http.request.body")
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		tracer, _, _, stop, err := startTestTracer(t,
+			WithPayloadCapture(1),
+			WithPayloadCaptureMaxSize(12),
+			WithPayloadRedactor(func(tag string, payload []byte) []byte {
+				return bytes.ReplaceAll(payload, []byte("secret"), []byte("******"))
+			}),
+		)
+		require.NoError(t, err)
+		defer stop()
+
+		s := tracer.StartSpan("http.request")
+		assert.True(t, s.ShouldCapturePayload())
+		s.CapturePayload("http.request.body", []byte("password=secret&user=jane"))
+		s.CapturePayload("http.response.body", []byte("héllo"))
+		assert.Equal(t, "password=***", s.meta["http.request.body"])
+		assert.Equal(t, "héllo", s.meta["http.response.body"])
+
+		// the rate of one span per second is spent
+		other := tracer.StartSpan("http.request")
+		assert.False(t, other.ShouldCapturePayload())
+		other.CapturePayload("http.request.body", []byte("hello"))
+		assert.NotContains(t, other.meta, "http.request.body")
+		// the decision is kept for the span
+		assert.True(t, s.ShouldCapturePayload())
+	})
 }
 
 func TestSpanStartAndFinishLogs(t *testing.T) {
@@ -1232,6 +1710,12 @@ func TestSetUserPropagatedUserID(t *testing.T) {
 	assert.True(t, s.context.updated)
 }
 
+func TestSetUserNoTrace(t *testing.T) {
+	assert.NotPanics(t, func() {
+		new(Span).SetUser("userino", WithUserEmail("user@example.com"), WithPropagation())
+	})
+}
+
 func TestStartChild(t *testing.T) {
 	t.Run("own-service", func(t *testing.T) {
 		assert := assert.New(t)
@@ -9,6 +9,7 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
@@ -102,6 +103,16 @@ type SpanContext struct {
 	// propagated this context, but didn't send any spans to Datadog.
 	reparentID string
 	isRemote   bool
+	traceFlags byte // trace-flags of the W3C traceparent header the context was extracted from
+
+	// samplingThreshold is the OpenTelemetry consistent probability sampling
+	// threshold, i.e. the `th` sub-key of the `ot` tracestate list-member,
+	// in its hex encoded form. It is empty when the upstream didn't set it.
+	samplingThreshold string
+	// samplingRandomness is the explicit randomness value of OpenTelemetry consistent
+	// probability sampling, i.e. the `rv` sub-key of the `ot` tracestate list-member,
+	// in its hex encoded form. It is empty when the upstream didn't set it.
+	samplingRandomness string
 
 	// the below group should propagate cross-process
 
@@ -164,16 +175,20 @@ func newSpanContext(span *Span, parent *SpanContext) *SpanContext {
 
 	context.traceID.SetLower(span.traceID)
 	if parent != nil {
-		if !parent.baggageOnly {
-			context.traceID.SetUpper(parent.traceID.Upper())
-			context.trace = parent.trace
-			context.origin = parent.origin
-			context.errors.Store(parent.errors.Load())
-		}
+		// a baggage-only parent doesn't carry a trace: the span starts a new one,
+		// carrying forward the baggage.
 		parent.ForeachBaggageItem(func(k, v string) bool {
 			context.setBaggageItem(k, v)
 			return true
 		})
+	}
+	if parent != nil && !parent.baggageOnly {
+		context.traceID.SetUpper(parent.traceID.Upper())
+		context.trace = parent.trace
+		context.origin = parent.origin
+		context.samplingThreshold = parent.samplingThreshold
+		context.samplingRandomness = parent.samplingRandomness
+		context.errors.Store(parent.errors.Load())
 	} else if sharedinternal.BoolEnv("DD_TRACE_128_BIT_TRACEID_GENERATION_ENABLED", true) {
 		// add 128 bit trace id, if enabled, formatted as big-endian:
 		// <32-bit unix seconds> <32 bits of zero> <64 random bits>
@@ -187,10 +202,6 @@ func newSpanContext(span *Span, parent *SpanContext) *SpanContext {
 	if context.trace == nil {
 		context.trace = newTrace()
 	}
-	if context.trace.root == nil {
-		// first span in the trace can safely be assumed to be the root
-		context.trace.root = span
-	}
 	// put span in context's trace
 	context.trace.push(span)
 	// setting context.updated to false here is necessary to distinguish
@@ -240,6 +251,17 @@ func (c *SpanContext) TraceIDUpper() uint64 {
 	return c.traceID.Upper()
 }
 
+// Is128Bit reports whether the trace ID of the context is a 128-bit one, i.e. whether its
+// upper 64 bits are set. It can be used to decide between the full 32-hex and the
+// 16-hex lower forms of the trace ID, or to detect an upstream which only propagated
+// the lower 64 bits.
+func (c *SpanContext) Is128Bit() bool {
+	if c == nil {
+		return false
+	}
+	return c.traceID.HasUpper()
+}
+
 // SpanLinks implements ddtrace.SpanContext
 func (c *SpanContext) SpanLinks() []SpanLink {
 	cp := make([]SpanLink, len(c.spanLinks))
@@ -264,6 +286,23 @@ func (c *SpanContext) ForeachBaggageItem(handler func(k, v string) bool) {
 	}
 }
 
+// BaggageByteSize returns the size in bytes of the baggage header that would be
+// injected for the baggage items of c, with keys and values encoded as on injection.
+// The size is the one of all the items: the limits applied on injection, of 64 items
+// and 8192 bytes, aren't taken into account, so callers can compare it against them.
+func (c *SpanContext) BaggageByteSize() int {
+	size, n := 0, 0
+	c.ForeachBaggageItem(func(k, v string) bool {
+		if n > 0 {
+			size++ // ','
+		}
+		size += len(encodeKey(k)) + 1 + len(encodeValue(v))
+		n++
+		return true
+	})
+	return size
+}
+
 // sets the sampling priority and decision maker (based on `sampler`).
 func (c *SpanContext) setSamplingPriority(p int, sampler samplernames.SamplerName) {
 	if c.trace == nil {
@@ -275,6 +314,17 @@ func (c *SpanContext) setSamplingPriority(p int, sampler samplernames.SamplerNam
 	}
 }
 
+// TraceFlags returns the trace-flags found in the W3C traceparent header which the span
+// context was extracted from, such as the sampled (0x01) and, as defined by W3C Trace
+// Context Level 2, random (0x02) flags. It returns 0 if the span context wasn't extracted
+// from a traceparent header.
+func (c *SpanContext) TraceFlags() byte {
+	if c == nil {
+		return 0
+	}
+	return c.traceFlags
+}
+
 func (c *SpanContext) SamplingPriority() (p int, ok bool) {
 	if c == nil || c.trace == nil {
 		return 0, false
@@ -282,6 +332,16 @@ func (c *SpanContext) SamplingPriority() (p int, ok bool) {
 	return c.trace.samplingPriority()
 }
 
+// IsSampled reports whether the trace of the span context is currently set to be kept,
+// i.e. whether its sampling priority is at least ext.PriorityAutoKeep. It can be used to
+// only do costly work, such as capturing extra context, for the traces which are kept.
+// The decision may still change until the local root span finishes, e.g. when a span is
+// tagged with ext.ManualKeep. It returns false if no sampling decision was made.
+func (c *SpanContext) IsSampled() bool {
+	p, ok := c.SamplingPriority()
+	return ok && p >= ext.PriorityAutoKeep
+}
+
 func (c *SpanContext) setBaggageItem(key, val string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -292,6 +352,14 @@ func (c *SpanContext) setBaggageItem(key, val string) {
 	c.baggage[key] = val
 }
 
+// clearBaggage removes all the baggage items of the context.
+func (c *SpanContext) clearBaggage() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.baggage = nil
+	atomic.StoreUint32(&c.hasBaggage, 0)
+}
+
 func (c *SpanContext) baggageItem(key string) string {
 	if atomic.LoadUint32(&c.hasBaggage) == 0 {
 		return ""
@@ -304,6 +372,90 @@ func (c *SpanContext) baggageItem(key string) string {
 // finish marks this span as finished in the trace.
 func (c *SpanContext) finish() { c.trace.finishedOne(c.span) }
 
+// spanContextTextVersion is the version of the encoding produced by SpanContext.Serialize.
+const spanContextTextVersion = "1"
+
+// Serialize encodes the span context into a single token which can be persisted, e.g. by
+// durable workflows, and decoded with DeserializeSpanContext later on to resume the trace. The token holds the 128-bit trace ID,
+// the span ID, the sampling priority, the origin and the propagating tags of the trace, but
+// not the baggage. It is a URL query string, such as:
+//
+//	_dd.p.dm=-1&o=synthetics&p=1&s=00f067aa0ba902b7&t=4bf92f3577b34da6a3ce929d0e0e4736&v=1
+//
+// It returns ErrInvalidSpanContext if the span context has no trace or span ID.
+func (c *SpanContext) Serialize() ([]byte, error) {
+	if c == nil || c.traceID.Empty() || c.spanID == 0 {
+		return nil, ErrInvalidSpanContext
+	}
+	v := url.Values{}
+	v.Set("v", spanContextTextVersion)
+	v.Set("t", c.traceID.HexEncoded())
+	v.Set("s", spanIDHexEncoded(c.spanID, 16))
+	if p, ok := c.SamplingPriority(); ok {
+		v.Set("p", strconv.Itoa(p))
+	}
+	if c.origin != "" {
+		v.Set("o", c.origin)
+	}
+	if c.trace != nil {
+		c.trace.iteratePropagatingTags(func(k, val string) bool {
+			// the upper part of the trace ID is already encoded in t
+			if strings.HasPrefix(k, "_dd.p.") && k != keyTraceID128 {
+				v.Set(k, val)
+			}
+			return true
+		})
+	}
+	return []byte(v.Encode()), nil
+}
+
+// DeserializeSpanContext decodes a span context encoded by SpanContext.Serialize, which
+// can then be used to start child spans with ChildOf. Like extracted span contexts, it
+// is remote. It returns an error wrapping ErrSpanContextCorrupted if data is not a valid
+// encoding.
+func DeserializeSpanContext(data []byte) (*SpanContext, error) {
+	v, err := url.ParseQuery(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrSpanContextCorrupted, err)
+	}
+	if version := v.Get("v"); version != spanContextTextVersion {
+		return nil, fmt.Errorf("%w: unsupported version %q", ErrSpanContextCorrupted, version)
+	}
+	ctx := SpanContext{isRemote: true}
+	tid := v.Get("t")
+	if len(tid) != 32 || !isValidID(tid) {
+		return nil, fmt.Errorf("%w: malformed trace ID %q", ErrSpanContextCorrupted, tid)
+	}
+	if _, err := hex.Decode(ctx.traceID[:], []byte(tid)); err != nil {
+		return nil, fmt.Errorf("%w: malformed trace ID %q", ErrSpanContextCorrupted, tid)
+	}
+	sid := v.Get("s")
+	if len(sid) != 16 || !isValidID(sid) {
+		return nil, fmt.Errorf("%w: malformed span ID %q", ErrSpanContextCorrupted, sid)
+	}
+	ctx.spanID, _ = parseHexUint64(sid)
+	if ctx.traceID.Empty() || ctx.spanID == 0 {
+		return nil, fmt.Errorf("%w: missing trace or span ID", ErrSpanContextCorrupted)
+	}
+	if p := v.Get("p"); p != "" {
+		priority, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed sampling priority %q", ErrSpanContextCorrupted, p)
+		}
+		ctx.setSamplingPriority(priority, samplernames.Unknown)
+	}
+	ctx.origin = v.Get("o")
+	for k := range v {
+		if strings.HasPrefix(k, "_dd.p.") && k != keyTraceID128 {
+			setPropagatingTag(&ctx, k, v.Get(k))
+		}
+	}
+	if ctx.traceID.HasUpper() {
+		setPropagatingTag(&ctx, keyTraceID128, ctx.traceID.UpperHex())
+	}
+	return &ctx, nil
+}
+
 // safeDebugString returns a safe string representation of the SpanContext for debug logging.
 // It excludes potentially sensitive data like baggage contents while preserving useful debugging information.
 func (c *SpanContext) safeDebugString() string {
@@ -349,6 +501,12 @@ type trace struct {
 	priority         *float64          // sampling priority
 	locked           bool              // specifies if the sampling priority can be altered
 	samplingDecision samplingDecision  // samplingDecision indicates whether to send the trace to the agent.
+	flushedAt        int64             // start of the trace, or time of its last partial flush, in nanoseconds
+	ignored          bool              // the root span has an ignored resource, see WithIgnoreResources
+	samplingHistory  []string          // changes of the sampling priority, see WithSamplingHistory
+	forcedDecision   samplingDecision  // decision forced through the context, see WithForceKeep
+	manual           bool              // the sampling priority was set manually, e.g. with ext.ManualKeep
+	flushed          []*Span           // spans flushed before the trace completed, see flushFinished
 
 	// root specifies the root of the trace, if known; it is nil when a span
 	// context is extracted from a carrier, at which point there are no spans in
@@ -405,6 +563,52 @@ func (t *trace) drop() {
 	atomic.CompareAndSwapUint32((*uint32)(&t.samplingDecision), uint32(decisionNone), uint32(decisionDrop))
 }
 
+// span returns the span of the trace with the given ID, if any.
+func (t *trace) span(id uint64) *Span {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for i := len(t.spans) - 1; i >= 0; i-- {
+		if s := t.spans[i]; s.spanID == id {
+			return s
+		}
+	}
+	return nil
+}
+
+// isManual reports whether the sampling priority of the trace was set manually, locally
+// or by an upstream service.
+func (t *trace) isManual() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.manual || t.propagatingTags[keyDecisionMaker] == "-4"
+}
+
+// forceDecision forces the trace to be kept or dropped when its root span finishes, see
+// WithForceKeep.
+func (t *trace) forceDecision(keep bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if keep {
+		t.forcedDecision = decisionKeep
+	} else {
+		t.forcedDecision = decisionDrop
+	}
+}
+
+// forcedPriority returns the sampling priority forced through the context, if any, see
+// WithForceKeep.
+func (t *trace) forcedPriority() (p int, ok bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	switch t.forcedDecision {
+	case decisionKeep:
+		return ext.PriorityUserKeep, true
+	case decisionDrop:
+		return ext.PriorityUserReject, true
+	}
+	return 0, false
+}
+
 func (t *trace) setTag(key, value string) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -427,7 +631,8 @@ func (t *trace) setSamplingPriorityLocked(p int, sampler samplernames.SamplerNam
 		return false
 	}
 
-	updatedPriority := t.priority == nil || *t.priority != float64(p)
+	updated := t.priority == nil || *t.priority != float64(p)
+	t.manual = sampler == samplernames.Manual
 
 	if t.priority == nil {
 		t.priority = new(float64)
@@ -443,17 +648,33 @@ func (t *trace) setSamplingPriorityLocked(p int, sampler samplernames.SamplerNam
 		// Note that once global rate sampling is deprecated, we no longer need to compare
 		// the DMs. Sampling priority is sufficient to distinguish a change in DM.
 		dm := samplerToDM(sampler)
-		updatedDM := !existed || dm != curDM
-		if updatedDM {
+		if !existed || dm != curDM {
 			t.setPropagatingTagLocked(keyDecisionMaker, dm)
-			return true
+			updated = true
 		}
 	}
 	if p <= 0 && existed {
 		delete(t.propagatingTags, keyDecisionMaker)
 	}
+	if updated {
+		t.recordSamplingLocked(p, sampler)
+	}
+	return updated
+}
+
+// maxSamplingHistory limits the number of sampling priority changes recorded per trace.
+const maxSamplingHistory = 16
 
-	return updatedPriority
+// recordSamplingLocked records a change of the sampling priority of the trace to p by
+// sampler, if WithSamplingHistory is enabled. t must already be locked.
+func (t *trace) recordSamplingLocked(p int, sampler samplernames.SamplerName) {
+	if len(t.samplingHistory) >= maxSamplingHistory {
+		return
+	}
+	if tr, ok := getGlobalTracer().(*tracer); !ok || !tr.config.samplingHistory {
+		return
+	}
+	t.samplingHistory = append(t.samplingHistory, strconv.Itoa(p)+":"+strconv.Itoa(int(sampler)))
 }
 
 func (t *trace) isLocked() bool {
@@ -473,6 +694,11 @@ func (t *trace) setLocked(locked bool) {
 func (t *trace) push(sp *Span) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	if t.root == nil {
+		// first span in the trace can safely be assumed to be the root; it is set
+		// under the lock since concurrent children of an extracted context race for it.
+		t.root = sp
+	}
 	if t.full {
 		return
 	}
@@ -490,6 +716,9 @@ func (t *trace) push(sp *Span) {
 	if v, ok := sp.metrics[keySamplingPriority]; ok {
 		t.setSamplingPriorityLocked(int(v), samplernames.Unknown)
 	}
+	if t.flushedAt == 0 {
+		t.flushedAt = sp.start
+	}
 	t.spans = append(t.spans, sp)
 	if tr != nil {
 		tracerstats.Signal(tracerstats.SpanStarted, 1)
@@ -532,6 +761,15 @@ func (t *trace) finishedOne(s *Span) {
 		// to a race condition where spans can be modified while flushing.
 		//
 		// TODO(partialFlush): should we do a partial flush in this scenario?
+		if s == t.root {
+			if tr, ok := getGlobalTracer().(*tracer); ok {
+				// the trace was dropped, report it with the final service and resource of its root
+				tr.statsd.Incr("datadog.tracer.traces_truncated", []string{"service:" + s.service, "resource_name:" + s.resource}, 1)
+				if tr.longRunning != nil {
+					tr.longRunning.remove(t)
+				}
+			}
+		}
 		return
 	}
 	t.finished++
@@ -547,11 +785,25 @@ func (t *trace) finishedOne(s *Span) {
 	if s.service != "" && !strings.EqualFold(s.service, tc.ServiceTag) {
 		s.meta[keyBaseService] = tc.ServiceTag
 	}
+	if s == t.root {
+		if tr, ok := tr.(*tracer); ok {
+			if tr.ignoresResource(s.resource) {
+				t.ignored = true
+			}
+			if tr.longRunning != nil {
+				// the trace isn't long-running anymore
+				tr.longRunning.remove(t)
+			}
+		}
+	}
 	if s == t.root && t.priority != nil {
 		// after the root has finished we lock down the priority;
 		// we won't be able to make changes to a span after finishing
 		// without causing a race condition.
 		t.root.setMetric(keySamplingPriority, *t.priority)
+		if len(t.samplingHistory) > 0 {
+			t.root.setMeta(keySamplingHistory, strings.Join(t.samplingHistory, ","))
+		}
 		t.locked = true
 	}
 	if len(t.spans) > 0 && s == t.spans[0] {
@@ -577,28 +829,67 @@ func (t *trace) finishedOne(s *Span) {
 			})
 		}
 		t.spans = nil
+		t.flushed = nil
 		return
 	}
 
-	doPartialFlush := tc.PartialFlush && t.finished >= tc.PartialFlushMinSpans
-	if !doPartialFlush {
+	var reason string
+	switch {
+	case tc.PartialFlush && t.finished >= tc.PartialFlushMinSpans:
+		reason = "reason:large_trace"
+	case tc.MaxTraceDuration > 0 && s.start+s.duration-t.flushedAt >= int64(tc.MaxTraceDuration):
+		// the trace has been open for too long, don't risk losing its finished spans
+		reason = "reason:max_duration"
+	default:
 		return // The trace hasn't completed and partial flushing will not occur
 	}
+	if tc.CompleteTraceSampling && t.root != nil && !t.root.finished {
+		// the sampling decision is deferred until the root finishes, see WithCompleteTraceSampling
+		return
+	}
 	log.Debug("Partial flush triggered with %d finished spans", t.finished)
-	telemetry.Count(telemetry.NamespaceTracers, "trace_partial_flush.count", []string{"reason:large_trace"}).Submit(1)
+	telemetry.Count(telemetry.NamespaceTracers, "trace_partial_flush.count", []string{reason}).Submit(1)
+	telemetry.Distribution(telemetry.NamespaceTracers, "trace_partial_flush.spans_closed", nil).Submit(float64(t.finished))
+	telemetry.Distribution(telemetry.NamespaceTracers, "trace_partial_flush.spans_remaining", nil).Submit(float64(len(t.spans) - t.finished))
+	// the chunk is sent with the current priority, lock it down so that the
+	// following chunks of the trace carry the same sampling decision.
+	t.flushFinishedLocked(tr, true)
+}
+
+// flushFinished submits the finished spans of the trace to tr as a new chunk, if
+// there are any, while the unfinished ones stay buffered until they finish. Unlike
+// a partial flush, it leaves the sampling decision of the trace open, and the spans
+// it submits are still seen by the tail sampling rules when the root finishes.
+func (t *trace) flushFinished(tr Tracer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.full || t.finished == 0 {
+		return
+	}
+	t.flushed = append(t.flushed, t.flushFinishedLocked(tr, false)...)
+}
+
+// flushFinishedLocked submits the finished spans of the trace to tr as a new chunk
+// and keeps the unfinished ones buffered, locking the sampling priority of the trace
+// if lockPriority is set. t must already be locked and must hold at least one
+// finished span. It returns the submitted spans.
+func (t *trace) flushFinishedLocked(tr Tracer, lockPriority bool) []*Span {
 	finishedSpans := make([]*Span, 0, t.finished)
 	leftoverSpans := make([]*Span, 0, len(t.spans)-t.finished)
-	for _, s2 := range t.spans {
-		if s2.finished {
-			finishedSpans = append(finishedSpans, s2)
+	for _, s := range t.spans {
+		if s.finished {
+			finishedSpans = append(finishedSpans, s)
 		} else {
-			leftoverSpans = append(leftoverSpans, s2)
+			leftoverSpans = append(leftoverSpans, s)
 		}
 	}
-	telemetry.Distribution(telemetry.NamespaceTracers, "trace_partial_flush.spans_closed", nil).Submit(float64(len(finishedSpans)))
-	telemetry.Distribution(telemetry.NamespaceTracers, "trace_partial_flush.spans_remaining", nil).Submit(float64(len(leftoverSpans)))
-	finishedSpans[0].setMetric(keySamplingPriority, *t.priority)
-	if s != t.spans[0] {
+	if t.priority != nil {
+		finishedSpans[0].setMetric(keySamplingPriority, *t.priority)
+		if lockPriority {
+			t.locked = true
+		}
+	}
+	if finishedSpans[0] != t.spans[0] {
 		// Make sure the first span in the chunk has the trace-level tags
 		t.setTraceTags(finishedSpans[0])
 	}
@@ -609,10 +900,14 @@ func (t *trace) finishedOne(s *Span) {
 		})
 	}
 	t.spans = leftoverSpans
+	t.flushedAt = now()
+	return finishedSpans
 }
 
 func (t *trace) finishChunk(tr *tracer, ch *chunk) {
-	tr.submitChunk(ch)
+	if !t.ignored {
+		tr.submitChunk(ch)
+	}
 	t.finished = 0 // important, because a buffer can be used for several flushes
 }
 
@@ -20,6 +20,7 @@ import (
 	"github.com/DataDog/dd-trace-go/v2/internal/log"
 	"github.com/DataDog/dd-trace-go/v2/internal/processtags"
 	"github.com/DataDog/dd-trace-go/v2/internal/samplernames"
+	"github.com/DataDog/dd-trace-go/v2/internal/statsdtest"
 	"github.com/DataDog/dd-trace-go/v2/internal/telemetry"
 	"github.com/DataDog/dd-trace-go/v2/internal/telemetry/telemetrytest"
 
@@ -351,6 +352,133 @@ func TestPartialFlush(t *testing.T) {
 
 }
 
+func TestSamplingHistory(t *testing.T) {
+	carrier := TextMapCarrier(map[string]string{
+		traceparentHeader: "00-00000000000000001111111111111111-2222222222222222-01",
+		tracestateHeader:  "dd=s:2;o:rum",
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		tracer, transport, flush, stop, err := startTestTracer(t, WithSamplingHistory(true))
+		require.NoError(t, err)
+		defer stop()
+
+		sctx, err := tracer.Extract(carrier)
+		require.NoError(t, err)
+		root := tracer.StartSpan("root", ChildOf(sctx))
+		root.SetTag(ext.ManualKeep, true)
+		root.Finish()
+		flush(1)
+
+		ts := transport.Traces()
+		require.Len(t, ts, 1)
+		// traceparent flags, then tracestate priority, then manual keep
+		assert.Equal(t, "1:-1,2:-1,2:4", ts[0][0].meta[keySamplingHistory])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		tracer, transport, flush, stop, err := startTestTracer(t)
+		require.NoError(t, err)
+		defer stop()
+
+		sctx, err := tracer.Extract(carrier)
+		require.NoError(t, err)
+		tracer.StartSpan("root", ChildOf(sctx)).Finish()
+		flush(1)
+
+		ts := transport.Traces()
+		require.Len(t, ts, 1)
+		assert.NotContains(t, ts[0][0].meta, keySamplingHistory)
+	})
+}
+
+func TestPartialFlushSamplingPriority(t *testing.T) {
+	t.Run("locked", func(t *testing.T) {
+		tracer, transport, flush, stop, err := startTestTracer(t, WithPartialFlushing(2))
+		require.NoError(t, err)
+		defer stop()
+
+		root := tracer.StartSpan("root")
+		for i := 0; i < 3; i++ {
+			tracer.StartSpan(fmt.Sprintf("child%d", i), ChildOf(root.Context())).Finish()
+		}
+		flush(1)
+		// the first chunk was sent with the current priority, which can't change anymore
+		root.SetTag(ext.ManualKeep, true)
+		root.Finish()
+		flush(2)
+
+		ts := transport.Traces()
+		require.Len(t, ts, 2)
+		for _, chunk := range ts {
+			assert.Equal(t, float64(ext.PriorityAutoKeep), chunk[0].metrics[keySamplingPriority])
+		}
+	})
+
+	t.Run("complete-trace", func(t *testing.T) {
+		tracer, transport, flush, stop, err := startTestTracer(t, WithPartialFlushing(2), WithCompleteTraceSampling(true))
+		require.NoError(t, err)
+		defer stop()
+
+		root := tracer.StartSpan("root")
+		for i := 0; i < 3; i++ {
+			tracer.StartSpan(fmt.Sprintf("child%d", i), ChildOf(root.Context())).Finish()
+		}
+		flush(-1)
+		root.SetTag(ext.ManualKeep, true)
+		root.Finish()
+		flush(1)
+
+		ts := transport.Traces()
+		require.Len(t, ts, 1)
+		assert.Len(t, ts[0], 4)
+		assert.Equal(t, float64(ext.PriorityUserKeep), ts[0][0].metrics[keySamplingPriority])
+	})
+
+	t.Run("flush-trace", func(t *testing.T) {
+		tracer, _, _, stop, err := startTestTracer(t)
+		require.NoError(t, err)
+		defer stop()
+
+		root := tracer.StartSpan("root")
+		child := tracer.StartSpan("child", ChildOf(root.Context()))
+		child.Finish()
+		tracer.flushTrace(child)
+		// flushing a trace explicitly doesn't lock its sampling decision
+		root.SetTag(ext.ManualDrop, true)
+		root.Finish()
+		assert.Equal(t, float64(ext.PriorityUserReject), root.metrics[keySamplingPriority])
+	})
+}
+
+func TestMaxTraceDuration(t *testing.T) {
+	tracer, transport, flush, stop, err := startTestTracer(t, WithMaxTraceDuration(time.Hour))
+	assert.Nil(t, err)
+	defer stop()
+
+	root := tracer.StartSpan("root", StartTime(time.Now().Add(-2*time.Hour)))
+	child := tracer.StartSpan("child", ChildOf(root.Context()))
+	child.Finish()
+	flush(1)
+
+	ts := transport.Traces()
+	require.Len(t, ts, 1)
+	require.Len(t, ts[0], 1)
+	comparePayloadSpans(t, child, ts[0][0])
+
+	// the trace was flushed less than an hour ago, the next span waits for the root
+	child2 := tracer.StartSpan("child2", ChildOf(root.Context()))
+	child2.Finish()
+	root.Finish()
+	flush(1)
+
+	ts = transport.Traces()
+	require.Len(t, ts, 1)
+	require.Len(t, ts[0], 2)
+	comparePayloadSpans(t, root, ts[0][0])
+	comparePayloadSpans(t, child2, ts[0][1])
+}
+
 func TestSpanTracePushNoFinish(t *testing.T) {
 	defer setupteardown(2, 5)()
 
@@ -896,6 +1024,44 @@ func TestSpanContextPushFull(t *testing.T) {
 	assert.Contains(tp.Logs()[0], "ERROR: trace buffer full (2 spans)")
 }
 
+func TestSpanContextFullReportsTruncatedTrace(t *testing.T) {
+	defer func(old int) { traceMaxSize = old }(traceMaxSize)
+	traceMaxSize = 2
+	var tg statsdtest.TestStatsdClient
+	tracer, _, _, stop, err := startTestTracer(t, withStatsdClient(&tg))
+	require.NoError(t, err)
+	defer stop()
+
+	root := tracer.StartSpan("root", ServiceName("runaway"))
+	for i := 0; i < 3; i++ {
+		tracer.StartSpan("child", ChildOf(root.Context())).Finish()
+	}
+	assert.Empty(t, tg.GetCallsByName("datadog.tracer.traces_truncated"))
+	root.SetTag(ext.ResourceName, "GET /loop")
+	root.Finish()
+
+	calls := tg.GetCallsByName("datadog.tracer.traces_truncated")
+	require.Len(t, calls, 1)
+	assert.ElementsMatch(t, []string{"service:runaway", "resource_name:GET /loop"}, calls[0].Tags())
+}
+
+func TestSpanContextIsSampled(t *testing.T) {
+	tracer, _, _, stop, err := startTestTracer(t)
+	require.NoError(t, err)
+	defer stop()
+
+	span := tracer.StartSpan("kept")
+	defer span.Finish()
+	assert.True(t, span.IsSampled())
+	span.SetTag(ext.ManualDrop, true)
+	assert.False(t, span.IsSampled())
+	assert.False(t, span.Context().IsSampled())
+
+	var nilSpan *Span
+	assert.False(t, nilSpan.IsSampled())
+	assert.False(t, (&SpanContext{}).IsSampled())
+}
+
 func TestSpanContextBaggage(t *testing.T) {
 	assert := assert.New(t)
 
@@ -1007,6 +1173,26 @@ func TestTraceIDEmpty(t *testing.T) {
 	assert.False(t, tid.Empty())
 }
 
+func TestSpanContextIs128Bit(t *testing.T) {
+	var nilCtx *SpanContext
+	assert.False(t, nilCtx.Is128Bit())
+
+	ctx := &SpanContext{}
+	ctx.traceID.SetLower(5)
+	assert.False(t, ctx.Is128Bit())
+	ctx.traceID.SetUpper(1)
+	assert.True(t, ctx.Is128Bit())
+
+	// an upstream which only propagates the lower 64 bits downgrades the trace ID
+	t.Setenv(headerPropagationStyle, "datadog")
+	extracted, err := NewPropagator(nil).Extract(TextMapCarrier{
+		DefaultTraceIDHeader:  "5",
+		DefaultParentIDHeader: "6",
+	})
+	require.NoError(t, err)
+	assert.False(t, extracted.Is128Bit())
+}
+
 func TestSpanIDHexEncoded(t *testing.T) {
 	sid := spanIDHexEncoded(5, 16)
 	assert.Equal(t, fmt.Sprintf("%016x", 5), sid)
@@ -1026,6 +1212,56 @@ func TestSpanIDHexEncoded(t *testing.T) {
 	assert.Equal(t, spanIDHexEncoded(math.MaxUint64, 16), sid)
 }
 
+func TestSpanContextSerialize(t *testing.T) {
+	tracer, err := newTracer()
+	require.NoError(t, err)
+	defer tracer.Stop()
+
+	sctx, err := tracer.Extract(TextMapCarrier{
+		traceparentHeader: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		tracestateHeader:  "dd=s:2;o:synthetics;t.usr.id:123",
+	})
+	require.NoError(t, err)
+	span := tracer.StartSpan("workflow.step", ChildOf(sctx))
+	defer span.Finish()
+
+	text, err := span.Context().Serialize()
+	require.NoError(t, err)
+	assert.Equal(t, "_dd.p.usr.id=123&o=synthetics&p=2&s="+spanIDHexEncoded(span.spanID, 16)+"&t=4bf92f3577b34da6a3ce929d0e0e4736&v=1", string(text))
+
+	got, err := DeserializeSpanContext(text)
+	require.NoError(t, err)
+	assert.Equal(t, span.Context().TraceID(), got.TraceID())
+	assert.Equal(t, span.Context().SpanID(), got.SpanID())
+	p, ok := got.SamplingPriority()
+	assert.True(t, ok)
+	assert.Equal(t, 2, p)
+	assert.Equal(t, "synthetics", got.origin)
+	assert.Equal(t, "123", got.trace.propagatingTag("_dd.p.usr.id"))
+	assert.True(t, got.isRemote)
+
+	// the trace is resumed with the decoded span context
+	child := tracer.StartSpan("workflow.resume", ChildOf(got))
+	assert.Equal(t, span.Context().TraceID(), child.Context().TraceID())
+	assert.Equal(t, span.spanID, child.parentID)
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := (&SpanContext{}).Serialize()
+		assert.Equal(t, ErrInvalidSpanContext, err)
+		for _, text := range []string{
+			"",
+			"v=2&t=4bf92f3577b34da6a3ce929d0e0e4736&s=00f067aa0ba902b7",
+			"v=1&t=4bf92f3577b34da6&s=00f067aa0ba902b7",
+			"v=1&t=4bf92f3577b34da6a3ce929d0e0e4736&s=0000000000000000",
+			"v=1&t=4bf92f3577b34da6a3ce929d0e0e4736&s=00f067aa0ba902b7&p=x",
+			"v=1&t=4bf92f3577b34da6a3ce929d0e0e4736&s=00f067aa0ba902b7&%zz",
+		} {
+			_, err := DeserializeSpanContext([]byte(text))
+			assert.ErrorIs(t, err, ErrSpanContextCorrupted, text)
+		}
+	})
+}
+
 func TestSpanProcessTags(t *testing.T) {
 	testCases := []struct {
 		name    string
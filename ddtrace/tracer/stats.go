@@ -50,6 +50,10 @@ type concentrator struct {
 	stop         chan struct{}         // closing this channel triggers shutdown
 	cfg          *config               // tracer startup configuration
 	statsdClient internal.StatsdClient // statsd client for sending metrics.
+
+	// traceMetrics is the statsd client trace metrics are emitted with when the stats are
+	// flushed. It is nil unless enabled, see WithTraceMetrics.
+	traceMetrics internal.StatsdClient
 }
 
 type tracerStatSpan struct {
@@ -217,6 +221,17 @@ const (
 // the concentrator config. The current bucket is only included if includeCurrent is true, such as during shutdown.
 func (c *concentrator) flushAndSend(timenow time.Time, includeCurrent bool) {
 	csps := c.spanConcentrator.Flush(timenow.UnixNano(), includeCurrent)
+	if c.traceMetrics != nil {
+		c.emitTraceMetrics(csps)
+		if !c.cfg.canDropP0s() {
+			// the stats were only computed for the trace metrics
+			return
+		}
+	}
+	if c.cfg.dryRun {
+		// the stats are computed like the traces are sampled, but not sent
+		return
+	}
 
 	obfVersion := 0
 	if c.shouldObfuscate() {
@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
+	"github.com/DataDog/dd-trace-go/v2/internal/samplernames"
+)
+
+// TailSamplingRule keeps a share of the traces matching all of its criteria, which are
+// evaluated on the spans of the trace once its local root span finishes, see
+// WithTailSamplingRules. A rule without criteria matches all traces.
+type TailSamplingRule struct {
+	// Error matches the traces holding at least one errored span, if true.
+	Error bool
+
+	// MinDuration matches the traces whose local root span lasted at least MinDuration,
+	// if non-zero.
+	MinDuration time.Duration
+
+	// Tags matches the traces holding a span with all of the given tags, whose values
+	// are glob patterns like in Rule.Tags, if non-empty.
+	Tags map[string]string
+
+	// Rate is the rate, between 0 and 1, at which the matching traces are kept.
+	Rate float64
+}
+
+// tailSamplingRule is a TailSamplingRule whose tag patterns are compiled.
+type tailSamplingRule struct {
+	TailSamplingRule
+	tags map[string]*regexp.Regexp
+}
+
+func newTailSamplingRule(r TailSamplingRule) tailSamplingRule {
+	rule := tailSamplingRule{TailSamplingRule: r}
+	if len(r.Tags) > 0 {
+		rule.tags = make(map[string]*regexp.Regexp, len(r.Tags))
+		for k, v := range r.Tags {
+			rule.tags[k] = globMatch(v)
+		}
+	}
+	return rule
+}
+
+// match reports whether the trace made of spans, whose local root span lasted
+// duration, matches the rule.
+func (r *tailSamplingRule) match(spans []*Span, duration time.Duration) bool {
+	if r.MinDuration > 0 && duration < r.MinDuration {
+		return false
+	}
+	if r.Error && !slices.ContainsFunc(spans, func(s *Span) bool {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return s.error != 0
+	}) {
+		return false
+	}
+	if len(r.tags) > 0 && !slices.ContainsFunc(spans, r.matchTags) {
+		return false
+	}
+	return true
+}
+
+// matchTags reports whether s holds all the tags of the rule.
+func (r *tailSamplingRule) matchTags(s *Span) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for k, pattern := range r.tags {
+		v, ok := s.meta[k]
+		if !ok || (pattern != nil && !pattern.MatchString(v)) {
+			return false
+		}
+	}
+	return true
+}
+
+// sampleTail makes the sampling decision of the trace of the local root span s, which
+// finishes at finishTime, from the first of the tail sampling rules matching the trace.
+// The decision is left unchanged if no rule matches. It isn't called for traces whose
+// decision was made manually, e.g. with ext.ManualKeep.
+func (t *tracer) sampleTail(s *Span, finishTime int64) {
+	trace := s.context.trace
+	trace.mu.RLock()
+	spans := slices.Concat(trace.flushed, trace.spans)
+	trace.mu.RUnlock()
+
+	s.mu.RLock()
+	duration := time.Duration(finishTime - s.start)
+	if s.duration != 0 {
+		duration = time.Duration(s.duration)
+	}
+	s.mu.RUnlock()
+
+	for i := range t.config.tailSamplingRules {
+		r := &t.config.tailSamplingRules[i]
+		if !r.match(spans, duration) {
+			continue
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.setMetric(keyRulesSamplerAppliedRate, r.Rate)
+		if sampledByRate(s.traceID, r.Rate) {
+			s.setSamplingPriorityLocked(ext.PriorityUserKeep, samplernames.RuleRate)
+		} else {
+			s.setSamplingPriorityLocked(ext.PriorityUserReject, samplernames.RuleRate)
+		}
+		return
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/DataDog/dd-trace-go/v2/profiler"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTelemetryEnabled(t *testing.T) {
@@ -184,3 +185,22 @@ func TestTelemetryEnabled(t *testing.T) {
 		telemetrytest.CheckConfig(t, telemetryClient.Configuration, "orchestrion_version", "v1337.42.0-phony")
 	})
 }
+
+func TestTelemetryRuntimeConfigChange(t *testing.T) {
+	_, _, _, stop, err := startTestTracer(t)
+	require.NoError(t, err)
+	defer stop()
+	telemetryClient := new(telemetrytest.RecordClient)
+	defer telemetry.MockClient(telemetryClient)()
+
+	// configurations changed after start result in an app-client-configuration-change event
+	require.NoError(t, Reconfigure(WithSamplingRules(TraceSamplingRules(Rule{ServiceGlob: "*", Rate: 0.5}))))
+	var found bool
+	for _, c := range telemetryClient.Configuration {
+		if c.Name == "trace_sample_rules" {
+			found = true
+			assert.Equal(t, telemetry.OriginCode, c.Origin)
+		}
+	}
+	assert.True(t, found)
+}
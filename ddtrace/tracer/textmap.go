@@ -6,12 +6,16 @@
 package tracer
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 
 	"maps"
@@ -94,6 +98,10 @@ const (
 	// DefaultBaggageHeader specifies the key that will be used in HTTP headers
 	// or text maps to store the baggage value.
 	DefaultBaggageHeader = "baggage"
+
+	// DefaultTracestateKey specifies the key of the W3C tracestate list-member
+	// which holds the Datadog trace context.
+	DefaultTracestateKey = "dd"
 )
 
 // originHeader specifies the name of the header indicating the origin of the trace.
@@ -135,6 +143,176 @@ type PropagatorConfig struct {
 	// BaggageHeader specifies the map key that will be used to store the baggage key-value pairs.
 	// It defaults to DefaultBaggageHeader.
 	BaggageHeader string
+
+	// DisableSpanLinks disables the span links which are added to the extracted span context
+	// when the propagation styles carry conflicting trace contexts. It can also be disabled by
+	// setting DD_TRACE_PROPAGATION_SPAN_LINKS to false.
+	DisableSpanLinks bool
+
+	// TraceIDHexFallback makes the Datadog propagator parse the trace ID header as
+	// hexadecimal when it isn't a valid decimal number, for interoperability with
+	// upstreams which don't follow the decimal convention. It can also be enabled by
+	// setting DD_TRACE_DATADOG_TRACEID_HEX_FALLBACK to true.
+	TraceIDHexFallback bool
+
+	// MissingPriority specifies the sampling priority given by the Datadog propagator to the
+	// span contexts it extracts with a trace ID and a parent ID but no sampling priority, e.g.
+	// ext.PriorityAutoKeep. Some upstreams omit the priority on purpose to delegate the sampling
+	// decision, while others do so because of a bug. When nil, the default, the priority is left
+	// unset and the local sampler makes the decision when the first span of the trace starts.
+	MissingPriority *int
+
+	// MaxTracestateLen specifies the maximum length of the W3C tracestate header read by the
+	// tracecontext propagator. Longer headers are truncated to their first list-members
+	// which fit in this length, and the _dd.propagation_error trace tag is set to
+	// tracestate_max_size, so that oversized headers can't cost excessive CPU on every hop.
+	// It defaults to 8192, the size of 32 list-members of 256 characters allowed by W3C.
+	MaxTracestateLen int
+
+	// AlwaysExtractTracestate makes extraction read the W3C tracestate header even when the
+	// tracecontext style isn't extracted, e.g. with datadog-only extraction, so the list-members
+	// of other vendors survive the hop. The tracestate is only kept when the traceparent header
+	// is valid and carries the trace ID of the extracted span context, and the tracestate read by
+	// the tracecontext extractor, when enabled, takes precedence. The parent ID, sampling priority
+	// and origin are still those of the extracted styles. The tracestate is re-propagated by the
+	// tracecontext injector.
+	AlwaysExtractTracestate bool
+
+	// TracestateKey specifies the key of the W3C tracestate list-member holding the Datadog
+	// trace context, which is both written and read by the tracecontext propagator. Changing
+	// it avoids collisions between the contexts of several Datadog organizations traversing
+	// the same system. It must be a valid tracestate key, and defaults to DefaultTracestateKey.
+	TracestateKey string
+
+	// CorrelationIDHeader specifies the name of a header which is injected alongside the
+	// trace context headers with the trace ID formatted as CorrelationIDFormat, for systems
+	// which only correlate on such an ID (e.g. "X-Correlation-ID"). It is disabled when empty.
+	CorrelationIDHeader string
+
+	// CorrelationIDFormat specifies the format of the correlation ID header value.
+	// It defaults to CorrelationIDHex.
+	CorrelationIDFormat CorrelationIDFormat
+
+	// CorrelationIDSeedsTrace makes extraction use the correlation ID header, when no
+	// trace context headers are present, as the trace ID of the extracted span context,
+	// so spans continue the trace of the upstream correlation ID.
+	CorrelationIDSeedsTrace bool
+
+	// BaggageTrustFunc, when set, is called by the baggage propagator with the carrier it
+	// extracts from, and the W3C baggage header is only extracted when it returns true.
+	// It allows gateways to accept baggage from internal callers, e.g. identified by a signed
+	// internal header, while dropping the baggage sent by untrusted external clients.
+	BaggageTrustFunc func(carrier TextMapReader) bool
+
+	// BaggageTruncationTag, when true, makes the baggage propagator tag the span whose
+	// context is injected with _dd.baggage.truncated, holding the number of baggage items
+	// which were dropped because the baggage header exceeded its limits of 64 items or 8192
+	// bytes. It makes it visible in the trace that the baggage expected downstream was cut.
+	BaggageTruncationTag bool
+
+	// BaggageHashedKeys lists the baggage keys whose values are injected by the baggage
+	// and datadog propagators as a salted hash instead of their plaintext, so that
+	// downstream services can correlate them, e.g. a user.id, without intermediaries
+	// logging the headers being able to read them. The local span context keeps the
+	// plaintext values.
+	BaggageHashedKeys []string
+
+	// BaggageHashSalt specifies the salt of the hashes of BaggageHashedKeys values.
+	BaggageHashSalt string
+
+	// BaggageHashFunc, when set, returns the hashed form of the value of a baggage key listed
+	// in BaggageHashedKeys, salted with salt. It defaults to the hex encoding of the first 16
+	// bytes of the HMAC-SHA256 of value, keyed with salt.
+	BaggageHashFunc func(value, salt string) string
+
+	// MinimalPropagation, when true, makes the injectors emit only the essential fields of
+	// the span context: the trace ID, the parent span ID, the sampling priority and the
+	// origin. Propagating tags, baggage and the tracestate list-members of other vendors
+	// aren't injected, except for the _dd.p.tid tag of the datadog style, which holds the
+	// upper 64 bits of 128-bit trace IDs. It is meant for external-facing boundaries.
+	MinimalPropagation bool
+
+	// OriginHeader specifies when the datadog style injects the x-datadog-origin header,
+	// for downstreams which tell an empty origin from an absent header apart. It defaults
+	// to OriginHeaderWhenSet.
+	OriginHeader OriginHeaderPolicy
+}
+
+// CorrelationIDFormat specifies how the trace ID is formatted in the correlation ID
+// header, see PropagatorConfig.CorrelationIDHeader.
+type CorrelationIDFormat int
+
+const (
+	// CorrelationIDHex formats the 128-bit trace ID as 32 lowercase hex characters,
+	// as in the W3C traceparent header.
+	CorrelationIDHex CorrelationIDFormat = iota
+	// CorrelationIDDecimal formats the lower 64 bits of the trace ID as a decimal
+	// number, as in the Datadog headers and log correlation.
+	CorrelationIDDecimal
+	// CorrelationIDUUID formats the 128-bit trace ID as a UUID, e.g.
+	// 6614d0e1-0000-0000-1b7b-ac51cfca6e3d.
+	CorrelationIDUUID
+)
+
+// format returns the correlation ID of the given trace ID.
+func (f CorrelationIDFormat) format(id traceID) string {
+	switch f {
+	case CorrelationIDDecimal:
+		return strconv.FormatUint(id.Lower(), 10)
+	case CorrelationIDUUID:
+		h := id.HexEncoded()
+		return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+	default:
+		return id.HexEncoded()
+	}
+}
+
+// parse returns the trace ID of the given correlation ID.
+func (f CorrelationIDFormat) parse(v string) (traceID, bool) {
+	var id traceID
+	switch f {
+	case CorrelationIDDecimal:
+		lower, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return id, false
+		}
+		id.SetLower(lower)
+	default:
+		if f == CorrelationIDUUID {
+			v = strings.ReplaceAll(v, "-", "")
+		}
+		v = strings.ToLower(v)
+		if len(v) != 32 || !isValidID(v) {
+			return id, false
+		}
+		hex.Decode(id[:], []byte(v))
+	}
+	return id, !id.Empty()
+}
+
+// OriginHeaderPolicy specifies when the x-datadog-origin header is injected,
+// see PropagatorConfig.OriginHeader.
+type OriginHeaderPolicy int
+
+const (
+	// OriginHeaderWhenSet injects the origin header only when the trace has an origin.
+	OriginHeaderWhenSet OriginHeaderPolicy = iota
+	// OriginHeaderAlways always injects the origin header, empty when the trace has no origin.
+	OriginHeaderAlways
+	// OriginHeaderNever never injects the origin header.
+	OriginHeaderNever
+)
+
+// inject reports whether the origin header is injected for the given origin.
+func (p OriginHeaderPolicy) inject(origin string) bool {
+	switch p {
+	case OriginHeaderAlways:
+		return true
+	case OriginHeaderNever:
+		return false
+	default:
+		return origin != ""
+	}
 }
 
 // NewPropagator returns a new propagator which uses TextMap to inject
@@ -146,6 +324,14 @@ type PropagatorConfig struct {
 //  1. DD_TRACE_PROPAGATION_STYLE_INJECT
 //  2. DD_TRACE_PROPAGATION_STYLE (applies to both inject and extract)
 //  3. If none of the above, use default values
+//
+// The extract propagators are determined in the same way from DD_TRACE_PROPAGATION_STYLE_EXTRACT
+// and DD_TRACE_PROPAGATION_STYLE, so both sides can differ. For example, injecting "tracecontext"
+// while extracting "datadog" continues traces coming from Datadog headers but only sends W3C
+// headers downstream. With that setup, the "p" member of the tracestate header is set to the ID
+// of the injected span, or to the extracted Datadog parent ID when injecting an extracted context
+// as is, so that downstream spans can be reparented. Spans started from the extracted context are
+// not tagged with _dd.parent_id, since there is no W3C parent to reparent from.
 func NewPropagator(cfg *PropagatorConfig, propagators ...Propagator) Propagator {
 	if cfg == nil {
 		cfg = new(PropagatorConfig)
@@ -165,8 +351,27 @@ func NewPropagator(cfg *PropagatorConfig, propagators ...Propagator) Propagator
 	if cfg.BaggageHeader == "" {
 		cfg.BaggageHeader = DefaultBaggageHeader
 	}
+	if cfg.TracestateKey == "" {
+		cfg.TracestateKey = DefaultTracestateKey
+	} else if !isValidTracestateKey(cfg.TracestateKey) {
+		log.Warn("Invalid tracestate key %q, using %q instead.", cfg.TracestateKey, DefaultTracestateKey)
+		cfg.TracestateKey = DefaultTracestateKey
+	}
+	if cfg.MaxTracestateLen <= 0 {
+		cfg.MaxTracestateLen = defaultMaxTracestateLen
+	}
+	if !cfg.TraceIDHexFallback {
+		cfg.TraceIDHexFallback = internal.BoolEnv("DD_TRACE_DATADOG_TRACEID_HEX_FALLBACK", false)
+	}
 	cp := new(chainedPropagator)
 	cp.onlyExtractFirst = internal.BoolEnv("DD_TRACE_PROPAGATION_EXTRACT_FIRST", false)
+	cp.spanLinks = !cfg.DisableSpanLinks && internal.BoolEnv("DD_TRACE_PROPAGATION_SPAN_LINKS", true)
+	cp.correlationIDHeader = cfg.CorrelationIDHeader
+	cp.correlationIDFormat = cfg.CorrelationIDFormat
+	cp.correlationIDSeedsTrace = cfg.CorrelationIDSeedsTrace
+	if cfg.AlwaysExtractTracestate {
+		cp.tracestate = &propagatorW3c{tracestateKey: cfg.TracestateKey, maxTracestateLen: cfg.MaxTracestateLen}
+	}
 	if len(propagators) > 0 {
 		cp.injectors = propagators
 		cp.extractors = propagators
@@ -188,6 +393,16 @@ type chainedPropagator struct {
 	injectorNames    string
 	extractorsNames  string
 	onlyExtractFirst bool // value of DD_TRACE_PROPAGATION_EXTRACT_FIRST
+	spanLinks        bool // whether to link the conflicting trace contexts, see PropagatorConfig.DisableSpanLinks
+
+	// correlation ID header settings, see PropagatorConfig.CorrelationIDHeader
+	correlationIDHeader     string
+	correlationIDFormat     CorrelationIDFormat
+	correlationIDSeedsTrace bool
+
+	// tracestate extracts the W3C tracestate when not done by the extractors,
+	// see PropagatorConfig.AlwaysExtractTracestate. It is nil when disabled.
+	tracestate *propagatorW3c
 }
 
 // getPropagators returns a list of propagators based on ps, which is a comma seperated
@@ -195,8 +410,19 @@ type chainedPropagator struct {
 // default propagator will be returned. Any invalid values in the list will log
 // a warning and be ignored.
 func getPropagators(cfg *PropagatorConfig, ps string) ([]Propagator, string) {
-	dd := &propagator{cfg}
-	defaultPs := []Propagator{dd, &propagatorW3c{}, &propagatorBaggage{}}
+	dd := newDatadogPropagator(cfg)
+	w3c := &propagatorW3c{
+		tracestateKey:    cfg.TracestateKey,
+		maxTracestateLen: cfg.MaxTracestateLen,
+		minimal:          cfg.MinimalPropagation,
+	}
+	baggage := &propagatorBaggage{
+		minimal:       cfg.MinimalPropagation,
+		trustFunc:     cfg.BaggageTrustFunc,
+		truncationTag: cfg.BaggageTruncationTag,
+		hashing:       newBaggageHashing(cfg),
+	}
+	defaultPs := []Propagator{dd, w3c, baggage}
 	defaultPsName := "datadog,tracecontext,baggage"
 	if cfg.B3 {
 		defaultPs = append(defaultPs, &propagatorB3{})
@@ -225,10 +451,10 @@ func getPropagators(cfg *PropagatorConfig, ps string) ([]Propagator, string) {
 			list = append(list, dd)
 			listNames = append(listNames, v)
 		case "tracecontext":
-			list = append(list, &propagatorW3c{})
+			list = append(list, w3c)
 			listNames = append(listNames, v)
 		case "baggage":
-			list = append(list, &propagatorBaggage{})
+			list = append(list, baggage)
 			listNames = append(listNames, v)
 		case "b3", "b3multi":
 			if !cfg.B3 {
@@ -239,11 +465,20 @@ func getPropagators(cfg *PropagatorConfig, ps string) ([]Propagator, string) {
 		case "b3 single header":
 			list = append(list, &propagatorB3SingleHeader{})
 			listNames = append(listNames, v)
+		case "xray":
+			list = append(list, &propagatorXRay{})
+			listNames = append(listNames, v)
 		case "none":
 			log.Warn("Propagator \"none\" has no effect when combined with other propagators. " +
 				"To disable the propagator, set to `none`")
 		default:
-			log.Warn("unrecognized propagator: %s\n", v)
+			factory, ok := registeredPropagator(v)
+			if !ok {
+				log.Warn("unrecognized propagator: %s\n", v)
+				continue
+			}
+			list = append(list, factory(cfg))
+			listNames = append(listNames, v)
 		}
 	}
 	if len(list) == 0 {
@@ -252,6 +487,49 @@ func getPropagators(cfg *PropagatorConfig, ps string) ([]Propagator, string) {
 	return list, strings.Join(listNames, ",")
 }
 
+var (
+	// customPropagatorsMu guards customPropagators.
+	customPropagatorsMu sync.RWMutex
+	// customPropagators holds the factories of the propagators registered with
+	// RegisterPropagator, keyed by lower-cased name.
+	customPropagators map[string]func(*PropagatorConfig) Propagator
+)
+
+// RegisterPropagator registers a custom propagation style under the given name, so that it
+// can be listed in DD_TRACE_PROPAGATION_STYLE, DD_TRACE_PROPAGATION_STYLE_INJECT and
+// DD_TRACE_PROPAGATION_STYLE_EXTRACT alongside the built-in styles, e.g.
+// DD_TRACE_PROPAGATION_STYLE=datadog,myformat. When a propagator is created, factory is
+// called with its configuration to create the propagator of the style. Names are case
+// insensitive and the names of the built-in styles can't be registered. It must be called
+// before the tracer is started, since the styles are resolved when it starts.
+func RegisterPropagator(name string, factory func(*PropagatorConfig) Propagator) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case "", "datadog", "tracecontext", "baggage", "b3", "b3multi", "b3 single header", "xray", "none":
+		log.Warn("ignoring propagator %q: the name is reserved", name)
+		return
+	}
+	if factory == nil {
+		log.Warn("ignoring propagator %q: the factory is nil", name)
+		return
+	}
+	customPropagatorsMu.Lock()
+	defer customPropagatorsMu.Unlock()
+	if customPropagators == nil {
+		customPropagators = make(map[string]func(*PropagatorConfig) Propagator)
+	}
+	customPropagators[name] = factory
+}
+
+// registeredPropagator returns the factory of the propagation style registered under
+// name, see RegisterPropagator.
+func registeredPropagator(name string) (func(*PropagatorConfig) Propagator, bool) {
+	customPropagatorsMu.RLock()
+	defer customPropagatorsMu.RUnlock()
+	factory, ok := customPropagators[name]
+	return factory, ok
+}
+
 // Inject defines the Propagator to propagate SpanContext data
 // out of the current process. The implementation propagates the
 // TraceID and the current active SpanID, as well as the Span baggage.
@@ -265,6 +543,11 @@ func (p *chainedPropagator) Inject(spanCtx *SpanContext, carrier interface{}) er
 			return err
 		}
 	}
+	if p.correlationIDHeader != "" && !spanCtx.baggageOnly {
+		if w, ok := carrier.(TextMapWriter); ok {
+			w.Set(p.correlationIDHeader, p.correlationIDFormat.format(spanCtx.traceID))
+		}
+	}
 	return nil
 }
 
@@ -305,6 +588,7 @@ func (p *chainedPropagator) Extract(carrier interface{}) (*SpanContext, error) {
 				}
 			}
 			if p.onlyExtractFirst {
+				p.extractTracestate(extractedCtx, carrier)
 				return extractedCtx, nil
 			}
 			ctx = extractedCtx
@@ -328,7 +612,7 @@ func (p *chainedPropagator) Extract(carrier interface{}) (*SpanContext, error) {
 						overrideDatadogParentID(ctx2, extractedCtx2, ddCtx)
 					}
 				}
-			} else if extractedCtx2 != nil { // Trace IDs do not match - create span links
+			} else if extractedCtx2 != nil && p.spanLinks { // Trace IDs do not match - create span links
 				link := SpanLink{TraceID: extractedCtx2.TraceIDLower(), SpanID: extractedCtx2.SpanID(), TraceIDHigh: extractedCtx2.TraceIDUpper(), Attributes: map[string]string{"reason": "terminated_context", "context_headers": getPropagatorName(v)}}
 				if trace := extractedCtx2.trace; trace != nil {
 					if flags := uint32(*trace.priority); flags > 0 { // Set the flags based on the sampling priority
@@ -343,6 +627,9 @@ func (p *chainedPropagator) Extract(carrier interface{}) (*SpanContext, error) {
 		}
 	}
 
+	if ctx == nil && p.correlationIDSeedsTrace {
+		ctx = p.extractCorrelationID(carrier)
+	}
 	if ctx == nil {
 		if len(pendingBaggage) > 0 {
 			ctx := &SpanContext{
@@ -369,10 +656,50 @@ func (p *chainedPropagator) Extract(carrier interface{}) (*SpanContext, error) {
 	if len(links) > 0 {
 		ctx.spanLinks = links
 	}
+	p.extractTracestate(ctx, carrier)
 	log.Debug("Extracted span context: %s", ctx.safeDebugString())
 	return ctx, nil
 }
 
+// extractTracestate propagates the W3C tracestate of carrier in ctx when it wasn't
+// extracted by the tracecontext extractor, see PropagatorConfig.AlwaysExtractTracestate.
+func (p *chainedPropagator) extractTracestate(ctx *SpanContext, carrier interface{}) {
+	if p.tracestate == nil || ctx == nil {
+		return
+	}
+	if ctx.trace != nil && ctx.trace.hasPropagatingTag(tracestateHeader) {
+		return // the tracestate extracted by the tracecontext extractor takes precedence
+	}
+	w3cCtx, err := p.tracestate.Extract(carrier)
+	if err != nil {
+		return
+	}
+	p.tracestate.propagateTracestate(ctx, w3cCtx)
+}
+
+// extractCorrelationID returns a span context continuing the trace of the
+// correlation ID header of carrier, or nil if there is none.
+func (p *chainedPropagator) extractCorrelationID(carrier interface{}) *SpanContext {
+	reader, ok := carrier.(TextMapReader)
+	if !ok {
+		return nil
+	}
+	header := strings.ToLower(p.correlationIDHeader)
+	var ctx *SpanContext
+	reader.ForeachKey(func(k, v string) error {
+		if strings.ToLower(k) != header {
+			return nil
+		}
+		if id, ok := p.correlationIDFormat.parse(v); ok {
+			ctx = &SpanContext{traceID: id, isRemote: true}
+		} else {
+			log.Debug("Ignoring invalid correlation ID %q", v)
+		}
+		return nil
+	})
+	return ctx
+}
+
 func getPropagatorName(p Propagator) string {
 	switch p.(type) {
 	case *propagator:
@@ -385,6 +712,8 @@ func getPropagatorName(p Propagator) string {
 		return "tracecontext"
 	case *propagatorBaggage:
 		return "baggage"
+	case *propagatorXRay:
+		return "xray"
 	default:
 		return ""
 	}
@@ -415,14 +744,37 @@ func (p *propagatorW3c) propagateTracestate(ctx *SpanContext, w3cCtx *SpanContex
 	// and origin will remain unchanged.
 	ts := w3cCtx.trace.propagatingTag(tracestateHeader)
 	priority, _ := ctx.SamplingPriority()
-	setPropagatingTag(ctx, tracestateHeader, composeTracestate(ctx, priority, ts))
+	setPropagatingTag(ctx, tracestateHeader, composeTracestate(ctx, p.key(), priority, ts))
 	ctx.isRemote = (w3cCtx.isRemote)
+	ctx.traceFlags = w3cCtx.traceFlags
+	ctx.samplingThreshold = w3cCtx.samplingThreshold
+	ctx.samplingRandomness = w3cCtx.samplingRandomness
 }
 
 // propagator implements Propagator and injects/extracts span contexts
 // using datadog headers. Only TextMap carriers are supported.
 type propagator struct {
 	cfg *PropagatorConfig
+
+	// lowercase forms of the configured header names, matched on extraction against
+	// the lowercased carrier keys, so that mixed-case custom names are found.
+	traceHeader    string
+	parentHeader   string
+	priorityHeader string
+	baggagePrefix  string
+
+	hashing baggageHashing // see PropagatorConfig.BaggageHashedKeys
+}
+
+func newDatadogPropagator(cfg *PropagatorConfig) *propagator {
+	return &propagator{
+		cfg:            cfg,
+		traceHeader:    strings.ToLower(cfg.TraceHeader),
+		parentHeader:   strings.ToLower(cfg.ParentHeader),
+		priorityHeader: strings.ToLower(cfg.PriorityHeader),
+		baggagePrefix:  strings.ToLower(cfg.BaggagePrefix),
+		hashing:        newBaggageHashing(cfg),
+	}
 }
 
 func (p *propagator) Inject(spanCtx *SpanContext, carrier interface{}) error {
@@ -453,12 +805,18 @@ func (p *propagator) injectTextMap(spanCtx *SpanContext, writer TextMapWriter) e
 	if sp, ok := ctx.SamplingPriority(); ok {
 		writer.Set(p.cfg.PriorityHeader, strconv.Itoa(sp))
 	}
-	if ctx.origin != "" {
+	if p.cfg.OriginHeader.inject(ctx.origin) {
 		writer.Set(originHeader, ctx.origin)
 	}
+	if p.cfg.MinimalPropagation {
+		if ctx.traceID.HasUpper() {
+			writer.Set(traceTagsHeader, keyTraceID128+"="+ctx.traceID.UpperHex())
+		}
+		return nil
+	}
 	ctx.ForeachBaggageItem(func(k, v string) bool {
 		// Propagate OpenTracing baggage.
-		writer.Set(p.cfg.BaggagePrefix+k, v)
+		writer.Set(p.cfg.BaggagePrefix+k, p.hashing.value(k, v))
 		return true
 	})
 	if p.cfg.MaxTagsHeaderLen <= 0 {
@@ -522,19 +880,22 @@ func (p *propagator) extractTextMap(reader TextMapReader) (*SpanContext, error)
 		var err error
 		key := strings.ToLower(k)
 		switch key {
-		case p.cfg.TraceHeader:
+		case p.traceHeader:
 			var lowerTid uint64
 			lowerTid, err = parseUint64(v)
+			if err != nil && p.cfg.TraceIDHexFallback {
+				lowerTid, err = parseHexUint64(v)
+			}
 			if err != nil {
 				return ErrSpanContextCorrupted
 			}
 			ctx.traceID.SetLower(lowerTid)
-		case p.cfg.ParentHeader:
+		case p.parentHeader:
 			ctx.spanID, err = parseUint64(v)
 			if err != nil {
 				return ErrSpanContextCorrupted
 			}
-		case p.cfg.PriorityHeader:
+		case p.priorityHeader:
 			priority, err := strconv.Atoi(v)
 			if err != nil {
 				return ErrSpanContextCorrupted
@@ -545,8 +906,8 @@ func (p *propagator) extractTextMap(reader TextMapReader) (*SpanContext, error)
 		case traceTagsHeader:
 			unmarshalPropagatingTags(&ctx, v)
 		default:
-			if strings.HasPrefix(key, p.cfg.BaggagePrefix) {
-				ctx.setBaggageItem(strings.TrimPrefix(key, p.cfg.BaggagePrefix), v)
+			if strings.HasPrefix(key, p.baggagePrefix) {
+				ctx.setBaggageItem(strings.TrimPrefix(key, p.baggagePrefix), v)
 			}
 		}
 		return nil
@@ -567,6 +928,11 @@ func (p *propagator) extractTextMap(reader TextMapReader) (*SpanContext, error)
 	if ctx.traceID.Empty() || (ctx.spanID == 0 && ctx.origin != "synthetics") {
 		return nil, ErrSpanContextNotFound
 	}
+	if p.cfg.MissingPriority != nil {
+		if _, ok := ctx.SamplingPriority(); !ok {
+			ctx.setSamplingPriority(*p.cfg.MissingPriority, samplernames.Unknown)
+		}
+	}
 	return &ctx, nil
 }
 
@@ -682,10 +1048,19 @@ func (*propagatorB3) injectTextMap(spanCtx *SpanContext, writer TextMapWriter) e
 	return nil
 }
 
+// Extract implements Propagator. When the carrier holds no B3 multi headers, the
+// context is extracted from the B3 single header, if valid, as some gateways forward
+// one form or the other regardless of the configuration.
 func (p *propagatorB3) Extract(carrier interface{}) (*SpanContext, error) {
 	switch c := carrier.(type) {
 	case TextMapReader:
-		return p.extractTextMap(c)
+		ctx, err := p.extractTextMap(c)
+		if err == ErrSpanContextNotFound {
+			if ctx, err := (&propagatorB3SingleHeader{}).extractTextMap(c); err == nil {
+				return ctx, nil
+			}
+		}
+		return ctx, err
 	default:
 		return nil, ErrInvalidCarrier
 	}
@@ -725,8 +1100,8 @@ func (*propagatorB3) extractTextMap(reader TextMapReader) (*SpanContext, error)
 	return &ctx, nil
 }
 
-// propagatorB3 implements Propagator and injects/extracts span contexts
-// using B3 headers. Only TextMap carriers are supported.
+// propagatorB3SingleHeader implements Propagator and injects/extracts span contexts
+// using the B3 single header. Only TextMap carriers are supported.
 type propagatorB3SingleHeader struct{}
 
 func (p *propagatorB3SingleHeader) Inject(spanCtx *SpanContext, carrier interface{}) error {
@@ -768,10 +1143,19 @@ func (*propagatorB3SingleHeader) injectTextMap(spanCtx *SpanContext, writer Text
 	return nil
 }
 
+// Extract implements Propagator. When the carrier holds no B3 single header, the
+// context is extracted from the B3 multi headers, if valid, as some gateways forward
+// one form or the other regardless of the configuration.
 func (p *propagatorB3SingleHeader) Extract(carrier interface{}) (*SpanContext, error) {
 	switch c := carrier.(type) {
 	case TextMapReader:
-		return p.extractTextMap(c)
+		ctx, err := p.extractTextMap(c)
+		if err == ErrSpanContextNotFound {
+			if ctx, err := (&propagatorB3{}).extractTextMap(c); err == nil {
+				return ctx, nil
+			}
+		}
+		return ctx, err
 	default:
 		return nil, ErrInvalidCarrier
 	}
@@ -821,6 +1205,108 @@ func (*propagatorB3SingleHeader) extractTextMap(reader TextMapReader) (*SpanCont
 	return &ctx, nil
 }
 
+// xrayHeader is the AWS X-Ray trace header, e.g.
+// Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1
+const xrayHeader = "x-amzn-trace-id"
+
+// propagatorXRay implements Propagator and injects/extracts span contexts using the
+// AWS X-Ray trace header, as set by AWS load balancers and API gateways. The X-Ray trace
+// ID holds the 128-bit trace ID, its first 8 hex digits being the epoch in seconds like
+// in Datadog 128-bit trace IDs. Only TextMap carriers are supported.
+type propagatorXRay struct{}
+
+func (p *propagatorXRay) Inject(spanCtx *SpanContext, carrier interface{}) error {
+	if spanCtx == nil {
+		return ErrInvalidSpanContext
+	}
+	switch c := carrier.(type) {
+	case TextMapWriter:
+		return p.injectTextMap(spanCtx, c)
+	default:
+		return ErrInvalidCarrier
+	}
+}
+
+func (*propagatorXRay) injectTextMap(spanCtx *SpanContext, writer TextMapWriter) error {
+	ctx := spanCtx
+	if ctx.traceID.Empty() || ctx.spanID == 0 {
+		return ErrInvalidSpanContext
+	}
+	traceID := ctx.traceID.HexEncoded()
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("Root=1-%s-%s;Parent=%016x", traceID[:8], traceID[8:], ctx.spanID))
+	if p, ok := ctx.SamplingPriority(); ok {
+		if p >= ext.PriorityAutoKeep {
+			sb.WriteString(";Sampled=1")
+		} else {
+			sb.WriteString(";Sampled=0")
+		}
+	}
+	writer.Set(xrayHeader, sb.String())
+	return nil
+}
+
+func (p *propagatorXRay) Extract(carrier interface{}) (*SpanContext, error) {
+	switch c := carrier.(type) {
+	case TextMapReader:
+		return p.extractTextMap(c)
+	default:
+		return nil, ErrInvalidCarrier
+	}
+}
+
+func (*propagatorXRay) extractTextMap(reader TextMapReader) (*SpanContext, error) {
+	var ctx SpanContext
+	err := reader.ForeachKey(func(k, v string) error {
+		if strings.ToLower(k) != xrayHeader {
+			return nil
+		}
+		for _, field := range strings.Split(v, ";") {
+			key, val, _ := strings.Cut(strings.TrimSpace(field), "=")
+			switch key {
+			case "Root":
+				// 1-<8 hex digits epoch>-<24 hex digits>
+				version, id, ok := strings.Cut(val, "-")
+				if !ok || version != "1" || len(id) != 33 || id[8] != '-' {
+					return ErrSpanContextCorrupted
+				}
+				id = id[:8] + id[9:]
+				if !isValidID(id) {
+					return ErrSpanContextCorrupted
+				}
+				if err := extractTraceID128(&ctx, id); err != nil {
+					return err
+				}
+			case "Parent":
+				if len(val) != 16 || !isValidID(val) {
+					return ErrSpanContextCorrupted
+				}
+				var err error
+				if ctx.spanID, err = strconv.ParseUint(val, 16, 64); err != nil {
+					return ErrSpanContextCorrupted
+				}
+			case "Sampled":
+				switch val {
+				case "1":
+					ctx.setSamplingPriority(ext.PriorityAutoKeep, samplernames.Unknown)
+				case "0":
+					ctx.setSamplingPriority(ext.PriorityAutoReject, samplernames.Unknown)
+				default:
+					// "?" lets the receiver make the sampling decision
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ctx.traceID.Empty() || ctx.spanID == 0 {
+		return nil, ErrSpanContextNotFound
+	}
+	return &ctx, nil
+}
+
 const (
 	traceparentHeader = "traceparent"
 	tracestateHeader  = "tracestate"
@@ -828,7 +1314,53 @@ const (
 
 // propagatorW3c implements Propagator and injects/extracts span contexts
 // using W3C tracecontext/traceparent headers. Only TextMap carriers are supported.
-type propagatorW3c struct{}
+type propagatorW3c struct {
+	tracestateKey    string // key of the Datadog tracestate list-member, see PropagatorConfig.TracestateKey
+	maxTracestateLen int    // maximum length of the extracted tracestate, see PropagatorConfig.MaxTracestateLen
+	minimal          bool   // see PropagatorConfig.MinimalPropagation
+}
+
+// defaultMaxTracestateLen is the default maximum length of the extracted tracestate header,
+// see PropagatorConfig.MaxTracestateLen.
+const defaultMaxTracestateLen = 8192
+
+// key returns the key of the Datadog list-member of the tracestate header.
+func (p *propagatorW3c) key() string {
+	if p.tracestateKey == "" {
+		return DefaultTracestateKey
+	}
+	return p.tracestateKey
+}
+
+// isValidTracestateKey reports whether key is a valid tracestate list-member key,
+// as defined by https://www.w3.org/TR/trace-context/#key, i.e. a simple key or a
+// multi-tenant key using the tenant-id@system-id format.
+func isValidTracestateKey(key string) bool {
+	tenant, system, multiTenant := strings.Cut(key, "@")
+	if !multiTenant {
+		return len(key) <= 256 && isValidTracestateKeyPart(key, true)
+	}
+	return len(tenant) <= 241 && isValidTracestateKeyPart(tenant, false) &&
+		len(system) <= 14 && isValidTracestateKeyPart(system, true)
+}
+
+// isValidTracestateKeyPart reports whether s is made of the characters allowed in a
+// tracestate key, starting with a lowercase letter, or also a digit unless firstAlpha.
+func isValidTracestateKeyPart(s string, firstAlpha bool) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9' && (i > 0 || !firstAlpha):
+		case i > 0 && (c == '_' || c == '-' || c == '*' || c == '/'):
+		default:
+			return false
+		}
+	}
+	return true
+}
 
 func (p *propagatorW3c) Inject(spanCtx *SpanContext, carrier interface{}) error {
 	if spanCtx == nil {
@@ -850,7 +1382,7 @@ func (p *propagatorW3c) Inject(spanCtx *SpanContext, carrier interface{}) error
 // which is equal to 00000001 when no other flag is present.
 // tracestateHeader is a comma-separated list of list-members with a <key>=<value> format,
 // where each list-member is managed by a vendor or instrumentation library.
-func (*propagatorW3c) injectTextMap(spanCtx *SpanContext, writer TextMapWriter) error {
+func (pw *propagatorW3c) injectTextMap(spanCtx *SpanContext, writer TextMapWriter) error {
 	if spanCtx == nil {
 		return ErrInvalidSpanContext
 	}
@@ -877,16 +1409,20 @@ func (*propagatorW3c) injectTextMap(spanCtx *SpanContext, writer TextMapWriter)
 		}
 	}
 	writer.Set(traceparentHeader, fmt.Sprintf("00-%s-%016x-%v", traceID, ctx.spanID, flags))
+	if pw.minimal {
+		writer.Set(tracestateHeader, composeMinimalTracestate(ctx, pw.key(), p))
+		return nil
+	}
 	// if context priority / origin / tags were updated after extraction,
 	// or if there is a span on the trace
-	// or the tracestateHeader doesn't start with `dd=`
+	// or the tracestateHeader doesn't start with the Datadog list-member (e.g. `dd=`)
 	// we need to recreate tracestate
 	if ctx.updated ||
 		(!ctx.isRemote || ctx.isRemote && ctx.trace != nil && ctx.trace.root != nil) ||
-		(ctx.trace != nil && !strings.HasPrefix(ctx.trace.propagatingTag(tracestateHeader), "dd=")) ||
+		(ctx.trace != nil && !strings.HasPrefix(ctx.trace.propagatingTag(tracestateHeader), pw.key()+"=")) ||
 		ctx.trace.propagatingTagsLen() == 0 {
 		// compose a new value for the tracestate
-		writer.Set(tracestateHeader, composeTracestate(ctx, p, ctx.trace.propagatingTag(tracestateHeader)))
+		writer.Set(tracestateHeader, composeTracestate(ctx, pw.key(), p, ctx.trace.propagatingTag(tracestateHeader)))
 	} else {
 		// use a cached value for the tracestate (e.g., no updating p: key)
 		writer.Set(tracestateHeader, ctx.trace.propagatingTag(tracestateHeader))
@@ -1010,6 +1546,19 @@ var (
 	}
 )
 
+// sanitizeOrigin replaces the characters of origin which are not allowed in propagation
+// headers with underscores, see originDisallowedFn. The equals character is kept, since
+// it is only encoded when composing the tracestate header.
+func sanitizeOrigin(origin string) string {
+	sm := &stringMutator{}
+	return sm.Mutate(func(r rune) (rune, bool) {
+		if r == '=' {
+			return r, false
+		}
+		return originDisallowedFn(r)
+	}, origin)
+}
+
 const (
 	asciiLowerA = 97
 	asciiLowerF = 102
@@ -1037,19 +1586,41 @@ func isValidID(id string) bool {
 	return true
 }
 
+// composeMinimalTracestate returns a tracestate holding only the Datadog list-member, with
+// the sampling priority, origin and parent ID of ctx, see PropagatorConfig.MinimalPropagation.
+func composeMinimalTracestate(ctx *SpanContext, key string, priority int) string {
+	var b strings.Builder
+	b.WriteString(key)
+	b.WriteString("=s:")
+	b.WriteString(strconv.Itoa(priority))
+	if ctx.origin != "" {
+		b.WriteString(";o:")
+		b.WriteString((&stringMutator{}).Mutate(originDisallowedFn, ctx.origin))
+	}
+	if !ctx.isRemote {
+		b.WriteString(";p:")
+		b.WriteString(spanIDHexEncoded(ctx.SpanID(), 16))
+	} else if ctx.reparentID != "" {
+		b.WriteString(";p:")
+		b.WriteString(ctx.reparentID)
+	}
+	return b.String()
+}
+
 // composeTracestate creates a tracestateHeader from the spancontext.
-// The Datadog tracing library is only responsible for managing the list member with key dd,
-// which holds the values of the sampling decision(`s:<value>`), origin(`o:<origin>`),
+// The Datadog tracing library is only responsible for managing the list member with the given key
+// (dd by default), which holds the values of the sampling decision(`s:<value>`), origin(`o:<origin>`),
 // the last parent ID of a Datadog span (`p:<parent_id>`),
 // and propagated tags prefixed with `t.`(e.g. _dd.p.usr.id:usr_id tag will become `t.usr.id:usr_id`).
-func composeTracestate(ctx *SpanContext, priority int, oldState string) string {
+func composeTracestate(ctx *SpanContext, key string, priority int, oldState string) string {
 	var (
 		b  strings.Builder
 		sm = &stringMutator{}
 	)
 
 	b.Grow(128)
-	b.WriteString("dd=s:")
+	b.WriteString(key)
+	b.WriteString("=s:")
 	b.WriteString(strconv.Itoa(priority))
 	listLength := 1
 
@@ -1089,12 +1660,25 @@ func composeTracestate(ctx *SpanContext, priority int, oldState string) string {
 		b.WriteString(value)
 		return true
 	})
+	// the upstream sampling threshold is forwarded unless the old state already
+	// carries the OpenTelemetry list-member it belongs to
+	if ctx.samplingThreshold != "" && !hasTracestateMember(oldState, otelTracestateKey) {
+		b.WriteString(",")
+		b.WriteString(otelTracestateKey)
+		b.WriteString("=th:")
+		b.WriteString(ctx.samplingThreshold)
+		if ctx.samplingRandomness != "" {
+			b.WriteString(";rv:")
+			b.WriteString(ctx.samplingRandomness)
+		}
+		listLength++
+	}
 	// the old state is split by vendors, must be concatenated with a `,`
 	if len(oldState) == 0 {
 		return b.String()
 	}
 	for _, s := range strings.Split(strings.Trim(oldState, " \t"), ",") {
-		if strings.HasPrefix(s, "dd=") {
+		if strings.HasPrefix(s, key+"=") {
 			continue
 		}
 		listLength++
@@ -1118,7 +1702,7 @@ func (p *propagatorW3c) Extract(carrier interface{}) (*SpanContext, error) {
 	}
 }
 
-func (*propagatorW3c) extractTextMap(reader TextMapReader) (*SpanContext, error) {
+func (p *propagatorW3c) extractTextMap(reader TextMapReader) (*SpanContext, error) {
 	var parentHeader string
 	var stateHeader string
 	var ctx SpanContext
@@ -1146,10 +1730,41 @@ func (*propagatorW3c) extractTextMap(reader TextMapReader) (*SpanContext, error)
 	if err := parseTraceparent(&ctx, parentHeader); err != nil {
 		return nil, err
 	}
-	parseTracestate(&ctx, stateHeader)
+	if limit := p.maxTracestateLen; limit > 0 && len(stateHeader) > limit {
+		log.Warn("Truncated %s to its list-members within the size limit: %d.", tracestateHeader, limit)
+		stateHeader = truncateTracestate(stateHeader, limit)
+		if ctx.trace == nil {
+			ctx.trace = newTrace()
+		}
+		ctx.trace.setTag(keyPropagationError, "tracestate_max_size")
+	}
+	parseTracestate(&ctx, p.key(), stateHeader)
 	return &ctx, nil
 }
 
+// truncateTracestate returns the first list-members of the tracestate header, up to 32 of
+// them, which fit in limit bytes. It doesn't look past the first limit bytes of the header.
+func truncateTracestate(header string, limit int) string {
+	if len(header) > limit {
+		if header[limit] == ',' {
+			header = header[:limit]
+		} else if i := strings.LastIndexByte(header[:limit], ','); i >= 0 {
+			header = header[:i]
+		} else {
+			return ""
+		}
+	}
+	for i, n := 0, 0; i < len(header); i++ {
+		if header[i] != ',' {
+			continue
+		}
+		if n++; n == 32 {
+			return header[:i]
+		}
+	}
+	return header
+}
+
 // parseTraceparent attempts to parse traceparentHeader which describes the position
 // of the incoming request in its trace graph in a portable, fixed-length format.
 // The format of the traceparentHeader is `-` separated string with in the
@@ -1227,6 +1842,7 @@ func parseTraceparent(ctx *SpanContext, header string) error {
 	if err != nil {
 		return ErrSpanContextCorrupted
 	}
+	ctx.traceFlags = byte(f)
 	ctx.setSamplingPriority(int(f)&0x1, samplernames.Unknown)
 	return nil
 }
@@ -1235,12 +1851,14 @@ func parseTraceparent(ctx *SpanContext, header string) error {
 // with up to 32 comma-separated (,) list-members.
 // An example value would be: `vendorname1=opaqueValue1,vendorname2=opaqueValue2,dd=s:1;o:synthetics`,
 // Where `dd` list contains values that would be in x-datadog-tags as well as those needed for propagation information.
+// The key of the Datadog list-member is given by key, which is `dd` by default.
 // The keys to the "dd" values have been shortened as follows to save space:
 // `sampling_priority` = `s`
 // `origin` = `o`
 // `last parent` = `p`
 // `_dd.p.` prefix = `t.`
-func parseTracestate(ctx *SpanContext, header string) {
+// The sampling threshold (`th`) and randomness (`rv`) of the OpenTelemetry `ot` list-member are read as well.
+func parseTracestate(ctx *SpanContext, key string, header string) {
 	if header == "" {
 		// The W3C spec says tracestate can be empty but should avoid sending it.
 		// https://www.w3.org/TR/trace-context-1/#tracestate-header-field-values
@@ -1250,10 +1868,14 @@ func parseTracestate(ctx *SpanContext, header string) {
 	setPropagatingTag(ctx, tracestateHeader, header)
 	combined := strings.Split(strings.Trim(header, "\t "), ",")
 	for _, group := range combined {
-		if !strings.HasPrefix(group, "dd=") {
+		if g := strings.Trim(group, "\t "); strings.HasPrefix(g, otelTracestateKey+"=") {
+			parseOtelTracestate(ctx, g[len(otelTracestateKey)+1:])
+			continue
+		}
+		if !strings.HasPrefix(group, key+"=") {
 			continue
 		}
-		ddMembers := strings.Split(group[len("dd="):], ";")
+		ddMembers := strings.Split(group[len(key)+1:], ";")
 		dropDM := false
 		// indicate that backend could reparent this as a root
 		for _, member := range ddMembers {
@@ -1305,6 +1927,67 @@ func parseTracestate(ctx *SpanContext, header string) {
 	}
 }
 
+// otelTracestateKey is the key of the tracestate list-member owned by OpenTelemetry.
+const otelTracestateKey = "ot"
+
+// parseOtelTracestate reads the sampling threshold and randomness from the value
+// of the OpenTelemetry tracestate list-member, e.g. `th:8;rv:9b8233f7e3a151`.
+// The threshold, made of 1 to 14 hex digits, is the rejection threshold
+// used by OpenTelemetry consistent probability sampling, and the randomness,
+// made of exactly 14 hex digits, replaces the one of the trace ID; see
+// SpanContext.otelSampled. Invalid values are ignored.
+func parseOtelTracestate(ctx *SpanContext, value string) {
+	for _, member := range strings.Split(value, ";") {
+		if th, ok := strings.CutPrefix(member, "th:"); ok {
+			if len(th) > 0 && len(th) <= otelSamplingDigits && isValidID(th) {
+				ctx.samplingThreshold = th
+			}
+		} else if rv, ok := strings.CutPrefix(member, "rv:"); ok {
+			if len(rv) == otelSamplingDigits && isValidID(rv) {
+				ctx.samplingRandomness = rv
+			}
+		}
+	}
+}
+
+// otelSamplingDigits is the number of hex digits of the 56-bit thresholds and
+// randomness values of OpenTelemetry consistent probability sampling.
+const otelSamplingDigits = 14
+
+// otelSampled reports whether the trace of c is kept according to the sampling
+// threshold set upstream by OpenTelemetry consistent probability sampling, which
+// keeps the traces whose randomness is at least the threshold. The randomness is
+// the one set upstream, or else the 56 least significant bits of the trace ID.
+// ok is false if no valid threshold was set upstream.
+func (c *SpanContext) otelSampled() (keep, ok bool) {
+	if c.samplingThreshold == "" {
+		return false, false
+	}
+	// the threshold is given by its most significant digits, the others being zeros
+	threshold, err := strconv.ParseUint(c.samplingThreshold+strings.Repeat("0", otelSamplingDigits-len(c.samplingThreshold)), 16, 64)
+	if err != nil {
+		return false, false
+	}
+	randomness := c.traceID.Lower() & (1<<56 - 1)
+	if c.samplingRandomness != "" {
+		if randomness, err = strconv.ParseUint(c.samplingRandomness, 16, 64); err != nil {
+			return false, false
+		}
+	}
+	return randomness >= threshold, true
+}
+
+// hasTracestateMember reports whether the tracestate header contains a
+// list-member with the given key.
+func hasTracestateMember(header, key string) bool {
+	for _, member := range strings.Split(header, ",") {
+		if strings.HasPrefix(strings.Trim(member, " \t"), key+"=") {
+			return true
+		}
+	}
+	return false
+}
+
 // extractTraceID128 extracts the trace id from v and populates the traceID
 // field, and the traceID128 field (if applicable) of the provided ctx,
 // returning an error if v is invalid.
@@ -1363,7 +2046,58 @@ func urlEncode(input string, safeCharacters string) string {
 
 // propagatorBaggage implements Propagator and injects/extracts span contexts
 // using baggage headers.
-type propagatorBaggage struct{}
+type propagatorBaggage struct {
+	minimal       bool                     // see PropagatorConfig.MinimalPropagation
+	trustFunc     func(TextMapReader) bool // see PropagatorConfig.BaggageTrustFunc
+	truncationTag bool                     // see PropagatorConfig.BaggageTruncationTag
+	hashing       baggageHashing           // see PropagatorConfig.BaggageHashedKeys
+}
+
+// baggageHashing hashes the values of the baggage keys listed in
+// PropagatorConfig.BaggageHashedKeys on injection.
+type baggageHashing struct {
+	keys map[string]struct{}         // see PropagatorConfig.BaggageHashedKeys
+	salt string                      // see PropagatorConfig.BaggageHashSalt
+	hash func(string, string) string // see PropagatorConfig.BaggageHashFunc
+}
+
+func newBaggageHashing(cfg *PropagatorConfig) baggageHashing {
+	if len(cfg.BaggageHashedKeys) == 0 {
+		return baggageHashing{}
+	}
+	h := baggageHashing{
+		keys: make(map[string]struct{}, len(cfg.BaggageHashedKeys)),
+		salt: cfg.BaggageHashSalt,
+		hash: cfg.BaggageHashFunc,
+	}
+	for _, k := range cfg.BaggageHashedKeys {
+		h.keys[k] = struct{}{}
+	}
+	if h.hash == nil {
+		h.hash = hashBaggageValue
+	}
+	return h
+}
+
+// value returns the value of the baggage item k to inject, which is v hashed if k is
+// one of the hashed keys.
+func (h baggageHashing) value(k, v string) string {
+	if _, ok := h.keys[k]; ok {
+		return h.hash(v, h.salt)
+	}
+	return v
+}
+
+// hashBaggageValue is the default PropagatorConfig.BaggageHashFunc.
+func hashBaggageValue(value, salt string) string {
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// keyBaggageTruncated holds the number of baggage items dropped on injection, see
+// PropagatorConfig.BaggageTruncationTag.
+const keyBaggageTruncated = "_dd.baggage.truncated"
 
 func (p *propagatorBaggage) Inject(spanCtx *SpanContext, carrier interface{}) error {
 	switch c := carrier.(type) {
@@ -1377,23 +2111,25 @@ func (p *propagatorBaggage) Inject(spanCtx *SpanContext, carrier interface{}) er
 // injectTextMap propagates baggage items from the span context into the writer,
 // in the format of a single HTTP "baggage" header. Baggage consists of key=value pairs,
 // separated by commas. This function enforces a maximum number of baggage items and a maximum overall size.
-// If either limit is exceeded, excess items or bytes are dropped, and a warning is logged.
+// If either limit is exceeded, excess items or bytes are dropped, and the span of ctx is tagged
+// with the number of dropped items if PropagatorConfig.BaggageTruncationTag is set.
 //
 // Example of a single "baggage" header:
 // baggage: foo=bar,baz=qux
 //
 // Each key and value pair is encoded and added to the existing baggage header in <key>=<value> format,
 // joined together by commas,
-func (*propagatorBaggage) injectTextMap(ctx *SpanContext, writer TextMapWriter) error {
-	if ctx == nil {
+func (p *propagatorBaggage) injectTextMap(ctx *SpanContext, writer TextMapWriter) error {
+	if ctx == nil || p.minimal {
 		return nil
 	}
 
-	ctr := 0
+	ctr, dropped := 0, 0
 	var baggageBuilder strings.Builder
 	ctx.ForeachBaggageItem(func(k, v string) bool {
-		if ctr >= baggageMaxItems {
-			return false
+		if dropped > 0 || ctr >= baggageMaxItems {
+			dropped++
+			return true
 		}
 
 		var itemBuilder strings.Builder
@@ -1403,9 +2139,10 @@ func (*propagatorBaggage) injectTextMap(ctx *SpanContext, writer TextMapWriter)
 
 		itemBuilder.WriteString(encodeKey(k))
 		itemBuilder.WriteRune('=')
-		itemBuilder.WriteString(encodeValue(v))
+		itemBuilder.WriteString(encodeValue(p.hashing.value(k, v)))
 		if itemBuilder.Len()+baggageBuilder.Len() > baggageMaxBytes {
-			return false
+			dropped++
+			return true
 		}
 		baggageBuilder.WriteString(itemBuilder.String())
 		ctr++
@@ -1414,6 +2151,9 @@ func (*propagatorBaggage) injectTextMap(ctx *SpanContext, writer TextMapWriter)
 	if baggageBuilder.Len() > 0 {
 		writer.Set("baggage", baggageBuilder.String())
 	}
+	if dropped > 0 && p.truncationTag && ctx.span != nil {
+		ctx.span.SetTag(keyBaggageTruncated, dropped)
+	}
 	return nil
 }
 
@@ -1426,9 +2166,12 @@ func (p *propagatorBaggage) Extract(carrier interface{}) (*SpanContext, error) {
 	}
 }
 
-func (*propagatorBaggage) extractTextMap(reader TextMapReader) (*SpanContext, error) {
+func (p *propagatorBaggage) extractTextMap(reader TextMapReader) (*SpanContext, error) {
 	var baggageHeader string
 	var ctx SpanContext
+	if p.trustFunc != nil && !p.trustFunc(reader) {
+		return &ctx, nil
+	}
 	err := reader.ForeachKey(func(k, v string) error {
 		if strings.ToLower(k) == "baggage" {
 			// Expect only one baggage header, return early
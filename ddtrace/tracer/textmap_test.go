@@ -8,6 +8,7 @@ package tracer
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"reflect"
 	"regexp"
@@ -224,6 +225,78 @@ func TestTextMapExtractTracestatePropagation(t *testing.T) {
 	}
 }
 
+func TestTextMapExtractAlwaysTracestate(t *testing.T) {
+	tests := []struct {
+		name             string
+		propagationStyle string
+		traceparent      string
+		wantTracestate   string
+		wantSpanID       uint64
+	}{
+		{
+			name:             "datadog-only",
+			propagationStyle: "datadog",
+			traceparent:      "00-00000000000000000000000000000004-2222222222222222-01",
+			wantTracestate:   "dd=s:0;o:synthetics;p:0000000000000001,othervendor=t61rcWkgMzE",
+			wantSpanID:       1,
+		},
+		{
+			name:             "datadog-only-mismatching-ids",
+			propagationStyle: "datadog",
+			traceparent:      "00-00000000000000000000000000000088-2222222222222222-01",
+			wantSpanID:       1,
+		},
+		{
+			name:             "datadog-only-no-traceparent",
+			propagationStyle: "datadog",
+			wantSpanID:       1,
+		},
+		{
+			// the tracecontext extractor takes precedence
+			name:             "datadog-and-w3c",
+			propagationStyle: "datadog,tracecontext",
+			traceparent:      "00-00000000000000000000000000000004-2222222222222222-01",
+			wantTracestate:   "dd=s:0;o:synthetics;p:0000000000000001,othervendor=t61rcWkgMzE",
+			wantSpanID:       0x2222222222222222,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(headerPropagationStyleExtract, tc.propagationStyle)
+			propagator := NewPropagator(&PropagatorConfig{AlwaysExtractTracestate: true})
+			headers := TextMapCarrier(map[string]string{
+				DefaultTraceIDHeader:  "4",
+				DefaultParentIDHeader: "1",
+				originHeader:          "synthetics",
+				tracestateHeader:      "dd=s:2;o:rum;p:0000000000000001;t.tid:1230000000000000~~,othervendor=t61rcWkgMzE",
+			})
+			if tc.traceparent != "" {
+				headers[traceparentHeader] = tc.traceparent
+			}
+
+			sctx, err := propagator.Extract(headers)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantSpanID, sctx.spanID)
+			assert.Equal(t, "synthetics", sctx.origin)
+			if tc.wantTracestate == "" {
+				if sctx.trace != nil {
+					assert.False(t, sctx.trace.hasPropagatingTag(tracestateHeader))
+				}
+				return
+			}
+			assert.Equal(t, tc.wantTracestate, sctx.trace.propagatingTag(tracestateHeader))
+
+			tracer, err := newTracer()
+			require.NoError(t, err)
+			defer tracer.Stop()
+			child := tracer.StartSpan("child", ChildOf(sctx))
+			out := TextMapCarrier{}
+			require.NoError(t, tracer.Inject(child.Context(), out))
+			assert.Contains(t, out[tracestateHeader], "othervendor=t61rcWkgMzE")
+		})
+	}
+}
+
 func TestTextMapPropagatorErrors(t *testing.T) {
 	t.Setenv(headerPropagationStyleExtract, "datadog")
 	propagator := NewPropagator(nil)
@@ -266,6 +339,103 @@ func TestTextMapPropagatorErrors(t *testing.T) {
 	assert.Equal(ErrSpanContextNotFound, err)
 }
 
+func TestTextMapPropagatorMissingPriority(t *testing.T) {
+	t.Setenv(headerPropagationStyleExtract, "datadog")
+	reject := ext.PriorityAutoReject
+
+	for _, tc := range []struct {
+		name     string
+		missing  *int
+		priority string
+		want     int
+		wantOK   bool
+	}{
+		{name: "present", priority: "2", want: 2, wantOK: true},
+		{name: "absent"},
+		{name: "present/default", missing: &reject, priority: "2", want: 2, wantOK: true},
+		{name: "absent/default", missing: &reject, want: ext.PriorityAutoReject, wantOK: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			propagator := NewPropagator(&PropagatorConfig{MissingPriority: tc.missing})
+			carrier := TextMapCarrier{
+				DefaultTraceIDHeader:  "1",
+				DefaultParentIDHeader: "2",
+			}
+			if tc.priority != "" {
+				carrier[DefaultPriorityHeader] = tc.priority
+			}
+			ctx, err := propagator.Extract(carrier)
+			require.NoError(t, err)
+			p, ok := ctx.SamplingPriority()
+			assert.Equal(t, tc.wantOK, ok)
+			assert.Equal(t, tc.want, p)
+		})
+	}
+
+	t.Run("span", func(t *testing.T) {
+		keep := ext.PriorityAutoKeep
+		tracer, err := newTracer(WithPropagator(NewPropagator(&PropagatorConfig{MissingPriority: &keep})), WithSamplerRate(0))
+		require.NoError(t, err)
+		defer tracer.Stop()
+		sctx, err := tracer.Extract(TextMapCarrier{
+			DefaultTraceIDHeader:  "1",
+			DefaultParentIDHeader: "2",
+		})
+		require.NoError(t, err)
+		// the local sampler, which would drop the trace, doesn't make the decision
+		span := tracer.StartSpan("web.request", ChildOf(sctx))
+		p, ok := span.Context().SamplingPriority()
+		assert.True(t, ok)
+		assert.Equal(t, ext.PriorityAutoKeep, p)
+	})
+}
+
+func TestTextMapPropagatorTraceIDHexFallback(t *testing.T) {
+	t.Setenv(headerPropagationStyleExtract, "datadog")
+
+	for _, tc := range []struct {
+		name     string
+		fallback bool
+		traceID  string
+		want     uint64
+		err      error
+	}{
+		{name: "decimal", traceID: "1234", want: 1234},
+		{name: "hex", traceID: "4d2", err: ErrSpanContextCorrupted},
+		{name: "decimal/fallback", fallback: true, traceID: "1234", want: 1234},
+		{name: "hex/fallback", fallback: true, traceID: "4d2", want: 0x4d2},
+		{name: "hex-prefix/fallback", fallback: true, traceID: "0xFFFFFFFFFFFFFFFF", want: math.MaxUint64},
+		{name: "hex-too-long/fallback", fallback: true, traceID: "1ffffffffffffffff", err: ErrSpanContextCorrupted},
+		{name: "invalid/fallback", fallback: true, traceID: "xyz", err: ErrSpanContextCorrupted},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			propagator := NewPropagator(&PropagatorConfig{TraceIDHexFallback: tc.fallback})
+			ctx, err := propagator.Extract(TextMapCarrier(map[string]string{
+				DefaultTraceIDHeader:  tc.traceID,
+				DefaultParentIDHeader: "2",
+			}))
+			if tc.err != nil {
+				assert.Equal(t, tc.err, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, ctx.traceID.Lower())
+			assert.Equal(t, uint64(2), ctx.SpanID())
+		})
+	}
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("DD_TRACE_DATADOG_TRACEID_HEX_FALLBACK", "true")
+		propagator := NewPropagator(nil)
+		ctx, err := propagator.Extract(TextMapCarrier(map[string]string{
+			DefaultTraceIDHeader:  "4d2",
+			DefaultParentIDHeader: "2",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0x4d2), ctx.traceID.Lower())
+	})
+}
+
 func TestTextMapPropagatorInjectHeader(t *testing.T) {
 	assert := assert.New(t)
 
@@ -297,6 +467,36 @@ func TestTextMapPropagatorInjectHeader(t *testing.T) {
 	assert.Equal(headers.Get(DefaultPriorityHeader), "0")
 }
 
+func TestTextMapPropagatorMixedCaseHeaders(t *testing.T) {
+	t.Setenv(headerPropagationStyle, "datadog")
+	propagator := NewPropagator(&PropagatorConfig{
+		BaggagePrefix:  "My-Baggage-",
+		TraceHeader:    "MyTraceID",
+		ParentHeader:   "MyParentID",
+		PriorityHeader: "MyPriority",
+	})
+	carrier := TextMapCarrier{}
+	require.NoError(t, propagator.Inject(&SpanContext{traceID: traceIDFrom64Bits(1), spanID: 2, baggage: map[string]string{"item": "x"}, hasBaggage: 1}, carrier))
+	// the configured names are kept as is on injection
+	assert.Equal(t, "1", carrier["MyTraceID"])
+	assert.Equal(t, "2", carrier["MyParentID"])
+	assert.Equal(t, "x", carrier["My-Baggage-item"])
+
+	ctx, err := propagator.Extract(TextMapCarrier{
+		"MyTraceID":       "1",
+		"MYPARENTID":      "2",
+		"mypriority":      "1",
+		"My-Baggage-item": "x",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), ctx.traceID.Lower())
+	assert.Equal(t, uint64(2), ctx.spanID)
+	p, ok := ctx.SamplingPriority()
+	assert.True(t, ok)
+	assert.Equal(t, 1, p)
+	assert.Equal(t, "x", ctx.baggageItem("item"))
+}
+
 func TestTextMapPropagatorOrigin(t *testing.T) {
 	t.Setenv(headerPropagationStyleExtract, "datadog")
 	t.Setenv(headerPropagationStyleInject, "datadog")
@@ -1676,6 +1876,64 @@ func TestEnvVars(t *testing.T) {
 		}
 	})
 
+	t.Run("datadog extract / w3c inject", func(t *testing.T) {
+		// Migration setup: look like OpenTelemetry to downstream services while
+		// still continuing traces from Datadog-instrumented upstream services.
+		t.Setenv(headerPropagationStyleInject, "tracecontext")
+		t.Setenv(headerPropagationStyleExtract, "datadog")
+		inHeaders := TextMapCarrier{
+			DefaultTraceIDHeader:  "123456789",
+			DefaultParentIDHeader: "987654321",
+			DefaultPriorityHeader: "1",
+			originHeader:          "rum",
+		}
+
+		tracer, err := newTracer(WithHTTPClient(c), withStatsdClient(&statsd.NoOpClientDirect{}))
+		require.NoError(t, err)
+		defer tracer.Stop()
+		ctx, err := tracer.Extract(inHeaders)
+		require.NoError(t, err)
+
+		t.Run("extracted", func(t *testing.T) {
+			headers := TextMapCarrier{}
+			require.NoError(t, tracer.Inject(ctx, headers))
+			assert := assert.New(t)
+			assert.Equal("00-000000000000000000000000075bcd15-000000003ade68b1-01", headers[traceparentHeader])
+			// the extracted Datadog span ID is the last seen parent
+			checkSameElements(assert, "dd=s:1;o:rum;p:000000003ade68b1", headers[tracestateHeader])
+			assert.NotContains(headers, DefaultTraceIDHeader)
+			assert.NotContains(headers, DefaultParentIDHeader)
+		})
+
+		t.Run("child", func(t *testing.T) {
+			root := tracer.StartSpan("web.request", ChildOf(ctx), WithSpanID(1))
+			defer root.Finish()
+			assert.Equal(t, uint64(987654321), root.parentID)
+			// the parent came from Datadog headers, so there is nothing to reparent
+			assert.NotContains(t, root.meta, keyReparentID)
+
+			headers := TextMapCarrier{}
+			require.NoError(t, tracer.Inject(root.Context(), headers))
+			assert.Equal(t, "00-000000000000000000000000075bcd15-0000000000000001-01", headers[traceparentHeader])
+			checkSameElements(assert.New(t), "dd=s:1;o:rum;p:0000000000000001", headers[tracestateHeader])
+
+			t.Run("downstream", func(t *testing.T) {
+				t.Setenv(headerPropagationStyleExtract, "tracecontext")
+				downstream, err := newTracer(WithHTTPClient(c), withStatsdClient(&statsd.NoOpClientDirect{}))
+				require.NoError(t, err)
+				defer downstream.Stop()
+				dctx, err := downstream.Extract(headers)
+				require.NoError(t, err)
+				span := downstream.StartSpan("db.query", ChildOf(dctx))
+				defer span.Finish()
+				assert.Equal(t, uint64(1), span.parentID)
+				assert.Equal(t, uint64(123456789), span.traceID)
+				// p: points to the direct parent, so reparenting is a no-op
+				assert.Equal(t, "0000000000000001", span.meta[keyReparentID])
+			})
+		})
+	})
+
 	t.Run("w3c inject/extract", func(t *testing.T) {
 		testEnvs = []map[string]string{
 			{headerPropagationStyleInject: "tracecontext", headerPropagationStyleExtract: "tracecontext"},
@@ -1894,6 +2152,110 @@ func checkSameElements(assert *assert.Assertions, want, got string) {
 	assert.ElementsMatch(gotInnerList, wantInnerList)
 }
 
+func TestB3ExtractFallback(t *testing.T) {
+	for name, tt := range map[string]struct {
+		style   string
+		carrier TextMapCarrier
+		err     error
+	}{
+		"multi-style/single-header": {
+			style:   "b3multi",
+			carrier: TextMapCarrier{b3SingleHeader: "000000000000000a-000000000000000b-1"},
+		},
+		"single-style/multi-headers": {
+			style: "b3 single header",
+			carrier: TextMapCarrier{
+				b3TraceIDHeader: "000000000000000a",
+				b3SpanIDHeader:  "000000000000000b",
+				b3SampledHeader: "1",
+			},
+		},
+		"multi-style/invalid-single-header": {
+			style:   "b3multi",
+			carrier: TextMapCarrier{b3SingleHeader: "000000000000000a-000000000000000b-x"},
+			err:     ErrSpanContextNotFound,
+		},
+		"single-style/no-headers": {
+			style:   "b3 single header",
+			carrier: TextMapCarrier{},
+			err:     ErrSpanContextNotFound,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(headerPropagationStyleExtract, tt.style)
+			ctx, err := NewPropagator(nil).Extract(tt.carrier)
+			if tt.err != nil {
+				assert.Equal(t, tt.err, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, uint64(10), ctx.traceID.Lower())
+			assert.Equal(t, uint64(11), ctx.spanID)
+			p, ok := ctx.SamplingPriority()
+			assert.True(t, ok)
+			assert.Equal(t, 1, p)
+		})
+	}
+}
+
+func TestXRayPropagator(t *testing.T) {
+	t.Setenv(headerPropagationStyle, "xray")
+	propagator := NewPropagator(nil)
+
+	t.Run("inject", func(t *testing.T) {
+		ctx := &SpanContext{traceID: traceIDFrom128Bits(0x5759e988bd862e3f, 0xe1be46a994272793), spanID: 0x53995c3f42cd8ad8}
+		carrier := TextMapCarrier{}
+		require.NoError(t, propagator.Inject(ctx, carrier))
+		assert.Equal(t, "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8", carrier[xrayHeader])
+
+		ctx.setSamplingPriority(ext.PriorityUserReject, samplernames.Manual)
+		require.NoError(t, propagator.Inject(ctx, carrier))
+		assert.Equal(t, "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=0", carrier[xrayHeader])
+
+		ctx = &SpanContext{traceID: traceIDFrom64Bits(1), spanID: 2}
+		ctx.setSamplingPriority(ext.PriorityUserKeep, samplernames.Manual)
+		require.NoError(t, propagator.Inject(ctx, carrier))
+		assert.Equal(t, "Root=1-00000000-000000000000000000000001;Parent=0000000000000002;Sampled=1", carrier[xrayHeader])
+	})
+
+	t.Run("extract", func(t *testing.T) {
+		for _, tc := range []struct {
+			header   string
+			priority int
+			sampled  bool
+		}{
+			{"Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1", ext.PriorityAutoKeep, true},
+			{"Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=0", ext.PriorityAutoReject, true},
+			{"Self=1-67891234-12456789abcdef012345678;Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=?", 0, false},
+			{"Parent=53995c3f42cd8ad8; Root=1-5759e988-bd862e3fe1be46a994272793", 0, false},
+		} {
+			t.Run(tc.header, func(t *testing.T) {
+				ctx, err := propagator.Extract(TextMapCarrier{"X-Amzn-Trace-Id": tc.header})
+				require.NoError(t, err)
+				assert.Equal(t, "5759e988bd862e3fe1be46a994272793", ctx.TraceID())
+				assert.Equal(t, uint64(0x53995c3f42cd8ad8), ctx.spanID)
+				p, ok := ctx.SamplingPriority()
+				assert.Equal(t, tc.sampled, ok)
+				assert.Equal(t, tc.priority, p)
+			})
+		}
+	})
+
+	t.Run("extract invalid", func(t *testing.T) {
+		for header, want := range map[string]error{
+			"Root=1-5759e988-bd862e3fe1be46a994272793":                                   ErrSpanContextNotFound,
+			"Root=2-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8":           ErrSpanContextCorrupted,
+			"Root=1-5759e988bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8":            ErrSpanContextCorrupted,
+			"Root=1-5759e988-bd862e3fe1be46a99427279z;Parent=53995c3f42cd8ad8":           ErrSpanContextCorrupted,
+			"Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad":            ErrSpanContextCorrupted,
+			"Root=1-00000000-000000000000000000000000;Parent=53995c3f42cd8ad8;Sampled=1": ErrSpanContextCorrupted,
+		} {
+			_, err := propagator.Extract(TextMapCarrier{xrayHeader: header})
+			assert.Equal(t, want, err, header)
+		}
+	})
+}
+
 func TestTraceContextPrecedence(t *testing.T) {
 	t.Setenv(headerPropagationStyleExtract, "datadog,b3,tracecontext")
 	tracer, err := newTracer()
@@ -2000,6 +2362,229 @@ func TestSpanLinks(t *testing.T) {
 		assert.Equal(traceIDFrom64Bits(1), sctx.traceID)
 		assert.Len(sctx.spanLinks, 0)
 	})
+	t.Run("No links when disabled", func(t *testing.T) {
+		carrier := TextMapCarrier{
+			DefaultTraceIDHeader:  "1",
+			DefaultParentIDHeader: "1",
+			DefaultPriorityHeader: "3",
+			traceparentHeader:     "00-00000000000000000000000000000002-0000000000000002-01",
+		}
+		t.Run("env", func(t *testing.T) {
+			t.Setenv("DD_TRACE_PROPAGATION_SPAN_LINKS", "false")
+			tracer, err := newTracer(WithHTTPClient(c))
+			assert.NoError(t, err)
+			defer tracer.Stop()
+			sctx, err := tracer.Extract(carrier)
+			require.NoError(t, err)
+			assert.Equal(t, traceIDFrom64Bits(1), sctx.traceID)
+			assert.Len(t, sctx.spanLinks, 0)
+		})
+		t.Run("config", func(t *testing.T) {
+			sctx, err := NewPropagator(&PropagatorConfig{DisableSpanLinks: true}).Extract(carrier)
+			require.NoError(t, err)
+			assert.Equal(t, traceIDFrom64Bits(1), sctx.traceID)
+			assert.Len(t, sctx.spanLinks, 0)
+		})
+	})
+}
+
+func TestW3CTraceFlags(t *testing.T) {
+	for name, tt := range map[string]struct {
+		env   string
+		flags string
+		want  byte
+	}{
+		"sampled":            {"tracecontext", "01", 0x01},
+		"random":             {"tracecontext", "02", 0x02},
+		"sampled-random":     {"tracecontext", "03", 0x03},
+		"not-sampled":        {"tracecontext", "00", 0x00},
+		"datadog-first":      {"datadog,tracecontext", "03", 0x03},
+		"datadog-first-only": {"datadog", "03", 0x00},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(headerPropagationStyleExtract, tt.env)
+			tracer, err := newTracer()
+			require.NoError(t, err)
+			defer tracer.Stop()
+
+			sctx, err := tracer.Extract(TextMapCarrier{
+				DefaultTraceIDHeader:  "1",
+				DefaultParentIDHeader: "1",
+				DefaultPriorityHeader: "1",
+				traceparentHeader:     "00-00000000000000000000000000000001-0000000000000002-" + tt.flags,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sctx.TraceFlags())
+		})
+	}
+}
+
+func TestW3CTracestateKey(t *testing.T) {
+	t.Setenv(headerPropagationStyle, "tracecontext")
+
+	t.Run("custom", func(t *testing.T) {
+		propagator := NewPropagator(&PropagatorConfig{TracestateKey: "dd2"})
+		sctx, err := propagator.Extract(TextMapCarrier{
+			traceparentHeader: "00-12345678901234567890123456789012-1234567890123456-01",
+			tracestateHeader:  "dd=s:2;o:rum,dd2=s:1;o:synthetics,othervendor=t61rcWkgMzE",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "synthetics", sctx.origin)
+
+		sctx.origin = "lambda"
+		sctx.updated = true
+		carrier := TextMapCarrier{}
+		require.NoError(t, propagator.Inject(sctx, carrier))
+		ts := carrier[tracestateHeader]
+		assert.True(t, strings.HasPrefix(ts, "dd2=s:1;o:lambda"), ts)
+		assert.True(t, strings.HasSuffix(ts, ",dd=s:2;o:rum,othervendor=t61rcWkgMzE"), ts)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		cfg := &PropagatorConfig{TracestateKey: "Not Valid"}
+		propagator := NewPropagator(cfg)
+		assert.Equal(t, DefaultTracestateKey, cfg.TracestateKey)
+		sctx, err := propagator.Extract(TextMapCarrier{
+			traceparentHeader: "00-12345678901234567890123456789012-1234567890123456-01",
+			tracestateHeader:  "dd=s:1;o:rum",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "rum", sctx.origin)
+	})
+}
+
+func TestW3CTracestateSamplingThreshold(t *testing.T) {
+	t.Setenv(headerPropagationStyle, "tracecontext")
+	propagator := NewPropagator(nil)
+
+	for header, want := range map[string]string{
+		"dd=s:1,ot=th:8;rv:9b8233f7e3a151":  "8",
+		"dd=s:1, ot=rv:9b8233f7e3a151;th:c": "c",
+		"ot=th:fd70a3d70a3d71":              "fd70a3d70a3d71",
+		"ot=th:fd70a3d70a3d710":             "",
+		"ot=th:XYZ":                         "",
+		"ot=th:":                            "",
+		"dd=s:1,othervendor=th:8":           "",
+	} {
+		t.Run(header, func(t *testing.T) {
+			sctx, err := propagator.Extract(TextMapCarrier{
+				traceparentHeader: "00-12345678901234567890123456789012-1234567890123456-01",
+				tracestateHeader:  header,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, want, sctx.samplingThreshold)
+		})
+	}
+
+	t.Run("inject", func(t *testing.T) {
+		sctx, err := propagator.Extract(TextMapCarrier{
+			traceparentHeader: "00-12345678901234567890123456789012-1234567890123456-01",
+			tracestateHeader:  "dd=s:1,ot=th:8;rv:9b8233f7e3a151",
+		})
+		require.NoError(t, err)
+		tracer, err := newTracer()
+		require.NoError(t, err)
+		defer tracer.Stop()
+		child := tracer.StartSpan("child", ChildOf(sctx))
+		defer child.Finish()
+		assert.Equal(t, "8", child.Context().samplingThreshold)
+
+		// the upstream list-member is forwarded untouched
+		carrier := TextMapCarrier{}
+		require.NoError(t, propagator.Inject(child.Context(), carrier))
+		assert.True(t, strings.HasSuffix(carrier[tracestateHeader], ",ot=th:8;rv:9b8233f7e3a151"), carrier[tracestateHeader])
+
+		// the threshold is added when the list-member is missing
+		ts := composeTracestate(child.Context(), DefaultTracestateKey, 1, "othervendor=t61rcWkgMzE")
+		assert.True(t, strings.HasSuffix(ts, ",ot=th:8;rv:9b8233f7e3a151,othervendor=t61rcWkgMzE"), ts)
+	})
+
+	t.Run("sampling", func(t *testing.T) {
+		t.Setenv(headerPropagationStyle, "datadog,tracecontext")
+		propagator := NewPropagator(nil)
+		tracer, err := newTracer()
+		require.NoError(t, err)
+		defer tracer.Stop()
+
+		for tracestate, want := range map[string]int{
+			// the explicit randomness is compared against the threshold
+			"ot=th:8;rv:9b8233f7e3a151": ext.PriorityAutoKeep,
+			"ot=th:c;rv:9b8233f7e3a151": ext.PriorityAutoReject,
+			// or else the 56 least significant bits of the trace ID, 0x34567890123456
+			"ot=th:3":  ext.PriorityAutoKeep,
+			"ot=th:35": ext.PriorityAutoReject,
+		} {
+			// the Datadog headers carry no sampling priority, the decision is left to the tracer
+			sctx, err := propagator.Extract(TextMapCarrier{
+				DefaultTraceIDHeader:  "14731774602130518", // 0x34567890123456
+				DefaultParentIDHeader: "1",
+				traceparentHeader:     "00-00000000000000000034567890123456-0000000000000001-01",
+				tracestateHeader:      tracestate,
+			})
+			require.NoError(t, err)
+			_, ok := sctx.SamplingPriority()
+			require.False(t, ok)
+			s := tracer.StartSpan("child", ChildOf(sctx))
+			p, _ := s.Context().SamplingPriority()
+			assert.Equal(t, want, p, tracestate)
+			s.Finish()
+		}
+	})
+}
+
+func TestIsValidTracestateKey(t *testing.T) {
+	for key, want := range map[string]bool{
+		"dd":                     true,
+		"dd2":                    true,
+		"dd_org-1/a*b":           true,
+		"tenant1@dd":             true,
+		"2dd":                    false,
+		"DD":                     false,
+		"dd=":                    false,
+		"":                       false,
+		"@dd":                    false,
+		"tenant@2dd":             false,
+		strings.Repeat("a", 257): false,
+	} {
+		assert.Equal(t, want, isValidTracestateKey(key), key)
+	}
+}
+
+func TestW3CExtractLargeTracestate(t *testing.T) {
+	t.Setenv(headerPropagationStyleExtract, "tracecontext")
+	members := make([]string, 40)
+	for i := range members {
+		members[i] = fmt.Sprintf("vendor%d=t61rcWkgMzE", i)
+	}
+	header := "dd=s:2;o:rum;t.dm:-4," + strings.Join(members, ",")
+	carrier := func(state string) TextMapCarrier {
+		return TextMapCarrier(map[string]string{
+			traceparentHeader: "00-00000000000000001111111111111111-2222222222222222-01",
+			tracestateHeader:  state,
+		})
+	}
+
+	t.Run("within-limit", func(t *testing.T) {
+		ctx, err := NewPropagator(nil).Extract(carrier(header))
+		require.NoError(t, err)
+		assert.NotContains(t, ctx.trace.tags, keyPropagationError)
+		assert.Equal(t, "rum", ctx.origin)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		ctx, err := NewPropagator(&PropagatorConfig{MaxTracestateLen: 64}).Extract(carrier(header))
+		require.NoError(t, err)
+		assert.Equal(t, "tracestate_max_size", ctx.trace.tags[keyPropagationError])
+		assert.Equal(t, "rum", ctx.origin)
+		assert.Equal(t, "dd=s:2;o:rum;t.dm:-4,vendor0=t61rcWkgMzE,vendor1=t61rcWkgMzE", ctx.trace.propagatingTag(tracestateHeader))
+	})
+
+	t.Run("truncateTracestate", func(t *testing.T) {
+		assert.Equal(t, "a=1,b=2", truncateTracestate("a=1,b=2,c=3", 7))
+		assert.Equal(t, "a=1,b=2", truncateTracestate("a=1,b=2,c=3", 9))
+		assert.Equal(t, "", truncateTracestate("a=1,b=2", 2))
+		assert.Equal(t, strings.Join(members[:32], ","), truncateTracestate(strings.Join(members, ","), 1<<20))
+	})
 }
 
 func TestW3CExtractsBaggage(t *testing.T) {
@@ -2024,6 +2609,75 @@ func TestW3CExtractsBaggage(t *testing.T) {
 	assert.True(t, found)
 }
 
+// headerPropagator propagates the span ID of a span context in a single header.
+type headerPropagator struct{ header string }
+
+func (p *headerPropagator) Inject(ctx *SpanContext, carrier interface{}) error {
+	w, ok := carrier.(TextMapWriter)
+	if !ok {
+		return ErrInvalidCarrier
+	}
+	w.Set(p.header, strconv.FormatUint(ctx.SpanID(), 10))
+	return nil
+}
+
+func (p *headerPropagator) Extract(carrier interface{}) (*SpanContext, error) {
+	r, ok := carrier.(TextMapReader)
+	if !ok {
+		return nil, ErrInvalidCarrier
+	}
+	var ctx *SpanContext
+	err := r.ForeachKey(func(k, v string) error {
+		if k != p.header {
+			return nil
+		}
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return ErrSpanContextCorrupted
+		}
+		ctx = &SpanContext{traceID: traceIDFrom64Bits(id), spanID: id}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ctx == nil {
+		return nil, ErrSpanContextNotFound
+	}
+	return ctx, nil
+}
+
+func TestRegisterPropagator(t *testing.T) {
+	t.Cleanup(func() {
+		customPropagatorsMu.Lock()
+		customPropagators = nil
+		customPropagatorsMu.Unlock()
+	})
+	RegisterPropagator("MyFormat", func(cfg *PropagatorConfig) Propagator {
+		return &headerPropagator{header: "x-my-" + cfg.TraceHeader}
+	})
+	RegisterPropagator("datadog", func(*PropagatorConfig) Propagator { return &headerPropagator{} })
+	_, ok := registeredPropagator("datadog")
+	assert.False(t, ok)
+
+	t.Setenv(headerPropagationStyle, "datadog,myformat")
+	tracer, err := newTracer()
+	require.NoError(t, err)
+	defer tracer.Stop()
+	assert.Equal(t, "datadog,myformat", tracer.config.propagator.(*chainedPropagator).injectorNames)
+
+	root := tracer.StartSpan("web.request")
+	defer root.Finish()
+	headers := TextMapCarrier{}
+	require.NoError(t, tracer.Inject(root.Context(), headers))
+	assert.Equal(t, strconv.FormatUint(root.Context().SpanID(), 10), headers["x-my-"+DefaultTraceIDHeader])
+	assert.Contains(t, headers, DefaultTraceIDHeader)
+
+	ctx, err := tracer.Extract(TextMapCarrier{"x-my-" + DefaultTraceIDHeader: "42"})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), ctx.SpanID())
+}
+
 func TestNonePropagator(t *testing.T) {
 	t.Run("inject/none", func(t *testing.T) {
 		t.Setenv(headerPropagationStyleInject, "none")
@@ -2318,6 +2972,30 @@ func BenchmarkExtractW3C(b *testing.B) {
 	}
 }
 
+// BenchmarkExtractW3CLargeTracestate shows that the extraction time of the tracecontext
+// propagator is bounded by PropagatorConfig.MaxTracestateLen, whatever the size of the
+// tracestate header.
+func BenchmarkExtractW3CLargeTracestate(b *testing.B) {
+	b.Setenv(headerPropagationStyleExtract, "tracecontext")
+	propagator := NewPropagator(nil)
+	log.SetLevel(log.LevelError)
+	for _, n := range []int{10, 1000, 100000} {
+		members := make([]string, n)
+		for i := range members {
+			members[i] = fmt.Sprintf("vendor%d=t61rcWkgMzE", i)
+		}
+		carrier := TextMapCarrier(map[string]string{
+			traceparentHeader: "00-00000000000000001111111111111111-2222222222222222-01",
+			tracestateHeader:  "dd=s:2;o:rum;t.dm:-4," + strings.Join(members, ","),
+		})
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				propagator.Extract(carrier)
+			}
+		})
+	}
+}
+
 func FuzzMarshalPropagatingTags(f *testing.F) {
 	f.Add("testA", "testB", "testC", "testD", "testG", "testF")
 	f.Fuzz(func(t *testing.T, key1 string, val1 string,
@@ -2329,7 +3007,7 @@ func FuzzMarshalPropagatingTags(f *testing.F) {
 		recvCtx.trace = newTrace()
 
 		pConfig := PropagatorConfig{MaxTagsHeaderLen: 128}
-		propagator := propagator{&pConfig}
+		propagator := propagator{cfg: &pConfig}
 		tags := map[string]string{key1: val1, key2: val2, key3: val3}
 		for key, val := range tags {
 			sendCtx.trace.setPropagatingTag(key, val)
@@ -2390,8 +3068,8 @@ func FuzzComposeTracestate(f *testing.F) {
 		if len(strings.Split(strings.Trim(oldState, " \t"), ",")) > 31 {
 			t.Skipf("Skipping invalid tags")
 		}
-		traceState := composeTracestate(sendCtx, priority, oldState)
-		parseTracestate(recvCtx, traceState)
+		traceState := composeTracestate(sendCtx, DefaultTracestateKey, priority, oldState)
+		parseTracestate(recvCtx, DefaultTracestateKey, traceState)
 		setPropagatingTag(sendCtx, tracestateHeader, traceState)
 		if !reflect.DeepEqual(sendCtx.trace.propagatingTags, recvCtx.trace.propagatingTags) {
 			t.Fatalf(`Inconsistent composing/parsing:
@@ -2545,7 +3223,7 @@ func BenchmarkComposeTracestate(b *testing.B) {
 	ctx.isRemote = false
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		composeTracestate(ctx, 1, "s:-2;o:synthetics___web")
+		composeTracestate(ctx, DefaultTracestateKey, 1, "s:-2;o:synthetics___web")
 	}
 }
 
@@ -2779,6 +3457,140 @@ func TestInjectBaggageMaxBytes(t *testing.T) {
 	assert.LessOrEqual(headerSize, baggageMaxBytes)
 }
 
+func TestMinimalPropagation(t *testing.T) {
+	t.Setenv(headerPropagationStyle, "datadog,tracecontext,baggage")
+	propagator := NewPropagator(&PropagatorConfig{MinimalPropagation: true})
+	sctx, err := propagator.Extract(TextMapCarrier{
+		traceparentHeader: "00-00000000000000aa00000000000000bb-00000000000000cc-01",
+		tracestateHeader:  "dd=s:2;o:rum;p:00000000000000cc;t.usr.id:baz64,othervendor=t61rcWkgMzE",
+		"baggage":         "user.id=alice",
+	})
+	require.NoError(t, err)
+	sctx.trace.setPropagatingTag("_dd.p.team", "checkout")
+
+	carrier := TextMapCarrier{}
+	require.NoError(t, propagator.Inject(sctx, carrier))
+	assert.Equal(t, TextMapCarrier{
+		DefaultTraceIDHeader:  "187",
+		DefaultParentIDHeader: "204",
+		DefaultPriorityHeader: "2",
+		originHeader:          "rum",
+		traceTagsHeader:       "_dd.p.tid=00000000000000aa",
+		traceparentHeader:     "00-00000000000000aa00000000000000bb-00000000000000cc-01",
+		tracestateHeader:      "dd=s:2;o:rum;p:00000000000000cc",
+	}, carrier)
+}
+
+func TestInjectOriginHeaderPolicy(t *testing.T) {
+	t.Setenv(headerPropagationStyleInject, "datadog")
+	withOrigin := &SpanContext{traceID: traceIDFrom64Bits(1), spanID: 2, origin: "synthetics"}
+	withoutOrigin := &SpanContext{traceID: traceIDFrom64Bits(1), spanID: 2}
+
+	for _, tc := range []struct {
+		policy  OriginHeaderPolicy
+		ctx     *SpanContext
+		want    string
+		present bool
+	}{
+		{policy: OriginHeaderWhenSet, ctx: withOrigin, want: "synthetics", present: true},
+		{policy: OriginHeaderWhenSet, ctx: withoutOrigin},
+		{policy: OriginHeaderAlways, ctx: withOrigin, want: "synthetics", present: true},
+		{policy: OriginHeaderAlways, ctx: withoutOrigin, want: "", present: true},
+		{policy: OriginHeaderNever, ctx: withOrigin},
+		{policy: OriginHeaderNever, ctx: withoutOrigin},
+	} {
+		propagator := NewPropagator(&PropagatorConfig{OriginHeader: tc.policy})
+		carrier := TextMapCarrier{}
+		require.NoError(t, propagator.Inject(tc.ctx, carrier))
+		origin, ok := carrier[originHeader]
+		assert.Equal(t, tc.present, ok, "policy %d, origin %q", tc.policy, tc.ctx.origin)
+		assert.Equal(t, tc.want, origin, "policy %d, origin %q", tc.policy, tc.ctx.origin)
+	}
+}
+
+func TestInjectBaggageHashedKeys(t *testing.T) {
+	t.Setenv(headerPropagationStyleInject, "baggage")
+	ctx := &SpanContext{}
+	ctx.setBaggageItem("user.id", "alice")
+	ctx.setBaggageItem("region", "eu")
+
+	t.Run("default", func(t *testing.T) {
+		propagator := NewPropagator(&PropagatorConfig{BaggageHashedKeys: []string{"user.id"}, BaggageHashSalt: "s3cr3t"})
+		carrier := TextMapCarrier{}
+		require.NoError(t, propagator.Inject(ctx, carrier))
+		assert.Contains(t, carrier["baggage"], "region=eu")
+		assert.Contains(t, carrier["baggage"], "user.id="+hashBaggageValue("alice", "s3cr3t"))
+		assert.NotContains(t, carrier["baggage"], "alice")
+		assert.NotEqual(t, hashBaggageValue("alice", "s3cr3t"), hashBaggageValue("alice", "other"))
+		assert.Equal(t, "alice", ctx.baggageItem("user.id"))
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		propagator := NewPropagator(&PropagatorConfig{
+			BaggageHashedKeys: []string{"user.id"},
+			BaggageHashSalt:   "s",
+			BaggageHashFunc:   func(value, salt string) string { return salt + "-" + strings.ToUpper(value) },
+		})
+		carrier := TextMapCarrier{}
+		require.NoError(t, propagator.Inject(ctx, carrier))
+		assert.Contains(t, carrier["baggage"], "user.id=s-ALICE")
+	})
+
+	t.Run("default styles", func(t *testing.T) {
+		t.Setenv(headerPropagationStyleInject, "")
+		propagator := NewPropagator(&PropagatorConfig{BaggageHashedKeys: []string{"user.id"}, BaggageHashSalt: "s3cr3t"})
+		ctx := &SpanContext{traceID: traceIDFrom64Bits(1), spanID: 2, trace: newTrace()}
+		ctx.setBaggageItem("user.id", "alice")
+		carrier := TextMapCarrier{}
+		require.NoError(t, propagator.Inject(ctx, carrier))
+		assert.Equal(t, hashBaggageValue("alice", "s3cr3t"), carrier[DefaultBaggageHeaderPrefix+"user.id"])
+		for k, v := range carrier {
+			assert.NotContains(t, v, "alice", k)
+		}
+	})
+}
+
+func TestInjectBaggageTruncationTag(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		t.Run(strconv.FormatBool(enabled), func(t *testing.T) {
+			propagator := NewPropagator(&PropagatorConfig{BaggageTruncationTag: enabled})
+			tracer, err := newTracer(WithPropagator(propagator))
+			require.NoError(t, err)
+			defer tracer.Stop()
+
+			root := tracer.StartSpan("web.request")
+			for i := 0; i < baggageMaxItems+3; i++ {
+				root.SetBaggageItem("key"+strconv.Itoa(i), "val")
+			}
+			require.NoError(t, tracer.Inject(root.Context(), TextMapCarrier{}))
+
+			if enabled {
+				assert.Equal(t, 3.0, root.metrics[keyBaggageTruncated])
+			} else {
+				assert.NotContains(t, root.metrics, keyBaggageTruncated)
+			}
+		})
+	}
+}
+
+func TestBaggageByteSize(t *testing.T) {
+	tracer, err := newTracer()
+	require.NoError(t, err)
+	defer tracer.Stop()
+
+	root := tracer.StartSpan("web.request")
+	assert.Equal(t, 0, root.Context().BaggageByteSize())
+
+	root.SetBaggageItem("user id", "a b")
+	root.SetBaggageItem("k", "v,w")
+	carrier := TextMapCarrier{}
+	require.NoError(t, tracer.Inject(root.Context(), carrier))
+	assert.Equal(t, len(carrier[DefaultBaggageHeader]), root.Context().BaggageByteSize())
+
+	var nilCtx *SpanContext
+	assert.Equal(t, 0, nilCtx.BaggageByteSize())
+}
+
 func TestExtractBaggagePropagatorMalformedHeader(t *testing.T) {
 	t.Run("missing equal sign", func(t *testing.T) {
 		tracer, err := newTracer()
@@ -2900,6 +3712,51 @@ func TestExtractOnlyBaggage(t *testing.T) {
 	assert.Equal(t, "qux", got["baz"])
 }
 
+func TestExtractBaggageTrustFunc(t *testing.T) {
+	propagator := NewPropagator(&PropagatorConfig{
+		BaggageTrustFunc: func(carrier TextMapReader) bool {
+			trusted := false
+			carrier.ForeachKey(func(k, v string) error {
+				if strings.EqualFold(k, "x-internal-signature") && v == "valid" {
+					trusted = true
+				}
+				return nil
+			})
+			return trusted
+		},
+	})
+
+	for _, tc := range []struct {
+		name      string
+		signature string
+		want      map[string]string
+	}{
+		{name: "trusted", signature: "valid", want: map[string]string{"foo": "bar"}},
+		{name: "untrusted", signature: "forged", want: map[string]string{}},
+		{name: "missing", want: map[string]string{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			headers := TextMapCarrier{
+				DefaultTraceIDHeader:  "4",
+				DefaultParentIDHeader: "1",
+				DefaultBaggageHeader:  "foo=bar",
+			}
+			if tc.signature != "" {
+				headers["x-internal-signature"] = tc.signature
+			}
+			ctx, err := propagator.Extract(headers)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(4), ctx.traceID.Lower())
+			got := make(map[string]string)
+			ctx.ForeachBaggageItem(func(k, v string) bool {
+				got[k] = v
+				return true
+			})
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
 // TestExtractBaggageFirstThenDatadog verifies that when both baggage and trace headers are present,
 // the trace context (trace ID, parent ID, etc.) is extracted from trace headers, and the baggage items are properly inherited,
 // specifically when baggage has a higher precedence than trace headers in the propagation style.
@@ -2935,6 +3792,96 @@ func TestExtractBaggageFirstThenDatadog(t *testing.T) {
 	assert.Equal(t, "xyz", got["item"])
 }
 
+func TestCorrelationIDHeader(t *testing.T) {
+	const header = "X-Correlation-ID"
+	var tid traceID
+	tid.SetUpper(0x6614d0e100000000)
+	tid.SetLower(0x1b7bac51cfca6e3d)
+
+	for format, want := range map[CorrelationIDFormat]string{
+		CorrelationIDHex:     "6614d0e1000000001b7bac51cfca6e3d",
+		CorrelationIDDecimal: "1980365928537943613",
+		CorrelationIDUUID:    "6614d0e1-0000-0000-1b7b-ac51cfca6e3d",
+	} {
+		t.Run(want, func(t *testing.T) {
+			assert.Equal(t, want, format.format(tid))
+			got, ok := format.parse(want)
+			assert.True(t, ok)
+			if format == CorrelationIDDecimal {
+				assert.Equal(t, tid.Lower(), got.Lower())
+			} else {
+				assert.Equal(t, tid, got)
+			}
+			_, ok = format.parse("not-an-id")
+			assert.False(t, ok)
+		})
+	}
+
+	t.Run("inject", func(t *testing.T) {
+		tracer, err := newTracer(WithCorrelationIDHeader(header))
+		require.NoError(t, err)
+		defer tracer.Stop()
+
+		sp := tracer.StartSpan("op")
+		defer sp.Finish()
+		carrier := TextMapCarrier{}
+		require.NoError(t, tracer.Inject(sp.Context(), carrier))
+		assert.Equal(t, sp.Context().TraceID(), carrier[header])
+		assert.NotEmpty(t, carrier[DefaultTraceIDHeader])
+	})
+
+	t.Run("extract", func(t *testing.T) {
+		propagator := NewPropagator(&PropagatorConfig{
+			CorrelationIDHeader:     header,
+			CorrelationIDFormat:     CorrelationIDUUID,
+			CorrelationIDSeedsTrace: true,
+		})
+		ctx, err := propagator.Extract(TextMapCarrier{header: "6614d0e1-0000-0000-1b7b-ac51cfca6e3d"})
+		require.NoError(t, err)
+		assert.Equal(t, tid, ctx.traceID)
+		assert.Zero(t, ctx.spanID)
+
+		// trace context headers take precedence
+		ctx, err = propagator.Extract(TextMapCarrier{
+			header:                "6614d0e1-0000-0000-1b7b-ac51cfca6e3d",
+			DefaultTraceIDHeader:  "1",
+			DefaultParentIDHeader: "2",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), ctx.traceID.Lower())
+
+		_, err = NewPropagator(&PropagatorConfig{CorrelationIDHeader: header}).
+			Extract(TextMapCarrier{header: "6614d0e1-0000-0000-1b7b-ac51cfca6e3d"})
+		assert.Equal(t, ErrSpanContextNotFound, err)
+	})
+}
+
+func TestStartSpanFromBaggageOnlyContext(t *testing.T) {
+	t.Setenv("DD_TRACE_PROPAGATION_STYLE", "datadog,baggage")
+
+	tracer, err := newTracer()
+	require.NoError(t, err)
+	defer tracer.Stop()
+
+	ctx, err := tracer.Extract(TextMapCarrier(map[string]string{"baggage": "item=xyz"}))
+	require.NoError(t, err)
+	require.True(t, ctx.baggageOnly)
+
+	sp := tracer.StartSpan("op", ChildOf(ctx))
+	defer sp.Finish()
+	assert := assert.New(t)
+	assert.Zero(sp.parentID)
+	assert.Equal(sp.spanID, sp.traceID)
+	assert.True(sp.context.traceID.HasUpper())
+	assert.Equal(sp, sp.Root())
+	assert.Equal("xyz", sp.BaggageItem("item"))
+
+	child := tracer.StartSpan("child", ChildOf(sp.Context()))
+	defer child.Finish()
+	assert.Equal("xyz", child.BaggageItem("item"))
+	assert.Equal(sp.traceID, child.traceID)
+}
+
 // TestSpanContextDebugLoggingSecurity verifies that debug logging of span context
 // does not expose sensitive data from baggage or other fields.
 func TestSpanContextDebugLoggingSecurity(t *testing.T) {
@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/sketches-go/ddsketch"
+	"github.com/DataDog/sketches-go/ddsketch/pb/sketchpb"
+	"google.golang.org/protobuf/proto"
+
+	globalinternal "github.com/DataDog/dd-trace-go/v2/internal"
+	"github.com/DataDog/dd-trace-go/v2/internal/log"
+)
+
+// traceMetricsPrefix prefixes the names of the trace metrics emitted by the tracer, see
+// WithTraceMetrics. It differs from the "trace." prefix of the metrics computed by the
+// agent, so that the spans which are kept are not counted twice.
+const traceMetricsPrefix = "client.trace."
+
+// newTraceMetricsStatsdClient returns the statsd client used to emit trace metrics. Unlike
+// the client of the health metrics, it isn't tagged with the service of the tracer, since
+// each metric is tagged with the service of its span.
+func newTraceMetricsStatsdClient(c *config) (globalinternal.StatsdClient, error) {
+	if c.statsdClient != nil {
+		return c.statsdClient, nil
+	}
+	tags := []string{"lang:go", "lang_version:" + runtime.Version()}
+	if c.env != "" {
+		tags = append(tags, "env:"+c.env)
+	}
+	return globalinternal.NewStatsdClient(c.dogstatsdAddr, tags)
+}
+
+// emitTraceMetrics emits the hits and errors counts and the distribution of durations of
+// the spans aggregated in csps by the concentrator, which are the spans the agent computes
+// trace metrics for, such as top level or measured spans. The metrics are shaped like the
+// ones of the agent, named after the operation name of the spans and tagged with their
+// service, type, version and HTTP status code. The resource isn't part of the tags, to
+// keep the cardinality of the metrics low.
+func (c *concentrator) emitTraceMetrics(csps []*pb.ClientStatsPayload) {
+	for _, csp := range csps {
+		for _, bucket := range csp.Stats {
+			for _, gs := range bucket.Stats {
+				tags := make([]string, 0, 4)
+				tags = append(tags, "service:"+gs.Service)
+				if gs.Type != "" {
+					tags = append(tags, "span.type:"+gs.Type)
+				}
+				if csp.Version != "" {
+					tags = append(tags, "version:"+csp.Version)
+				}
+				if gs.HTTPStatusCode != 0 {
+					tags = append(tags, "http.status_code:"+strconv.FormatUint(uint64(gs.HTTPStatusCode), 10))
+				}
+				name := traceMetricsPrefix + traceMetricName(gs.Name)
+				c.traceMetrics.Count(name+".hits", int64(gs.Hits), tags, 1)
+				if gs.Errors > 0 {
+					c.traceMetrics.Count(name+".errors", int64(gs.Errors), tags, 1)
+				}
+				c.emitDurations(name, gs.OkSummary, tags)
+				c.emitDurations(name, gs.ErrorSummary, tags)
+			}
+		}
+	}
+}
+
+// emitDurations emits the durations held by the encoded sketch summary as samples of the
+// distribution name, in seconds. Each bin of the sketch is emitted as a single sample,
+// weighted by the number of durations it holds.
+func (c *concentrator) emitDurations(name string, summary []byte, tags []string) {
+	if len(summary) == 0 {
+		return
+	}
+	var msg sketchpb.DDSketch
+	if err := proto.Unmarshal(summary, &msg); err != nil {
+		log.Debug("Error decoding trace metrics durations: %s", err.Error())
+		return
+	}
+	sketch, err := ddsketch.FromProto(&msg)
+	if err != nil {
+		log.Debug("Error decoding trace metrics durations: %s", err.Error())
+		return
+	}
+	sketch.ForEach(func(value, count float64) bool {
+		c.traceMetrics.DistributionSamples(name, []float64{time.Duration(value).Seconds()}, tags, 1/count)
+		return false
+	})
+}
+
+// traceMetricName returns the operation name op with the characters which aren't allowed
+// in metric names replaced with underscores.
+func traceMetricName(op string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, op)
+}
@@ -8,13 +8,18 @@ package tracer
 import (
 	gocontext "context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"math"
+	"net/url"
 	"os"
 	"runtime/pprof"
 	rt "runtime/trace"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -27,6 +32,8 @@ import (
 	"github.com/DataDog/dd-trace-go/v2/internal/datastreams"
 	"github.com/DataDog/dd-trace-go/v2/internal/globalconfig"
 	"github.com/DataDog/dd-trace-go/v2/internal/log"
+	"github.com/DataDog/dd-trace-go/v2/internal/obfuscation"
+	"github.com/DataDog/dd-trace-go/v2/internal/processtags"
 	"github.com/DataDog/dd-trace-go/v2/internal/remoteconfig"
 	"github.com/DataDog/dd-trace-go/v2/internal/samplernames"
 	"github.com/DataDog/dd-trace-go/v2/internal/telemetry"
@@ -39,18 +46,20 @@ import (
 )
 
 type TracerConf struct { //nolint:revive
-	CanComputeStats      bool
-	CanDropP0s           bool
-	DebugAbandonedSpans  bool
-	Disabled             bool
-	PartialFlush         bool
-	PartialFlushMinSpans int
-	PeerServiceDefaults  bool
-	PeerServiceMappings  map[string]string
-	EnvTag               string
-	VersionTag           string
-	ServiceTag           string
-	TracingAsTransport   bool
+	CanComputeStats       bool
+	CanDropP0s            bool
+	DebugAbandonedSpans   bool
+	Disabled              bool
+	PartialFlush          bool
+	PartialFlushMinSpans  int
+	CompleteTraceSampling bool
+	MaxTraceDuration      time.Duration
+	PeerServiceDefaults   bool
+	PeerServiceMappings   map[string]string
+	EnvTag                string
+	VersionTag            string
+	ServiceTag            string
+	TracingAsTransport    bool
 }
 
 // Tracer specifies an implementation of the Datadog tracer which allows starting
@@ -146,6 +155,10 @@ type tracer struct {
 	// statsd is used for tracking metrics associated with the runtime and the tracer.
 	statsd globalinternal.StatsdClient
 
+	// traceMetrics is used to emit trace metrics client-side. It is nil unless enabled,
+	// see WithTraceMetrics.
+	traceMetrics globalinternal.StatsdClient
+
 	// dataStreams processes data streams monitoring information
 	dataStreams *datastreams.Processor
 
@@ -153,6 +166,9 @@ type tracer struct {
 	// when abandoned spans debugging is enabled.
 	abandonedSpansDebugger *abandonedSpansDebugger
 
+	// longRunning sends snapshots of the long-running spans, when WithLongRunningSpans is set.
+	longRunning *longRunningTracker
+
 	// logFile contains a pointer to the file for writing tracer logs along with helper functionality for closing the file
 	// logFile is closed when tracer stops
 	// by default, tracer logs to stderr and this setting is unused
@@ -200,6 +216,8 @@ func Start(opts ...StartOption) error {
 		return nil
 	}
 	setGlobalTracer(t)
+	// share the obfuscator with the integrations, so that they obfuscate queries the same way
+	obfuscation.SetObfuscator(t.obfuscator)
 	if t.config.logStartup {
 		logStartup(t)
 	}
@@ -328,6 +346,13 @@ func newUnstartedTracer(opts ...StartOption) (*tracer, error) {
 	var writer traceWriter
 	if c.ciVisibilityEnabled {
 		writer = newCiVisibilityTraceWriter(c)
+	} else if c.dryRun {
+		log.Info("Dry run mode enabled, traces won't be sent.")
+		writer = newDryRunTraceWriter(c, sampler, statsd)
+	} else if c.exporter != nil {
+		writer = newExporterTraceWriter(c.exporter, statsd)
+	} else if c.otlpEndpoint != "" {
+		writer = newOTLPTraceWriter(c, statsd)
 	} else if c.logToStdout {
 		writer = newLogTraceWriter(c, statsd)
 	} else {
@@ -358,7 +383,12 @@ func newUnstartedTracer(opts ...StartOption) (*tracer, error) {
 		rulesSampler.traces.setTraceSampleRules, EqualsFalseNegative)
 	var dataStreamsProcessor *datastreams.Processor
 	if c.dataStreamsMonitoringEnabled {
-		dataStreamsProcessor = datastreams.NewProcessor(statsd, c.env, c.serviceName, c.version, c.agentURL, c.httpClient)
+		dataStreamsProcessor = datastreams.NewProcessor(statsd, c.env, c.serviceName, c.version, c.agentURL, c.httpClient,
+			datastreams.WithEndpointPath(c.dataStreamsEndpointPath),
+			datastreams.WithHeaders(c.dataStreamsHeaders),
+			datastreams.WithCompression(!c.dataStreamsCompressionDisabled),
+		)
+		dataStreamsProcessor.SetIdleTimeout(c.idleTimeout)
 	}
 	var logFile *log.ManagedFile
 	if v := c.logDirectory; v != "" {
@@ -371,7 +401,7 @@ func newUnstartedTracer(opts ...StartOption) (*tracer, error) {
 	t := &tracer{
 		config:           c,
 		traceWriter:      writer,
-		out:              make(chan *chunk, payloadQueueSize),
+		out:              make(chan *chunk, c.traceBufferSize),
 		stop:             make(chan struct{}),
 		flush:            make(chan chan<- struct{}),
 		rulesSampling:    rulesSampler,
@@ -381,17 +411,17 @@ func newUnstartedTracer(opts ...StartOption) (*tracer, error) {
 		stats:            newConcentrator(c, defaultStatsBucketSize, statsd),
 		spansStarted:     *globalinternal.NewXSyncMapCounterMap(),
 		spansFinished:    *globalinternal.NewXSyncMapCounterMap(),
-		obfuscator: obfuscate.NewObfuscator(obfuscate.Config{
-			SQL: obfuscate.SQLConfig{
-				TableNames:       c.agent.HasFlag("table_names"),
-				ReplaceDigits:    c.agent.HasFlag("quantize_sql_tables") || c.agent.HasFlag("replace_sql_digits"),
-				KeepSQLAlias:     c.agent.HasFlag("keep_sql_alias"),
-				DollarQuotedFunc: c.agent.HasFlag("dollar_quoted_func"),
-			},
-		}),
-		statsd:      statsd,
-		dataStreams: dataStreamsProcessor,
-		logFile:     logFile,
+		obfuscator:       obfuscate.NewObfuscator(obfuscation.Config(c.agent.HasFlag)),
+		statsd:           statsd,
+		dataStreams:      dataStreamsProcessor,
+		logFile:          logFile,
+	}
+	if c.traceMetrics {
+		if t.traceMetrics, err = newTraceMetricsStatsdClient(c); err != nil {
+			log.Error("Trace metrics disabled: could not initialize statsd client: %s", err.Error())
+			t.traceMetrics = nil
+		}
+		t.stats.traceMetrics = t.traceMetrics
 	}
 	return t, nil
 }
@@ -445,6 +475,14 @@ func newTracer(opts ...StartOption) (*tracer, error) {
 		defer t.wg.Done()
 		t.reportHealthMetricsAtInterval(statsInterval)
 	}()
+	if c.longRunningInterval > 0 {
+		t.longRunning = newLongRunningTracker(c.longRunningInterval)
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+			t.longRunning.run(t)
+		}()
+	}
 	t.stats.Start()
 	return t, nil
 }
@@ -465,6 +503,129 @@ func Flush() {
 	}
 }
 
+// FlushTrace flushes the finished spans of the local trace which the span found in
+// ctx belongs to, without waiting for the rest of the trace to finish, and then waits
+// for the tracer to flush, like Flush does. Spans of that trace which are still open
+// stay buffered and are sent once they finish. It is a no-op if ctx doesn't hold a
+// span or if the tracer is not started.
+//
+// FlushTrace is useful in tests or debug endpoints which need a just-finished request
+// to be queryable straight away.
+func FlushTrace(ctx gocontext.Context) {
+	s, ok := SpanFromContext(ctx)
+	if !ok {
+		return
+	}
+	t, ok := getGlobalTracer().(*tracer)
+	if !ok {
+		return
+	}
+	t.flushTrace(s)
+}
+
+// AgentEndpoint returns the URL of the agent the running tracer sends its data to, as
+// resolved from WithAgentAddr, WithAgentURL, WithUDS, DD_TRACE_AGENT_URL, DD_AGENT_HOST,
+// DD_TRACE_AGENT_PORT or the default socket, e.g. "http://localhost:8126" or
+// "unix:///var/run/datadog/apm.socket". It is useful in health endpoints, to find out which
+// agent a containerized application talks to. It returns an empty string if the tracer is
+// not started.
+func AgentEndpoint() string {
+	t, ok := getGlobalTracer().(*tracer)
+	if !ok || t.config.originalAgentURL == nil {
+		return ""
+	}
+	return t.config.originalAgentURL.String()
+}
+
+// Reconfigure applies opts to the running tracer without restarting it, unlike a Stop/Start
+// cycle which loses the spans buffered by the tracer. Only the following options are
+// applied, the others are ignored:
+//
+//   - WithSamplingRules replaces the trace sampling rules. Span sampling rules can't be changed.
+//   - WithGlobalTag adds or replaces a global tag, set on the spans started afterwards.
+//   - WithAgentAddr and WithAgentURL change the agent which traces and stats are sent to. It
+//     isn't supported with Unix domain sockets, so WithUDS and unix:// URLs are ignored, and
+//     the other products, such as remote configuration, keep using the agent the tracer was
+//     started with, as does AgentEndpoint.
+//   - WithDebugMode enables or disables debug logging. Disabling it restores the log level in
+//     effect before it was enabled.
+//
+// It returns an error if the tracer is not started.
+func Reconfigure(opts ...StartOption) error {
+	t, ok := getGlobalTracer().(*tracer)
+	if !ok {
+		return errors.New("the tracer is not started")
+	}
+	t.reconfigure(opts...)
+	return nil
+}
+
+func (t *tracer) reconfigure(opts ...StartOption) {
+	// the options are applied to a scratch configuration holding the current values,
+	// whose changes are then applied to the running tracer.
+	debug := log.GetLevel() == log.LevelDebug
+	c := &config{debug: debug}
+	c.initGlobalTags(maps.Clone(t.config.globalTags.get()), telemetry.OriginCode)
+	for _, fn := range opts {
+		fn(c)
+	}
+
+	var telemConfigs []telemetry.Configuration
+	if len(c.spanRules) > 0 {
+		log.Warn("ignoring %d span sampling rule(s): they can't be changed while the tracer is running", len(c.spanRules))
+	}
+	if c.traceRules != nil && t.config.traceSampleRules.update(c.traceRules, telemetry.OriginCode) {
+		telemConfigs = append(telemConfigs, t.config.traceSampleRules.toTelemetry())
+	}
+	if t.config.globalTags.update(c.globalTags.get(), telemetry.OriginCode) {
+		telemConfigs = append(telemConfigs, t.config.globalTags.toTelemetry())
+	}
+	if c.agentURL != nil {
+		t.setAgentURL(c.agentURL)
+	}
+	if c.debug != debug {
+		if c.debug {
+			t.config.logLevel = log.GetLevel()
+			log.SetLevel(log.LevelDebug)
+		} else if t.config.logLevel != log.LevelDebug {
+			log.SetLevel(t.config.logLevel)
+		} else {
+			// debug mode was already on before the tracer enabled it, e.g. left on by a
+			// previous tracer, so there is no level to restore.
+			log.SetLevel(log.LevelWarn)
+		}
+	}
+	if len(telemConfigs) > 0 {
+		telemetry.RegisterAppConfigs(telemConfigs...)
+	}
+}
+
+// setAgentURL makes the tracer send its traces and stats to the agent at u.
+func (t *tracer) setAgentURL(u *url.URL) {
+	// the URLs given with WithUDS have the unix scheme, the others are resolved to UDS_ hosts
+	isUDS := func(u *url.URL) bool { return u != nil && (u.Scheme == "unix" || strings.HasPrefix(u.Host, "UDS_")) }
+	if isUDS(u) || isUDS(t.config.agentURL) {
+		log.Warn("ignoring agent URL %s: Unix domain sockets can't be changed while the tracer is running", u.Redacted())
+		return
+	}
+	transport, ok := t.config.transport.(*httpTransport)
+	if !ok {
+		log.Warn("ignoring agent URL %s: the transport of the tracer can't be changed", u.Redacted())
+		return
+	}
+	transport.setURL(u.String())
+	log.Info("Sending traces to the agent at %s.", u.Redacted())
+}
+
+// flushTrace flushes the finished spans of the trace s belongs to and waits for them
+// to be sent.
+func (t *tracer) flushTrace(s *Span) {
+	if s.context != nil && s.context.trace != nil {
+		s.context.trace.flushFinished(t)
+	}
+	t.Flush()
+}
+
 // Flush triggers a flush and waits for it to complete.
 func (t *tracer) Flush() {
 	done := make(chan struct{})
@@ -477,45 +638,73 @@ func (t *tracer) Flush() {
 
 // worker receives finished traces to be added into the payload, as well
 // as periodically flushes traces to the transport.
+//
+// When an idle timeout is configured, the worker stops listening to tick once no trace
+// was received for that long, so that an idle process isn't woken up for nothing. It
+// resumes with the next trace; nothing is held back since the last tick flushed it all.
 func (t *tracer) worker(tick <-chan time.Time) {
+	var (
+		ticks      = tick // nil while parked
+		lastActive = time.Now()
+	)
 	for {
 		select {
 		case trace := <-t.out:
+			if ticks == nil {
+				log.Debug("Tracer active again, resuming scheduled flushes.")
+				ticks = tick
+			}
+			if t.config.idleTimeout > 0 {
+				lastActive = time.Now()
+			}
 			t.sampleChunk(trace)
 			if len(trace.spans) > 0 {
 				t.traceWriter.add(trace.spans)
 			}
-		case <-tick:
+		case <-ticks:
 			t.statsd.Incr("datadog.tracer.flush_triggered", []string{"reason:scheduled"}, 1)
 			t.traceWriter.flush()
+			if d := t.config.idleTimeout; d > 0 && time.Since(lastActive) >= d {
+				log.Debug("Tracer idle for %s, parking scheduled flushes until the next trace.", d)
+				ticks = nil
+			}
 
 		case done := <-t.flush:
 			t.statsd.Incr("datadog.tracer.flush_triggered", []string{"reason:invoked"}, 1)
+			// make sure the chunks which were already submitted are part of the flush
+			t.drainOut()
 			t.traceWriter.flush()
 			t.statsd.Flush()
 			if !t.config.tracingAsTransport {
 				t.stats.flushAndSend(time.Now(), withCurrentBucket)
 			}
+			if t.traceMetrics != nil {
+				t.traceMetrics.Flush()
+			}
 			// TODO(x): In reality, the traceWriter.flush() call is not synchronous
 			// when using the agent traceWriter. However, this functionality is used
 			// in Lambda so for that purpose this mechanism should suffice.
 			done <- struct{}{}
 
 		case <-t.stop:
-		loop:
-			// the loop ensures that the payload channel is fully drained
-			// before the final flush to ensure no traces are lost (see #526)
-			for {
-				select {
-				case trace := <-t.out:
-					t.sampleChunk(trace)
-					if len(trace.spans) > 0 {
-						t.traceWriter.add(trace.spans)
-					}
-				default:
-					break loop
-				}
+			// the payload channel is fully drained before the final flush
+			// to ensure no traces are lost (see #526)
+			t.drainOut()
+			return
+		}
+	}
+}
+
+// drainOut adds all the chunks pending in the payload channel to the trace writer.
+func (t *tracer) drainOut() {
+	for {
+		select {
+		case trace := <-t.out:
+			t.sampleChunk(trace)
+			if len(trace.spans) > 0 {
+				t.traceWriter.add(trace.spans)
 			}
+		default:
 			return
 		}
 	}
@@ -561,6 +750,25 @@ func (t *tracer) sampleChunk(c *chunk) {
 	}
 }
 
+// pushChunkBlocking waits for room in the payload queue to push the chunk, for as long
+// as the buffer full policy allows it. It reports whether the chunk was pushed.
+func (t *tracer) pushChunkBlocking(trace *chunk) bool {
+	timeout := t.config.bufferFullPolicy.timeout
+	if timeout <= 0 {
+		return false
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case t.out <- trace:
+		return true
+	case <-timer.C:
+		return false
+	case <-t.stop:
+		return false
+	}
+}
+
 func (t *tracer) pushChunk(trace *chunk) {
 	tracerstats.Signal(tracerstats.SpansFinished, uint32(len(trace.spans)))
 	select {
@@ -571,8 +779,11 @@ func (t *tracer) pushChunk(trace *chunk) {
 	select {
 	case t.out <- trace:
 	default:
-		log.Debug("payload queue full, trace dropped %d spans", len(trace.spans))
-		atomic.AddUint32(&t.totalTracesDropped, 1)
+		if !t.pushChunkBlocking(trace) {
+			log.Debug("payload queue full, trace dropped %d spans", len(trace.spans))
+			atomic.AddUint32(&t.totalTracesDropped, 1)
+			t.statsd.Incr("datadog.tracer.traces_dropped", []string{"reason:buffer_full"}, 1)
+		}
 	}
 	select {
 	case <-t.logDroppedTraces.C:
@@ -665,6 +876,17 @@ func spanStart(operationName string, options ...StartSpanOption) *Span {
 
 	}
 	span.context = newSpanContext(span, context)
+	if opts.NoBaggageInheritance {
+		span.context.clearBaggage()
+	}
+	if opts.Origin != "" && (context == nil || context.baggageOnly) {
+		// the span starts a new trace
+		span.setOrigin(opts.Origin)
+	}
+	if opts.AlwaysKeep {
+		span.setSamplingPriority(ext.PriorityUserKeep, samplernames.Manual)
+		span.context.trace.forceDecision(true)
+	}
 	span.setMeta("language", "go")
 	// add tags from options
 	for k, v := range opts.Tags {
@@ -685,12 +907,40 @@ func spanStart(operationName string, options ...StartSpanOption) *Span {
 	return span
 }
 
+// setBaggageTags copies the baggage items of span with the given keys as span tags,
+// or all of them if keys holds "*".
+func setBaggageTags(span *Span, keys []string) {
+	if slices.Contains(keys, "*") {
+		span.context.ForeachBaggageItem(func(k, v string) bool {
+			span.setMeta("baggage."+k, v)
+			return true
+		})
+		return
+	}
+	for _, k := range keys {
+		if v := span.context.baggageItem(k); v != "" {
+			span.setMeta("baggage."+k, v)
+		}
+	}
+}
+
+// setProcessTags copies the process tags with the given keys as span tags.
+func setProcessTags(span *Span, keys []string) {
+	pTags := processtags.GlobalTags()
+	for _, k := range keys {
+		if v, ok := pTags.Get(k); ok {
+			span.setMeta(k, v)
+		}
+	}
+}
+
 // StartSpan creates, starts, and returns a new Span with the given `operationName`.
 func (t *tracer) StartSpan(operationName string, options ...StartSpanOption) *Span {
 	if !t.config.enabled.current {
 		return nil
 	}
 	span := spanStart(operationName, options...)
+	span.capLinks(t.config.maxSpanLinks)
 	if span.service == "" {
 		span.service = t.config.serviceName
 	}
@@ -717,6 +967,20 @@ func (t *tracer) StartSpan(operationName string, options ...StartSpanOption) *Sp
 	if t.config.env != "" {
 		span.setMeta(ext.Environment, t.config.env)
 	}
+	if t.config.origin != "" && span.parentID == 0 && span.context.origin == "" {
+		// the span starts a new trace and wasn't given an origin
+		span.setOrigin(t.config.origin)
+	}
+	if len(t.config.baggageTagKeys) > 0 && span.context.trace.root == span {
+		// a local root span only carries baggage when continuing an extracted context
+		setBaggageTags(span, t.config.baggageTagKeys)
+	}
+	if len(t.config.processTagKeys) > 0 && span.context.trace.root == span {
+		setProcessTags(span, t.config.processTagKeys)
+	}
+	if t.longRunning != nil && span.context.trace.root == span {
+		t.longRunning.add(span.context.trace)
+	}
 	if _, ok := span.context.SamplingPriority(); !ok {
 		// if not already sampled or a brand new trace, sample it
 		t.sample(span)
@@ -750,6 +1014,9 @@ func (t *tracer) StartSpan(operationName string, options ...StartSpanOption) *Sp
 	}
 	span.setMetric(ext.Pid, float64(t.pid))
 	t.spansStarted.Inc(span.integration)
+	for _, p := range t.config.spanProcessors {
+		p.OnStart(span)
+	}
 
 	return span
 }
@@ -811,6 +1078,9 @@ func (t *tracer) Stop() {
 	t.wg.Wait()
 	t.traceWriter.stop()
 	t.statsd.Close()
+	if t.traceMetrics != nil && t.traceMetrics != t.statsd {
+		t.traceMetrics.Close()
+	}
 	if t.dataStreams != nil {
 		t.dataStreams.Stop()
 	}
@@ -859,6 +1129,14 @@ func (t *tracer) updateSampling(ctx *SpanContext) {
 		return
 	}
 
+	// a decision forced through the context is propagated as is and can't change anymore,
+	// see WithForceKeep
+	if p, ok := ctx.trace.forcedPriority(); ok {
+		ctx.trace.root.setSamplingPriority(p, samplernames.Manual)
+		ctx.trace.setLocked(true)
+		return
+	}
+
 	// the span was sampled with ManualKeep rules shouldn't override
 	if ctx.trace.propagatingTag(keyDecisionMaker) == "-4" {
 		return
@@ -887,18 +1165,20 @@ func (t *tracer) Extract(carrier interface{}) (*SpanContext, error) {
 
 func (t *tracer) TracerConf() TracerConf {
 	return TracerConf{
-		CanComputeStats:      t.config.canComputeStats(),
-		CanDropP0s:           t.config.canDropP0s(),
-		DebugAbandonedSpans:  t.config.debugAbandonedSpans,
-		Disabled:             !t.config.enabled.current,
-		PartialFlush:         t.config.partialFlushEnabled,
-		PartialFlushMinSpans: t.config.partialFlushMinSpans,
-		PeerServiceDefaults:  t.config.peerServiceDefaultsEnabled,
-		PeerServiceMappings:  t.config.peerServiceMappings,
-		EnvTag:               t.config.env,
-		VersionTag:           t.config.version,
-		ServiceTag:           t.config.serviceName,
-		TracingAsTransport:   t.config.tracingAsTransport,
+		CanComputeStats:       t.config.canComputeStats(),
+		CanDropP0s:            t.config.canDropP0s(),
+		DebugAbandonedSpans:   t.config.debugAbandonedSpans,
+		Disabled:              !t.config.enabled.current,
+		PartialFlush:          t.config.partialFlushEnabled,
+		PartialFlushMinSpans:  t.config.partialFlushMinSpans,
+		CompleteTraceSampling: t.config.completeTraceSampling,
+		MaxTraceDuration:      t.config.maxTraceDuration,
+		PeerServiceDefaults:   t.config.peerServiceDefaultsEnabled,
+		PeerServiceMappings:   t.config.peerServiceMappings,
+		EnvTag:                t.config.env,
+		VersionTag:            t.config.version,
+		ServiceTag:            t.config.serviceName,
+		TracingAsTransport:    t.config.tracingAsTransport,
 	}
 }
 
@@ -907,7 +1187,7 @@ func (t *tracer) submit(s *Span) {
 		return
 	}
 	// we have an active tracer
-	if !t.config.canDropP0s() {
+	if !t.config.canDropP0s() && t.traceMetrics == nil {
 		return
 	}
 	statSpan, shouldCalc := t.stats.newTracerStatSpan(s, t.obfuscator)
@@ -932,6 +1212,17 @@ func (t *tracer) submitAbandonedSpan(s *Span, finished bool) {
 	}
 }
 
+// ignoresResource reports whether the traces with a root span having the given
+// resource must be dropped. See WithIgnoreResources.
+func (t *tracer) ignoresResource(resource string) bool {
+	for _, re := range t.config.ignoreResources {
+		if re.MatchString(resource) {
+			return true
+		}
+	}
+	return false
+}
+
 func (t *tracer) submitChunk(c *chunk) {
 	t.pushChunk(c)
 }
@@ -945,15 +1236,28 @@ func (t *tracer) sample(span *Span) {
 		// sampling decision was already made
 		return
 	}
+	if keep, ok := span.context.otelSampled(); ok {
+		// follow the probability sampling of the upstream OpenTelemetry service,
+		// so that the decision is consistent across the trace
+		priority := ext.PriorityAutoKeep
+		if !keep {
+			span.context.trace.drop()
+			priority = ext.PriorityAutoReject
+		}
+		span.context.trace.setSamplingPriority(priority, samplernames.Default)
+		return
+	}
 	sampler := t.config.sampler
+	if sampler.Rate() < 1 {
+		// record the rate whether the span is kept or not, so that the decision
+		// can be accounted for later on
+		span.setMetric(sampleRateMetricKey, sampler.Rate())
+	}
 	if !sampler.Sample(span) {
 		span.context.trace.drop()
 		span.context.trace.setSamplingPriority(ext.PriorityAutoReject, samplernames.RuleRate)
 		return
 	}
-	if sampler.Rate() < 1 {
-		span.setMetric(sampleRateMetricKey, sampler.Rate())
-	}
 	if t.rulesSampling.SampleTraceGlobalRate(span) {
 		return
 	}
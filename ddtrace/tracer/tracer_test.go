@@ -24,6 +24,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -33,6 +34,8 @@ import (
 	"github.com/DataDog/dd-trace-go/v2/internal"
 	"github.com/DataDog/dd-trace-go/v2/internal/globalconfig"
 	"github.com/DataDog/dd-trace-go/v2/internal/log"
+	"github.com/DataDog/dd-trace-go/v2/internal/obfuscation"
+	"github.com/DataDog/dd-trace-go/v2/internal/processtags"
 	"github.com/DataDog/dd-trace-go/v2/internal/statsdtest"
 
 	"github.com/DataDog/datadog-go/v5/statsd"
@@ -887,6 +890,310 @@ func TestTracerBaggagePropagation(t *testing.T) {
 	assert.Equal("value", context.baggage["key"])
 }
 
+func TestTracerWithoutBaggageInheritance(t *testing.T) {
+	tracer, err := newTracer()
+	require.NoError(t, err)
+	defer tracer.Stop()
+
+	root := tracer.StartSpan("web.request")
+	root.SetBaggageItem("user.id", "42")
+	child := tracer.StartSpan("payments.charge", ChildOf(root.Context()), WithoutBaggageInheritance())
+	assert.Equal(t, root.Context().TraceID(), child.Context().TraceID())
+	assert.Equal(t, root.spanID, child.parentID)
+	assert.Empty(t, child.BaggageItem("user.id"))
+	assert.Equal(t, "42", root.BaggageItem("user.id"))
+
+	headers := TextMapCarrier{}
+	require.NoError(t, tracer.Inject(child.Context(), headers))
+	assert.NotContains(t, headers, DefaultBaggageHeaderPrefix+"user.id")
+	assert.NotContains(t, headers, DefaultBaggageHeader)
+
+	// baggage set below the boundary is inherited as usual
+	child.SetBaggageItem("charge.id", "7")
+	grandchild := tracer.StartSpan("db.query", ChildOf(child.Context()))
+	assert.Equal(t, "7", grandchild.BaggageItem("charge.id"))
+	assert.Empty(t, grandchild.BaggageItem("user.id"))
+}
+
+func TestTracerOrigin(t *testing.T) {
+	tracer, err := newTracer(WithDefaultOrigin("synthetics;monitor=1"))
+	require.NoError(t, err)
+	defer tracer.Stop()
+
+	root := tracer.StartSpan("web.request")
+	assert.Equal(t, "synthetics_monitor=1", root.meta[keyOrigin])
+	child := tracer.StartSpan("db.query", ChildOf(root.Context()))
+	assert.Equal(t, "synthetics_monitor=1", child.context.origin)
+
+	headers := TextMapCarrier{}
+	require.NoError(t, tracer.Inject(child.Context(), headers))
+	assert.Equal(t, "synthetics_monitor=1", headers[originHeader])
+
+	override := tracer.StartSpan("web.request", WithOrigin("canary"))
+	assert.Equal(t, "canary", override.meta[keyOrigin])
+	assert.Equal(t, "canary", override.context.origin)
+
+	// traces continued from an extracted context keep their origin
+	sctx, err := tracer.Extract(TextMapCarrier{
+		DefaultTraceIDHeader:  "1",
+		DefaultParentIDHeader: "2",
+		originHeader:          "rum",
+	})
+	require.NoError(t, err)
+	remote := tracer.StartSpan("web.request", ChildOf(sctx), WithOrigin("canary"))
+	assert.Equal(t, "rum", remote.meta[keyOrigin])
+	assert.Equal(t, "rum", remote.context.origin)
+}
+
+func TestTracerAlwaysKeep(t *testing.T) {
+	tracer, transport, flush, stop, err := startTestTracer(t, WithSamplingRules(TraceSamplingRules(Rule{Rate: 0})))
+	require.NoError(t, err)
+	defer stop()
+
+	root := tracer.StartSpan("audit.event", WithAlwaysKeep())
+	p, ok := root.Context().SamplingPriority()
+	assert.True(t, ok)
+	assert.Equal(t, ext.PriorityUserKeep, p)
+	root.Finish()
+
+	// the always-kept child overrides the decision of the sampling rules for the trace
+	parent := tracer.StartSpan("web.request")
+	p, _ = parent.Context().SamplingPriority()
+	assert.Equal(t, ext.PriorityUserReject, p)
+	child := tracer.StartSpan("audit.event", ChildOf(parent.Context()), WithAlwaysKeep())
+	child.Finish()
+	parent.Finish()
+	flush(2)
+
+	assert.Len(t, transport.Traces(), 2)
+	assert.Equal(t, float64(ext.PriorityUserKeep), root.metrics[keySamplingPriority])
+	assert.Equal(t, float64(ext.PriorityUserKeep), parent.metrics[keySamplingPriority])
+	assert.Equal(t, "-4", parent.context.trace.propagatingTag(keyDecisionMaker))
+}
+
+func TestTracerDryRun(t *testing.T) {
+	var tg statsdtest.TestStatsdClient
+	transport := newDummyTransport()
+	tracer, err := newTracer(withTransport(transport), withStatsdClient(&tg), WithDryRun(true),
+		WithSamplingRules(TraceSamplingRules(Rule{ServiceGlob: "dropped", Rate: 0})))
+	require.NoError(t, err)
+	setGlobalTracer(tracer)
+	defer func() {
+		setGlobalTracer(&NoopTracer{})
+		tracer.Stop()
+	}()
+	assert.False(t, tracer.config.canComputeStats())
+
+	root := tracer.StartSpan("web.request")
+	carrier := TextMapCarrier{}
+	require.NoError(t, tracer.Inject(root.Context(), carrier))
+	assert.Equal(t, strconv.FormatUint(root.traceID, 10), carrier[DefaultTraceIDHeader])
+	root.Finish()
+	tracer.StartSpan("web.request", ServiceName("dropped")).Finish()
+	tracer.Flush()
+
+	assert.Equal(t, 0, transport.Len())
+	var tags []string
+	for _, c := range tg.GetCallsByName("datadog.tracer.dry_run.traces") {
+		tags = append(tags, c.Tags()...)
+	}
+	assert.ElementsMatch(t, []string{"sampling_priority:1", "sampling_priority:-1"}, tags)
+}
+
+func TestTracerDryRunAgentFeatures(t *testing.T) {
+	var traces, emptyPayloads, stats atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/info":
+			w.Write([]byte(`{"endpoints":["/v0.4/traces","/v0.6/stats"],"client_drop_p0s":true}`))
+		case "/v0.6/stats":
+			stats.Add(1)
+		default:
+			if r.Header.Get(traceCountHeader) == "0" {
+				emptyPayloads.Add(1)
+			} else {
+				traces.Add(1)
+			}
+			w.Write([]byte(`{"rate_by_service":{"service:tracer.test,env:":0.5}}`))
+		}
+	}))
+	defer srv.Close()
+	var tg statsdtest.TestStatsdClient
+	tracer, err := newTracer(WithAgentAddr(strings.TrimPrefix(srv.URL, "http://")), withStatsdClient(&tg),
+		WithDryRun(true), WithStatsComputation(true), WithService("tracer.test"))
+	require.NoError(t, err)
+	setGlobalTracer(tracer)
+	defer setGlobalTracer(&NoopTracer{})
+	// the features of the agent are used
+	assert.True(t, tracer.config.canDropP0s())
+
+	tracer.StartSpan("web.request").Finish()
+	tracer.Flush()
+	tracer.Stop()
+
+	assert.Zero(t, traces.Load())
+	assert.Zero(t, stats.Load())
+	assert.NotZero(t, emptyPayloads.Load())
+	// the sampling rates of the agent are read
+	assert.Equal(t, 0.5, tracer.prioritySampling.getRate(&Span{service: "tracer.test"}))
+}
+
+func TestTracerSharesObfuscator(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/info" {
+			w.Write([]byte(`{"endpoints":["/v0.4/traces"],"feature_flags":["replace_sql_digits"]}`))
+		}
+	}))
+	defer srv.Close()
+	t.Cleanup(func() { obfuscation.SetObfuscator(nil) })
+	require.NoError(t, Start(WithAgentAddr(strings.TrimPrefix(srv.URL, "http://")), WithLogStartup(false)))
+	defer Stop()
+
+	assert.Same(t, getGlobalTracer().(*tracer).obfuscator, obfuscation.Obfuscator())
+	// the integrations obfuscate queries with the features of the agent
+	oq, err := obfuscation.ObfuscateSQL("SELECT * FROM orders_2024 WHERE id = 42")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM orders_? WHERE id = ?", oq)
+}
+
+func TestTracerLongRunningSpans(t *testing.T) {
+	tracer, transport, flush, stop, err := startTestTracer(t, WithLongRunningSpans(time.Hour))
+	require.NoError(t, err)
+	defer stop()
+
+	root := tracer.StartSpan("batch.job", StartTime(time.Now().Add(-2*time.Hour)))
+	step := tracer.StartSpan("batch.step", ChildOf(root.Context()))
+	tracer.StartSpan("batch.step", ChildOf(root.Context())).Finish()
+	// the trace hasn't been running for long enough to be snapshot
+	recent := tracer.StartSpan("web.request")
+
+	for version := 1; version <= 2; version++ {
+		tracer.longRunning.snapshot(tracer, now())
+		flush(1)
+		traces := transport.Traces()
+		require.Len(t, traces, 1)
+		spans := traces[0]
+		// only the open spans are part of the snapshot
+		require.Len(t, spans, 2)
+		assert.Equal(t, root.spanID, spans[0].spanID)
+		assert.Equal(t, step.spanID, spans[1].spanID)
+		assert.Equal(t, float64(version), spans[0].metrics[keyPartialVersion])
+		assert.Equal(t, float64(ext.PriorityAutoKeep), spans[0].metrics[keySamplingPriority])
+		assert.GreaterOrEqual(t, spans[0].duration, int64(2*time.Hour))
+	}
+
+	step.Finish()
+	root.Finish()
+	recent.Finish()
+	flush(2)
+	assert.Equal(t, float64(1), root.metrics[keyWasLongRunning])
+	assert.Equal(t, float64(1), step.metrics[keyWasLongRunning])
+	assert.NotContains(t, root.metrics, keyPartialVersion)
+	assert.NotContains(t, recent.metrics, keyWasLongRunning)
+
+	// the traces are no longer tracked once their root finished, without waiting for
+	// the next snapshot
+	assert.Empty(t, tracer.longRunning.traces)
+}
+
+func TestTracerIgnoreResources(t *testing.T) {
+	run := func(t *testing.T, want int, opts ...StartOption) (kept []string) {
+		tracer, transport, flush, stop, err := startTestTracer(t, opts...)
+		require.NoError(t, err)
+		defer stop()
+
+		for _, resource := range []string{"GET /healthz", "get /metrics", "GET /users", "POST /healthz/deep"} {
+			root := tracer.StartSpan("http.request", ResourceName(resource))
+			tracer.StartSpan("db.query", ChildOf(root.Context())).Finish()
+			root.Finish()
+		}
+		flush(want)
+		for _, trace := range transport.Traces() {
+			assert.Len(t, trace, 2)
+			kept = append(kept, trace[0].resource)
+		}
+		return kept
+	}
+
+	t.Run("option", func(t *testing.T) {
+		kept := run(t, 2, WithIgnoreResources("GET /healthz", "GET /metrics"))
+		assert.ElementsMatch(t, []string{"GET /users", "POST /healthz/deep"}, kept)
+	})
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("DD_TRACE_IGNORE_RESOURCES", "* /healthz*, ")
+		kept := run(t, 2)
+		assert.ElementsMatch(t, []string{"get /metrics", "GET /users"}, kept)
+	})
+
+	t.Run("wildcard", func(t *testing.T) {
+		kept := run(t, 4, WithIgnoreResources("*"))
+		assert.Len(t, kept, 4)
+	})
+}
+
+func TestTracerProcessTags(t *testing.T) {
+	t.Cleanup(processtags.Reload) // runs once the environment is restored
+	t.Setenv("DD_EXPERIMENTAL_PROPAGATE_PROCESS_TAGS_ENABLED", "true")
+	processtags.Reload()
+
+	tracer, err := newTracer(WithProcessTags("entrypoint.type", "unknown"))
+	require.NoError(t, err)
+	defer tracer.Stop()
+
+	root := tracer.StartSpan("web.request")
+	child := tracer.StartSpan("db.query", ChildOf(root.Context()))
+	assert.Equal(t, "executable", root.meta["entrypoint.type"])
+	assert.NotContains(t, root.meta, "unknown")
+	assert.NotContains(t, child.meta, "entrypoint.type")
+}
+
+func TestTracerBaggageTagKeys(t *testing.T) {
+	carrier := TextMapCarrier(map[string]string{
+		DefaultTraceIDHeader:  "1",
+		DefaultParentIDHeader: "1",
+		DefaultBaggageHeader:  "user.id=123,session.id=abc,other=x",
+	})
+	startRemoteRoot := func(t *testing.T, opts ...StartOption) (root, child *Span) {
+		tracer, err := newTracer(opts...)
+		require.NoError(t, err)
+		t.Cleanup(tracer.Stop)
+		sctx, err := tracer.Extract(carrier)
+		require.NoError(t, err)
+		root = tracer.StartSpan("web.request", ChildOf(sctx))
+		child = tracer.StartSpan("db.query", ChildOf(root.Context()))
+		return root, child
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		root, _ := startRemoteRoot(t)
+		assert.NotContains(t, root.meta, "baggage.user.id")
+	})
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("DD_TRACE_BAGGAGE_TAG_KEYS", "user.id, session.id")
+		root, child := startRemoteRoot(t)
+		assert.Equal(t, "123", root.meta["baggage.user.id"])
+		assert.Equal(t, "abc", root.meta["baggage.session.id"])
+		assert.NotContains(t, root.meta, "baggage.other")
+		assert.NotContains(t, child.meta, "baggage.user.id")
+	})
+
+	t.Run("option-overrides-env", func(t *testing.T) {
+		t.Setenv("DD_TRACE_BAGGAGE_TAG_KEYS", "user.id")
+		root, _ := startRemoteRoot(t, WithBaggageTagKeys("other"))
+		assert.Equal(t, "x", root.meta["baggage.other"])
+		assert.NotContains(t, root.meta, "baggage.user.id")
+	})
+
+	t.Run("wildcard", func(t *testing.T) {
+		root, _ := startRemoteRoot(t, WithBaggageTagKeys("*"))
+		assert.Equal(t, "123", root.meta["baggage.user.id"])
+		assert.Equal(t, "abc", root.meta["baggage.session.id"])
+		assert.Equal(t, "x", root.meta["baggage.other"])
+	})
+}
+
 func TestStartSpanOrigin(t *testing.T) {
 	t.Setenv(headerPropagationStyleExtract, "datadog")
 	t.Setenv(headerPropagationStyleInject, "datadog")
@@ -1320,6 +1627,19 @@ func TestTracerSampler(t *testing.T) {
 	assert.True(ok)
 }
 
+func TestTracerSamplerRateOnDrop(t *testing.T) {
+	tracer, err := newTracer(withTransport(newDefaultTransport()), WithSamplerRate(0))
+	assert.NoError(t, err)
+	defer tracer.Stop()
+
+	span := tracer.StartSpan("pylons.request")
+	p, ok := span.context.SamplingPriority()
+	assert.True(t, ok)
+	assert.Equal(t, ext.PriorityAutoReject, p)
+	assert.Equal(t, 0.0, span.metrics[sampleRateMetricKey])
+	assert.Contains(t, span.metrics, sampleRateMetricKey)
+}
+
 func TestTracerPrioritySampler(t *testing.T) {
 	assert := assert.New(t)
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
@@ -1789,6 +2109,50 @@ func TestPushTrace(t *testing.T) {
 	assert.True(len(tp.Logs()) >= 1)
 }
 
+func TestPushTraceBufferFull(t *testing.T) {
+	t.Run("drop-newest", func(t *testing.T) {
+		var statsd statsdtest.TestStatsdClient
+		tracer, err := newUnstartedTracer(WithTraceBufferSize(2), withStatsdClient(&statsd))
+		require.NoError(t, err)
+		defer tracer.statsd.Close()
+
+		for i := 0; i < 3; i++ {
+			tracer.pushChunk(&chunk{spans: make([]*Span, 1)})
+		}
+		assert.Len(t, tracer.out, 2)
+		calls := statsd.GetCallsByName("datadog.tracer.traces_dropped")
+		require.Len(t, calls, 1)
+		assert.Equal(t, []string{"reason:buffer_full"}, calls[0].Tags())
+	})
+
+	t.Run("block", func(t *testing.T) {
+		tracer, err := newUnstartedTracer(WithTraceBufferSize(1), WithBufferFullPolicy(BufferFullBlock(time.Second)))
+		require.NoError(t, err)
+		defer tracer.statsd.Close()
+
+		tracer.pushChunk(&chunk{spans: make([]*Span, 1)})
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			<-tracer.out
+		}()
+		tracer.pushChunk(&chunk{spans: make([]*Span, 2)})
+		require.Len(t, tracer.out, 1)
+		assert.Len(t, (<-tracer.out).spans, 2)
+		assert.Zero(t, atomic.LoadUint32(&tracer.totalTracesDropped))
+	})
+
+	t.Run("block-timeout", func(t *testing.T) {
+		tracer, err := newUnstartedTracer(WithTraceBufferSize(1), WithBufferFullPolicy(BufferFullBlock(10*time.Millisecond)))
+		require.NoError(t, err)
+		defer tracer.statsd.Close()
+
+		tracer.pushChunk(&chunk{spans: make([]*Span, 1)})
+		tracer.pushChunk(&chunk{spans: make([]*Span, 2)})
+		assert.Len(t, tracer.out, 1)
+		assert.Equal(t, uint32(1), atomic.LoadUint32(&tracer.totalTracesDropped))
+	})
+}
+
 func TestTracerFlush(t *testing.T) {
 	// https://github.com/DataDog/dd-trace-go/issues/377
 	tracer, transport, flush, stop, err := startTestTracer(t)
@@ -1830,6 +2194,188 @@ func TestTracerFlush(t *testing.T) {
 	})
 }
 
+func TestTracerIdleTimeout(t *testing.T) {
+	transport := newDummyTransport()
+	tick := make(chan time.Time)
+	tracer, err := newTracer(withTransport(transport), withTickChan(tick), WithIdleTimeout(time.Millisecond))
+	require.NoError(t, err)
+	setGlobalTracer(tracer)
+	defer func() {
+		setGlobalTracer(&NoopTracer{})
+		tracer.Stop()
+	}()
+	sendTick := func() bool {
+		select {
+		case tick <- time.Now():
+			return true
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}
+
+	// the worker parks on the first tick received once idle, after which ticks aren't received
+	assert.Eventually(t, func() bool { return !sendTick() }, time.Second*timeMultiplicator, time.Millisecond, "the worker should be parked")
+
+	tracer.StartSpan("root").Finish()
+	require.True(t, sendTick(), "the worker should resume with the next trace")
+	assert.Eventually(t, func() bool { return transport.Len() == 1 }, time.Second*timeMultiplicator, time.Millisecond)
+}
+
+func TestTracerFlushTrace(t *testing.T) {
+	tracer, transport, _, stop, err := startTestTracer(t)
+	assert.Nil(t, err)
+	defer stop()
+
+	assert := assert.New(t)
+	root := tracer.StartSpan("root")
+	child := tracer.StartSpan("child", ChildOf(root.Context()))
+	other := tracer.StartSpan("other")
+	tracer.StartSpan("other.child", ChildOf(other.Context())).Finish()
+	child.Finish()
+
+	tracer.flushTrace(child)
+	// the agent trace writer sends payloads asynchronously
+	assert.Eventually(func() bool { return transport.Len() == 1 }, time.Second*timeMultiplicator, time.Millisecond)
+	list := transport.Traces()
+	assert.Len(list, 1)
+	assert.Len(list[0], 1)
+	assert.Equal("child", list[0][0].name)
+	transport.Reset()
+
+	// the open spans are still sent once they finish
+	root.Finish()
+	other.Finish()
+	tracer.Flush()
+	assert.Eventually(func() bool { return transport.Len() == 2 }, time.Second*timeMultiplicator, time.Millisecond)
+	list = transport.Traces()
+	assert.Len(list, 2)
+}
+
+func TestAgentEndpoint(t *testing.T) {
+	assert.Empty(t, AgentEndpoint())
+
+	t.Run("http", func(t *testing.T) {
+		_, _, _, stop, err := startTestTracer(t, WithAgentAddr("agent:9126"))
+		require.NoError(t, err)
+		defer stop()
+		assert.Equal(t, "http://agent:9126", AgentEndpoint())
+	})
+
+	t.Run("unix", func(t *testing.T) {
+		_, _, _, stop, err := startTestTracer(t, WithUDS("/tmp/apm.socket"))
+		require.NoError(t, err)
+		defer stop()
+		assert.Equal(t, "unix:///tmp/apm.socket", AgentEndpoint())
+	})
+}
+
+type testSpanProcessor struct {
+	mu       sync.Mutex
+	started  []string
+	finished []string
+}
+
+func (p *testSpanProcessor) OnStart(s *Span) {
+	p.mu.Lock()
+	p.started = append(p.started, s.OperationName())
+	p.mu.Unlock()
+	s.SetTag("team", "tracing")
+}
+
+func (p *testSpanProcessor) OnFinish(s *Span) {
+	p.mu.Lock()
+	p.finished = append(p.finished, s.OperationName())
+	p.mu.Unlock()
+	s.SetTag("usr.email", "redacted")
+	if s.Resource() == "/health" {
+		s.SetTag(ext.ManualDrop, true)
+	}
+}
+
+func TestTracerSpanProcessor(t *testing.T) {
+	p := &testSpanProcessor{}
+	tracer, transport, flush, stop, err := startTestTracer(t, WithSpanProcessor(p), WithSpanProcessor(nil))
+	require.NoError(t, err)
+	defer stop()
+
+	root := tracer.StartSpan("web.request", ResourceName("/users"))
+	child := tracer.StartSpan("db.query", ChildOf(root.Context()), Tag("usr.email", "jane@example.com"))
+	assert.Equal(t, "tracing", child.Tags()["team"])
+	child.Finish()
+	child.Finish()
+	root.Finish()
+	health := tracer.StartSpan("web.request", ResourceName("/health"))
+	health.Finish()
+	flush(1)
+
+	assert.Equal(t, []string{"web.request", "db.query", "web.request"}, p.started)
+	assert.Equal(t, []string{"db.query", "web.request", "web.request"}, p.finished)
+	traces := transport.Traces()
+	require.Len(t, traces, 1)
+	assert.Equal(t, "redacted", traces[0][1].Tags()["usr.email"])
+	p0, _ := health.Context().SamplingPriority()
+	assert.Equal(t, ext.PriorityUserReject, p0)
+}
+
+func TestReconfigure(t *testing.T) {
+	assert.Error(t, Reconfigure(WithDebugMode(true)))
+
+	t.Run("sampling-tags-debug", func(t *testing.T) {
+		defer log.SetLevel(log.GetLevel())
+		log.SetLevel(log.LevelError)
+		tracer, _, _, stop, err := startTestTracer(t, WithGlobalTag("team", "a"))
+		require.NoError(t, err)
+		defer stop()
+
+		require.NoError(t, Reconfigure(
+			WithSamplingRules(TraceSamplingRules(Rule{ServiceGlob: "*", Rate: 0})),
+			WithGlobalTag("team", "b"),
+			WithGlobalTag("owner", "c"),
+			WithDebugMode(true),
+		))
+		s := tracer.StartSpan("web.request")
+		p, _ := s.Context().SamplingPriority()
+		assert.Equal(t, ext.PriorityUserReject, p)
+		assert.Equal(t, "b", s.meta["team"])
+		assert.Equal(t, "c", s.meta["owner"])
+		assert.Equal(t, globalconfig.RuntimeID(), s.meta[ext.RuntimeID])
+		assert.Equal(t, log.LevelDebug, log.GetLevel())
+
+		// the level in effect before debug mode was enabled is restored
+		require.NoError(t, Reconfigure(WithDebugMode(false)))
+		assert.Equal(t, log.LevelError, log.GetLevel())
+	})
+
+	t.Run("debug-at-start", func(t *testing.T) {
+		defer log.SetLevel(log.GetLevel())
+		log.SetLevel(log.LevelInfo)
+		_, _, _, stop, err := startTestTracer(t, WithDebugMode(true))
+		require.NoError(t, err)
+		defer stop()
+		assert.Equal(t, log.LevelDebug, log.GetLevel())
+
+		require.NoError(t, Reconfigure(WithDebugMode(false)))
+		assert.Equal(t, log.LevelInfo, log.GetLevel())
+	})
+
+	t.Run("agent", func(t *testing.T) {
+		tracer, err := newTracer(WithAgentAddr("agent:9126"))
+		require.NoError(t, err)
+		setGlobalTracer(tracer)
+		defer func() {
+			setGlobalTracer(&NoopTracer{})
+			tracer.Stop()
+		}()
+
+		require.NoError(t, Reconfigure(WithAgentURL("https://other-agent:8126")))
+		assert.Equal(t, "https://other-agent:8126/v0.4/traces", tracer.config.transport.endpoint())
+		require.NoError(t, Reconfigure(WithAgentURL("unix:///tmp/apm.socket")))
+		assert.Equal(t, "https://other-agent:8126/v0.4/traces", tracer.config.transport.endpoint())
+		require.NoError(t, Reconfigure(WithUDS("/tmp/apm.socket")))
+		assert.Equal(t, "https://other-agent:8126/v0.4/traces", tracer.config.transport.endpoint())
+	})
+}
+
 func TestTracerReportsHostname(t *testing.T) {
 	const hostname = "hostname-test"
 
@@ -2137,6 +2683,37 @@ func TestGitMetadata(t *testing.T) {
 		assert.Equal("", sp.meta[internal.TraceTagCommitSha])
 		assert.Equal("", sp.meta[internal.TraceTagRepositoryURL])
 	})
+
+	t.Run("git-metadata-on-chunk-root-only", func(t *testing.T) {
+		t.Cleanup(internal.RefreshGitMetadataTags)
+		t.Setenv(internal.EnvGitRepositoryURL, "github.com/user/repo")
+		t.Setenv(internal.EnvGitCommitSha, "123456789ABCD")
+		internal.RefreshGitMetadataTags()
+
+		tracer, transport, flush, stop, err := startTestTracer(t)
+		require.NoError(t, err)
+		defer stop()
+
+		root := tracer.StartSpan("http.request")
+		child := tracer.StartSpan("db.query", ChildOf(root.Context()))
+		child.Finish()
+		root.Finish()
+		flush(1)
+
+		traces := transport.Traces()
+		require.Len(t, traces, 1)
+		require.Len(t, traces[0], 2)
+		assert := assert.New(t)
+		for _, sp := range traces[0] {
+			if sp.spanID == root.spanID {
+				assert.Equal("123456789ABCD", sp.meta[internal.TraceTagCommitSha])
+				assert.Equal("github.com/user/repo", sp.meta[internal.TraceTagRepositoryURL])
+			} else {
+				assert.NotContains(sp.meta, internal.TraceTagCommitSha)
+				assert.NotContains(sp.meta, internal.TraceTagRepositoryURL)
+			}
+		}
+	})
 }
 
 // BenchmarkConcurrentTracing tests the performance of spawning a lot of
@@ -14,6 +14,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
@@ -44,8 +45,8 @@ func defaultHTTPClient(timeout time.Duration) *http.Client {
 		Transport: &http.Transport{
 			Proxy:                 http.ProxyFromEnvironment,
 			DialContext:           defaultDialer.DialContext,
-			MaxIdleConns:          100,
-			IdleConnTimeout:       90 * time.Second,
+			MaxIdleConns:          defaultAgentMaxIdleConns,
+			IdleConnTimeout:       defaultAgentIdleConnTimeout,
 			TLSHandshakeTimeout:   10 * time.Second,
 			ExpectContinueTimeout: 1 * time.Second,
 		},
@@ -53,6 +54,11 @@ func defaultHTTPClient(timeout time.Duration) *http.Client {
 	}
 }
 
+const (
+	defaultAgentMaxIdleConns    = 100              // maximum number of idle connections to the agent
+	defaultAgentIdleConnTimeout = 90 * time.Second // time after which an idle connection to the agent is closed
+)
+
 const (
 	defaultHostname          = "localhost"
 	defaultPort              = "8126"
@@ -76,6 +82,7 @@ type transport interface {
 }
 
 type httpTransport struct {
+	mu       sync.RWMutex      // guards traceURL and statsURL, which change on Reconfigure
 	traceURL string            // the delivery URL for traces
 	statsURL string            // the delivery URL for stats
 	client   *http.Client      // the HTTP client used in the POST
@@ -115,12 +122,23 @@ func newHTTPTransport(url string, client *http.Client) *httpTransport {
 	}
 }
 
+// setURL makes the transport send the traces and stats to the agent at the given url.
+func (t *httpTransport) setURL(url string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.traceURL = fmt.Sprintf("%s/v0.4/traces", url)
+	t.statsURL = fmt.Sprintf("%s/v0.6/stats", url)
+}
+
 func (t *httpTransport) sendStats(p *pb.ClientStatsPayload, tracerObfuscationVersion int) error {
 	var buf bytes.Buffer
 	if err := msgp.Encode(&buf, p); err != nil {
 		return err
 	}
-	req, err := http.NewRequest("POST", t.statsURL, &buf)
+	t.mu.RLock()
+	statsURL := t.statsURL
+	t.mu.RUnlock()
+	req, err := http.NewRequest("POST", statsURL, &buf)
 	if err != nil {
 		return err
 	}
@@ -150,7 +168,7 @@ func (t *httpTransport) sendStats(p *pb.ClientStatsPayload, tracerObfuscationVer
 }
 
 func (t *httpTransport) send(p *payload) (body io.ReadCloser, err error) {
-	req, err := http.NewRequest("POST", t.traceURL, p)
+	req, err := http.NewRequest("POST", t.endpoint(), p)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create http request: %s", err.Error())
 	}
@@ -194,9 +212,17 @@ func (t *httpTransport) send(p *payload) (body io.ReadCloser, err error) {
 		response.Body.Close()
 		txt := http.StatusText(code)
 		if n > 0 {
-			return nil, fmt.Errorf("%s (Status: %s)", msg[:n], txt)
+			err = fmt.Errorf("%s (Status: %s)", msg[:n], txt)
+		} else {
+			err = fmt.Errorf("%s", txt)
 		}
-		return nil, fmt.Errorf("%s", txt)
+		if code == http.StatusTooManyRequests {
+			return nil, &throttledError{
+				err:        err,
+				retryAfter: parseRetryAfter(response.Header.Get("Retry-After"), time.Now()),
+			}
+		}
+		return nil, err
 	}
 	return response.Body, nil
 }
@@ -217,5 +243,43 @@ func reportAPIErrorsMetric(response *http.Response, err error) {
 }
 
 func (t *httpTransport) endpoint() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	return t.traceURL
 }
+
+// maxRetryAfter caps the time the agent can ask the tracer to wait before retrying,
+// so that a misbehaving proxy can't hold up the flushes, and the tracer shutdown, for long.
+const maxRetryAfter = 10 * time.Second
+
+// throttledError is returned by the transport when the agent responds with
+// 429 Too Many Requests.
+type throttledError struct {
+	err error
+	// retryAfter is the time the agent asked to wait before retrying, as found in
+	// the Retry-After header. It is zero if the header was missing or invalid.
+	retryAfter time.Duration
+}
+
+func (e *throttledError) Error() string { return e.err.Error() }
+
+func (e *throttledError) Unwrap() error { return e.err }
+
+// parseRetryAfter returns the duration found in the value v of a Retry-After header,
+// which is either a number of seconds or an HTTP date, relative to now. It returns 0
+// if v is invalid, and caps the duration to maxRetryAfter.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	var d time.Duration
+	if secs, err := strconv.Atoi(v); err == nil {
+		d = time.Duration(secs) * time.Second
+	} else if t, err := http.ParseTime(v); err == nil {
+		d = t.Sub(now)
+	}
+	if d < 0 {
+		return 0
+	}
+	return min(d, maxRetryAfter)
+}
@@ -17,6 +17,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
 	"github.com/DataDog/dd-trace-go/v2/internal"
@@ -166,6 +167,35 @@ func TestTransportResponse(t *testing.T) {
 	}
 }
 
+func TestTransportThrottled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	transport := newHTTPTransport(srv.URL, defaultHTTPClient(0))
+	_, err := transport.send(newPayload())
+	var terr *throttledError
+	require.ErrorAs(t, err, &terr)
+	assert.Equal(t, 3*time.Second, terr.retryAfter)
+	assert.Equal(t, "Too Many Requests", err.Error())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for in, want := range map[string]time.Duration{
+		"":                              0,
+		"invalid":                       0,
+		"-1":                            0,
+		"2":                             2 * time.Second,
+		"3600":                          maxRetryAfter,
+		"Mon, 01 Jan 2024 00:00:05 GMT": 5 * time.Second,
+		"Sun, 31 Dec 2023 23:59:00 GMT": 0,
+	} {
+		assert.Equal(t, want, parseRetryAfter(in, now), in)
+	}
+}
+
 func TestTraceCountHeader(t *testing.T) {
 	assert := assert.New(t)
 
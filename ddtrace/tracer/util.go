@@ -26,6 +26,16 @@ func parseUint64(str string) (uint64, error) {
 	return strconv.ParseUint(str, 10, 64)
 }
 
+// parseHexUint64 parses a 64-bit ID encoded as up to 16 hexadecimal digits,
+// with an optional "0x" prefix.
+func parseHexUint64(str string) (uint64, error) {
+	str = strings.TrimPrefix(strings.TrimPrefix(str, "0x"), "0X")
+	if len(str) > 16 {
+		return 0, fmt.Errorf("hex ID too long: %q", str)
+	}
+	return strconv.ParseUint(str, 16, 64)
+}
+
 func isValidPropagatableTag(k, v string) error {
 	if len(k) == 0 {
 		return fmt.Errorf("key length must be greater than zero")
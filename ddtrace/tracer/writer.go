@@ -13,6 +13,7 @@ import (
 	"math"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -59,24 +60,82 @@ func newAgentTraceWriter(c *config, s *prioritySampler, statsdClient globalinter
 	return &agentTraceWriter{
 		config:           c,
 		payload:          newPayload(),
-		climit:           make(chan struct{}, concurrentConnectionLimit),
+		climit:           make(chan struct{}, c.maxConcurrentFlushes),
 		prioritySampling: s,
 		statsd:           statsdClient,
 	}
 }
 
 func (h *agentTraceWriter) add(trace []*Span) {
-	if err := h.payload.push(trace); err != nil {
+	if size := spanList(trace).Msgsize(); size > payloadSizeLimit && len(trace) > 1 {
+		h.stream(trace)
+	} else if err := h.payload.pushSized(trace, size); err != nil {
 		h.statsd.Incr("datadog.tracer.traces_dropped", []string{"reason:encoding_error"}, 1)
 		log.Error("Error encoding msgpack: %s", err.Error())
 	}
 	atomic.AddUint32(&h.tracesQueued, 1) // TODO: This does not differentiate between complete traces and partial chunks
+	h.flushIfFull()
+}
+
+// flushIfFull flushes the payload if it reached payloadSizeLimit.
+func (h *agentTraceWriter) flushIfFull() {
 	if h.payload.size() > payloadSizeLimit {
 		h.statsd.Incr("datadog.tracer.flush_triggered", []string{"reason:size"}, 1)
 		h.flush()
 	}
 }
 
+// streamChunkSize is the approximate encoded size of the chunks a trace is split into
+// when it is too large to fit in a payload, see agentTraceWriter.stream.
+const streamChunkSize = payloadSizeLimit / 4
+
+// stream encodes a trace which is too large to fit in a payload as a sequence of chunks of
+// about streamChunkSize bytes, flushing the payload whenever it fills up. Each flush is
+// waited for before the next chunk is encoded, so that a single payload of the trace is
+// held in memory at a time rather than all of them, however slow the agent is. As with
+// partial flushing, the first span of each chunk carries the trace level tags and the
+// sampling priority.
+func (h *agentTraceWriter) stream(trace []*Span) {
+	h.statsd.Incr("datadog.tracer.trace_streamed", nil, 1)
+	start, size := 0, 0
+	for i, s := range trace {
+		size += s.Msgsize()
+		if size < streamChunkSize && i < len(trace)-1 {
+			continue
+		}
+		chunk := spanList(trace[start : i+1])
+		if start > 0 {
+			copyTraceTags(chunk[0], trace[0])
+		}
+		if err := h.payload.pushSized(chunk, size); err != nil {
+			h.statsd.Incr("datadog.tracer.traces_dropped", []string{"reason:encoding_error"}, 1)
+			log.Error("Error encoding msgpack: %s", err.Error())
+			return
+		}
+		start, size = i+1, 0
+		if i < len(trace)-1 && h.payload.size() > payloadSizeLimit {
+			h.statsd.Incr("datadog.tracer.flush_triggered", []string{"reason:size"}, 1)
+			<-h.flushPayload()
+		}
+	}
+}
+
+// copyTraceTags sets the trace level tags and the sampling priority of src, the first span
+// of a trace, on dst, so that dst can be sent as the first span of a separate chunk.
+func copyTraceTags(dst, src *Span) {
+	dst.mu.Lock()
+	defer dst.mu.Unlock()
+	for k, v := range src.meta {
+		if strings.HasPrefix(k, "_dd.p.") || strings.HasPrefix(k, "_dd.git.") ||
+			k == keyOrigin || k == keyPropagationError || k == keyProcessTags {
+			dst.setMeta(k, v)
+		}
+	}
+	if p, ok := src.metrics[keySamplingPriority]; ok {
+		dst.setMetric(keySamplingPriority, p)
+	}
+}
+
 func (h *agentTraceWriter) stop() {
 	h.statsd.Incr("datadog.tracer.flush_triggered", []string{"reason:shutdown"}, 1)
 	h.flush()
@@ -85,8 +144,16 @@ func (h *agentTraceWriter) stop() {
 
 // flush will push any currently buffered traces to the server.
 func (h *agentTraceWriter) flush() {
+	h.flushPayload()
+}
+
+// flushPayload pushes any currently buffered traces to the server, returning a channel
+// which is closed once they were sent or dropped.
+func (h *agentTraceWriter) flushPayload() <-chan struct{} {
+	done := make(chan struct{})
 	if h.payload.itemCount() == 0 {
-		return
+		close(done)
+		return done
 	}
 	h.wg.Add(1)
 	h.climit <- struct{}{}
@@ -104,6 +171,7 @@ func (h *agentTraceWriter) flush() {
 			<-h.climit
 			h.statsd.Timing("datadog.tracer.flush_duration", time.Since(start), nil, 1)
 			h.wg.Done()
+			close(done)
 		}(time.Now())
 
 		var count, size int
@@ -116,7 +184,13 @@ func (h *agentTraceWriter) flush() {
 			if err == nil {
 				log.Debug("sent traces after %d attempts", attempt+1)
 				h.statsd.Count("datadog.tracer.flush_bytes", int64(size), nil, 1)
+				// the distribution of the payload sizes, on top of their total. It is not named
+				// flush.bytes, which would only differ from the flush_bytes count by a dot.
+				h.statsd.DistributionSamples("datadog.tracer.flush_bytes.distribution", []float64{float64(size)}, nil, 1)
 				h.statsd.Count("datadog.tracer.flush_traces", int64(count), nil, 1)
+				if fn := h.config.agentResponseHandler; fn != nil {
+					rc = handleAgentResponse(rc, fn)
+				}
 				if err := h.prioritySampling.readRatesJSON(rc); err != nil {
 					h.statsd.Incr("datadog.tracer.decode_error", nil, 1)
 				}
@@ -124,11 +198,30 @@ func (h *agentTraceWriter) flush() {
 			}
 			log.Error("failure sending traces (attempt %d of %d): %v", attempt+1, h.config.sendRetries+1, err.Error())
 			p.reset()
-			time.Sleep(h.config.retryInterval)
+			wait := h.config.retryInterval
+			var terr *throttledError
+			if errors.As(err, &terr) && terr.retryAfter > 0 {
+				// the agent is overloaded, back off for as long as it asked to
+				wait = terr.retryAfter
+			}
+			time.Sleep(wait)
 		}
 		h.statsd.Count("datadog.tracer.traces_dropped", int64(count), []string{"reason:send_failed"}, 1)
 		log.Error("lost %d traces: %v", count, err.Error())
 	}(oldp)
+	return done
+}
+
+// handleAgentResponse reads and closes the agent response rc, calls fn with its body and
+// returns a new reader of the body, see WithAgentResponseHandler.
+func handleAgentResponse(rc io.ReadCloser, fn func(body []byte)) io.ReadCloser {
+	defer rc.Close()
+	body, err := io.ReadAll(rc)
+	if err != nil {
+		log.Error("Failed to read the agent response: %s", err.Error())
+	}
+	fn(body)
+	return io.NopCloser(bytes.NewReader(body))
 }
 
 // logWriter specifies the output target of the logTraceWriter; replaced in tests.
@@ -360,3 +453,56 @@ func (h *logTraceWriter) flush() {
 	h.w.Write(h.buf.Bytes())
 	h.resetBuffer()
 }
+
+// dryRunTraceWriter discards traces instead of sending them, after logging and counting
+// their sampling decisions, see WithDryRun. It sends empty payloads to the agent instead,
+// to keep reading the sampling rates from its responses.
+type dryRunTraceWriter struct {
+	// config holds the tracer configuration
+	config *config
+
+	// prioritySampling is the prioritySampler into which dryRunTraceWriter will
+	// read sampling rates sent by the agent
+	prioritySampling *prioritySampler
+
+	statsd globalinternal.StatsdClient
+}
+
+func newDryRunTraceWriter(c *config, s *prioritySampler, statsdClient globalinternal.StatsdClient) *dryRunTraceWriter {
+	return &dryRunTraceWriter{
+		config:           c,
+		prioritySampling: s,
+		statsd:           statsdClient,
+	}
+}
+
+func (h *dryRunTraceWriter) add(trace []*Span) {
+	if len(trace) == 0 {
+		return
+	}
+	ctx := trace[0].context
+	priority, ok := ctx.SamplingPriority()
+	p := "none"
+	if ok {
+		p = strconv.Itoa(priority)
+	}
+	var dm string
+	if ctx.trace != nil {
+		dm = ctx.trace.propagatingTag(keyDecisionMaker)
+	}
+	h.statsd.Incr("datadog.tracer.dry_run.traces", []string{"sampling_priority:" + p}, 1)
+	log.Debug("Dry run: not sending trace %s of %d spans, sampling priority: %s, decision maker: %q.", ctx.TraceID(), len(trace), p, dm)
+}
+
+func (h *dryRunTraceWriter) flush() {
+	rc, err := h.config.transport.send(newPayload())
+	if err != nil {
+		log.Debug("Dry run: failed to read the sampling rates of the agent: %s", err.Error())
+		return
+	}
+	if err := h.prioritySampling.readRatesJSON(rc); err != nil {
+		h.statsd.Incr("datadog.tracer.decode_error", nil, 1)
+	}
+}
+
+func (h *dryRunTraceWriter) stop() {}
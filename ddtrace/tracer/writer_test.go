@@ -13,9 +13,11 @@ import (
 	"io"
 	"math"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
 	"github.com/DataDog/dd-trace-go/v2/internal/log"
 	"github.com/DataDog/dd-trace-go/v2/internal/statsdtest"
 
@@ -432,8 +434,14 @@ func TestTraceWriterFlushRetries(t *testing.T) {
 			assert.Equal(1, len(statsd.TimingCalls()))
 			if test.tracesSent {
 				assert.Equal(sentCounts, statsd.Counts())
+				dist := statsd.DistributionCalls()
+				if assert.Len(dist, 1) {
+					assert.Equal("datadog.tracer.flush_bytes.distribution", dist[0].Name())
+					assert.Equal(float64(sentCounts["datadog.tracer.flush_bytes"]), dist[0].FloatVal())
+				}
 			} else {
 				assert.Equal(droppedCounts, statsd.Counts())
+				assert.Empty(statsd.DistributionCalls())
 			}
 			if test.configRetries > 0 && test.failCount > 1 {
 				assert.GreaterOrEqual(elapsed, test.retryInterval*time.Duration(minInts(test.configRetries+1, test.failCount)))
@@ -442,6 +450,200 @@ func TestTraceWriterFlushRetries(t *testing.T) {
 	}
 }
 
+type blockingTransport struct {
+	dummyTransport
+	release     chan struct{}
+	inFlight    int32
+	maxInFlight int32
+}
+
+func (t *blockingTransport) send(_ *payload) (io.ReadCloser, error) {
+	n := atomic.AddInt32(&t.inFlight, 1)
+	for {
+		m := atomic.LoadInt32(&t.maxInFlight)
+		if n <= m || atomic.CompareAndSwapInt32(&t.maxInFlight, m, n) {
+			break
+		}
+	}
+	<-t.release
+	atomic.AddInt32(&t.inFlight, -1)
+	return io.NopCloser(strings.NewReader("OK")), nil
+}
+
+func TestTraceWriterMaxConcurrentFlushes(t *testing.T) {
+	p := &blockingTransport{release: make(chan struct{})}
+	c, err := newConfig(WithMaxConcurrentFlushes(1), func(c *config) {
+		c.transport = p
+	})
+	require.NoError(t, err)
+	h := newAgentTraceWriter(c, newPrioritySampler(), &statsdtest.TestStatsdClient{})
+
+	h.add([]*Span{makeSpan(0)})
+	h.flush()
+	h.add([]*Span{makeSpan(0)})
+	done := make(chan struct{})
+	go func() {
+		h.flush()
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("flush didn't wait for the in-flight one")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(p.release)
+	<-done
+	h.wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&p.maxInFlight))
+
+	c, err = newConfig(WithMaxConcurrentFlushes(0))
+	require.NoError(t, err)
+	assert.Equal(t, concurrentConnectionLimit, c.maxConcurrentFlushes)
+}
+
+type throttlingTransport struct {
+	dummyTransport
+	retryAfter   time.Duration
+	sendAttempts int
+}
+
+func (t *throttlingTransport) send(_ *payload) (io.ReadCloser, error) {
+	t.sendAttempts++
+	if t.sendAttempts == 1 {
+		return nil, &throttledError{err: errors.New("Too Many Requests"), retryAfter: t.retryAfter}
+	}
+	return io.NopCloser(strings.NewReader("OK")), nil
+}
+
+func TestTraceWriterFlushRetryAfter(t *testing.T) {
+	p := &throttlingTransport{retryAfter: 50 * time.Millisecond}
+	c, err := newConfig(func(c *config) {
+		c.transport = p
+		c.sendRetries = 1
+		c.retryInterval = time.Millisecond
+	})
+	require.NoError(t, err)
+
+	h := newAgentTraceWriter(c, newPrioritySampler(), &statsdtest.TestStatsdClient{})
+	h.add([]*Span{makeSpan(0)})
+	start := time.Now()
+	h.flush()
+	h.wg.Wait()
+
+	assert.Equal(t, 2, p.sendAttempts)
+	assert.GreaterOrEqual(t, time.Since(start), p.retryAfter)
+}
+
+// respondingTransport responds to trace payloads with a fixed body.
+type respondingTransport struct {
+	dummyTransport
+	body string
+}
+
+func (t *respondingTransport) send(_ *payload) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(t.body)), nil
+}
+
+func TestTraceWriterAgentResponseHandler(t *testing.T) {
+	const body = `{"rate_by_service":{"service:,env:":0.25},"directive":"boost"}`
+	var got []string
+	c, err := newConfig(func(c *config) {
+		c.transport = &respondingTransport{body: body}
+	}, WithAgentResponseHandler(func(body []byte) {
+		got = append(got, string(body))
+	}))
+	require.NoError(t, err)
+
+	ps := newPrioritySampler()
+	h := newAgentTraceWriter(c, ps, &statsdtest.TestStatsdClient{})
+	h.add([]*Span{makeSpan(0)})
+	h.flush()
+	h.wg.Wait()
+
+	assert.Equal(t, []string{body}, got)
+	// the rates are still read from the response
+	assert.Equal(t, 0.25, ps.defaultRate)
+}
+
+func TestTraceWriterStream(t *testing.T) {
+	transport := newDummyTransport()
+	c, err := newConfig(func(c *config) {
+		c.transport = transport
+	})
+	require.NoError(t, err)
+	var tg statsdtest.TestStatsdClient
+	h := newAgentTraceWriter(c, newPrioritySampler(), &tg)
+
+	trace := make([]*Span, 12)
+	for i := range trace {
+		trace[i] = makeSpan(0)
+		trace[i].meta["key"] = strings.Repeat("X", 1<<20)
+	}
+	trace[0].setMetric(keySamplingPriority, ext.PriorityUserKeep)
+	trace[0].setMeta(keyDecisionMaker, "-4")
+	h.add(trace)
+	h.add([]*Span{makeSpan(0), makeSpan(0)})
+	h.stop()
+
+	// payloads are sent concurrently, so the chunks may be received in any order
+	var n, streamed int
+	for _, chunk := range transport.Traces() {
+		if _, ok := chunk[0].meta["key"]; !ok {
+			assert.Len(t, chunk, 2)
+			continue
+		}
+		n += len(chunk)
+		streamed++
+		assert.Less(t, chunk.Msgsize(), int(payloadSizeLimit))
+		assert.Equal(t, float64(ext.PriorityUserKeep), chunk[0].metrics[keySamplingPriority])
+		assert.Equal(t, "-4", chunk[0].meta[keyDecisionMaker])
+	}
+	assert.Equal(t, len(trace), n)
+	assert.Greater(t, streamed, 1)
+	assert.Equal(t, int64(1), tg.Counts()["datadog.tracer.trace_streamed"])
+	assert.Contains(t, tg.CallNames(), "datadog.tracer.flush_triggered")
+}
+
+type slowTransport struct {
+	*dummyTransport
+	inFlight    int32
+	maxInFlight int32
+}
+
+func (t *slowTransport) send(p *payload) (io.ReadCloser, error) {
+	n := atomic.AddInt32(&t.inFlight, 1)
+	for {
+		m := atomic.LoadInt32(&t.maxInFlight)
+		if n <= m || atomic.CompareAndSwapInt32(&t.maxInFlight, m, n) {
+			break
+		}
+	}
+	defer atomic.AddInt32(&t.inFlight, -1)
+	time.Sleep(50 * time.Millisecond)
+	return t.dummyTransport.send(p)
+}
+
+func TestTraceWriterStreamInFlight(t *testing.T) {
+	transport := &slowTransport{dummyTransport: newDummyTransport()}
+	c, err := newConfig(func(c *config) {
+		c.transport = transport
+	})
+	require.NoError(t, err)
+	h := newAgentTraceWriter(c, newPrioritySampler(), &statsdtest.TestStatsdClient{})
+
+	trace := make([]*Span, 12)
+	for i := range trace {
+		trace[i] = makeSpan(0)
+		trace[i].meta["key"] = strings.Repeat("X", 1<<20)
+	}
+	h.add(trace)
+	h.stop()
+
+	// the payloads of a streamed trace are sent one after the other
+	assert.Greater(t, len(transport.Traces()), 1)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&transport.maxInFlight))
+}
+
 func minInts(a, b int) int {
 	if a < b {
 		return a
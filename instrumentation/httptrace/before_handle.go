@@ -64,7 +64,21 @@ func BeforeHandle(cfg *ServeConfig, w http.ResponseWriter, r *http.Request) (htt
 	span, ctx, finishSpans := StartRequestSpan(r, opts...)
 	rw, ddrw := wrapResponseWriter(w)
 	rt := r.WithContext(ctx)
+	var reqBody *BodyCapture
+	if span.ShouldCapturePayload() {
+		if rt.Body != nil && rt.Body != http.NoBody {
+			reqBody = new(BodyCapture)
+			rt.Body = reqBody.WrapBody(rt.Body)
+		}
+		ddrw.body = new(BodyCapture)
+	}
 	closeSpan := func() {
+		if reqBody != nil {
+			span.CapturePayload(ext.HTTPRequestBody, reqBody.Bytes())
+		}
+		if ddrw.body != nil {
+			span.CapturePayload(ext.HTTPResponseBody, ddrw.body.Bytes())
+		}
 		finishSpans(ddrw.status, cfg.IsStatusError, cfg.FinishOpts...)
 	}
 	afterHandle := closeSpan
@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package httptrace
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// maxCapturedBodySize is the number of bytes of an HTTP body which are buffered for
+// payload capture; the tracer redacts and truncates them further, see
+// tracer.WithPayloadCapture.
+const maxCapturedBodySize = 64 << 10
+
+// BodyCapture buffers the first bytes of an HTTP body, read or written, so that they
+// can be captured on a span with tracer.Span.CapturePayload. It is safe for concurrent use.
+type BodyCapture struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+// Write buffers p, up to the size of the captured bodies. It never fails.
+func (c *BodyCapture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n := maxCapturedBodySize - len(c.buf); n > 0 {
+		c.buf = append(c.buf, p[:min(n, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// Bytes returns the bytes buffered so far.
+func (c *BodyCapture) Bytes() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.buf
+}
+
+// WrapBody returns a body reading from body, and buffering the bytes read into c.
+func (c *BodyCapture) WrapBody(body io.ReadCloser) io.ReadCloser {
+	return &captureReadCloser{Reader: io.TeeReader(body, c), Closer: body}
+}
+
+// PeekBody reads the first bytes of body, at most n, buffers them into c, and returns a
+// body reading them again followed by the rest of body. It is meant for the bodies of the
+// responses received by HTTP clients, which are usually read after their spans finished;
+// n should be the length of the body when known, so that it doesn't block on a streamed body.
+func (c *BodyCapture) PeekBody(body io.ReadCloser, n int64) io.ReadCloser {
+	buf, err := io.ReadAll(io.LimitReader(body, min(n, maxCapturedBodySize)))
+	c.Write(buf)
+	var rest io.Reader = body
+	if err != nil {
+		rest = errReader{err}
+	}
+	return &captureReadCloser{Reader: io.MultiReader(bytes.NewReader(buf), rest), Closer: body}
+}
+
+// errReader is a reader failing with err, returned by PeekBody for the rest of a body
+// which failed to be read.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+type captureReadCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -185,7 +185,7 @@ func FinishRequestSpan(s *tracer.Span, status int, errorFn func(int) bool, opts
 	s.Finish(tracer.WithFinishConfig(fc))
 }
 
-// URLFromRequest returns the full URL from the HTTP request. If queryString is true, params are collected and they are obfuscated either by the default query string obfuscator or the custom obfuscator provided by the user (through DD_TRACE_OBFUSCATION_QUERY_STRING_REGEXP)
+// URLFromRequest returns the full URL from the HTTP request. If queryString is true, params are collected and they are obfuscated either by the default query string obfuscator or the custom obfuscator provided by the user (through DD_TRACE_OBFUSCATION_QUERY_STRING_REGEXP), then sanitized with the policy of DD_TRACE_URL_SANITIZE, see instrumentation.Instrumentation.SanitizeURL.
 // See https://docs.datadoghq.com/tracing/configure_data_security/?tab=net#redact-query-strings for more information.
 func URLFromRequest(r *http.Request, queryString bool) string {
 	// Quoting net/http comments about net.Request.URL on server requests:
@@ -211,7 +211,9 @@ func URLFromRequest(r *http.Request, queryString bool) string {
 		if cfg.queryStringRegexp != nil {
 			query = cfg.queryStringRegexp.ReplaceAllLiteralString(query, "<redacted>")
 		}
-		url = strings.Join([]string{url, query}, "?")
+		if query = instr.SanitizeQuery(query); query != "" {
+			url = strings.Join([]string{url, query}, "?")
+		}
 	}
 	if frag := r.URL.EscapedFragment(); frag != "" {
 		url = strings.Join([]string{url, frag}, "#")
@@ -7,12 +7,15 @@ package httptrace
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/DataDog/dd-trace-go/v2/ddtrace/baggage"
@@ -393,6 +396,44 @@ func TestStartRequestSpanWithBaggage(t *testing.T) {
 	assert.Equal(t, "value2", baggageMap["key2"], "should propagate baggage from header to context")
 }
 
+func TestBeforeHandlePayloadCapture(t *testing.T) {
+	tracer.Start(tracer.WithPayloadCapture(100), tracer.WithLogStartup(false))
+	defer tracer.Stop()
+
+	var span *tracer.Span
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span, _ = tracer.SpanFromContext(r.Context())
+		body, _ := io.ReadAll(r.Body)
+		w.Write(append([]byte("echo:"), body...))
+	})
+	r := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("ping"))
+	tw, tr, afterHandle, handled := BeforeHandle(nil, httptest.NewRecorder(), r)
+	require.False(t, handled)
+	h.ServeHTTP(tw, tr)
+	afterHandle()
+
+	m := span.AsMap()
+	assert.Equal(t, "ping", m[ext.HTTPRequestBody])
+	assert.Equal(t, "echo:ping", m[ext.HTTPResponseBody])
+}
+
+func TestBodyCapturePeekBody(t *testing.T) {
+	var c BodyCapture
+	body := c.PeekBody(io.NopCloser(strings.NewReader("hello world")), 5)
+	assert.Equal(t, "hello", string(c.Bytes()))
+	b, err := io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(b))
+
+	var failed BodyCapture
+	errRead := errors.New("connection reset")
+	body = failed.PeekBody(io.NopCloser(io.MultiReader(strings.NewReader("hel"), errReader{errRead})), 5)
+	assert.Equal(t, "hel", string(failed.Bytes()))
+	b, err = io.ReadAll(body)
+	assert.Equal(t, "hel", string(b))
+	assert.ErrorIs(t, err, errRead)
+}
+
 func TestStartRequestSpanMergedBaggage(t *testing.T) {
 	t.Setenv("DD_TRACE_PROPAGATION_STYLE", "datadog,tracecontext,baggage")
 	tracer.Start()
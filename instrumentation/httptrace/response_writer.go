@@ -14,6 +14,7 @@ import "net/http"
 type responseWriter struct {
 	http.ResponseWriter
 	status int
+	body   *BodyCapture // buffers the response body when the payloads of the span are captured
 }
 
 // ResetStatusCode resets the status code of the response writer.
@@ -24,7 +25,7 @@ func ResetStatusCode(w http.ResponseWriter) {
 }
 
 func newResponseWriter(w http.ResponseWriter) *responseWriter {
-	return &responseWriter{w, 0}
+	return &responseWriter{ResponseWriter: w}
 }
 
 // Status returns the status code that was monitored.
@@ -39,6 +40,9 @@ func (w *responseWriter) Write(b []byte) (int, error) {
 	if w.status == 0 {
 		w.WriteHeader(http.StatusOK)
 	}
+	if w.body != nil {
+		w.body.Write(b)
+	}
 	return w.ResponseWriter.Write(b)
 }
 
@@ -13,6 +13,7 @@ import (
 	"github.com/DataDog/dd-trace-go/v2/internal"
 	"github.com/DataDog/dd-trace-go/v2/internal/appsec"
 	"github.com/DataDog/dd-trace-go/v2/internal/globalconfig"
+	"github.com/DataDog/dd-trace-go/v2/internal/log"
 	"github.com/DataDog/dd-trace-go/v2/internal/namingschema"
 	"github.com/DataDog/dd-trace-go/v2/internal/normalizer"
 	"github.com/DataDog/dd-trace-go/v2/internal/stableconfig"
@@ -44,6 +45,8 @@ func Load(pkg Package) *Instrumentation {
 // ReloadConfig reloads config read from environment variables. This is useful for tests.
 func ReloadConfig() {
 	namingschema.ReloadConfig()
+	loadURLSanitizePolicy()
+	loadSQLObfuscation()
 }
 
 // Version returns the version of the dd-trace-go package.
@@ -71,7 +74,9 @@ func (i *Instrumentation) ServiceName(component Component, opCtx OperationContex
 	if useDDService && cfg.DDService != "" {
 		return cfg.DDService
 	}
-	return n.buildServiceNameV0(opCtx)
+	service := n.buildServiceNameV0(opCtx)
+	namingschema.RecordIntegrationService(service)
+	return service
 }
 
 // OperationName returns the operation name to be set for the given instrumentation component.
@@ -93,16 +98,39 @@ func (i *Instrumentation) Logger() Logger {
 	return i.logger
 }
 
+// AnalyticsRate returns the default analytics rate of the integration, which its own
+// options may override. In order of precedence, it is:
+//  1. 1.0 if DD_TRACE_<INTEGRATION>_ANALYTICS_ENABLED is true;
+//  2. the rate set for all integrations by DD_TRACE_ANALYTICS_SAMPLE_RATE;
+//  3. the global analytics rate if defaultGlobal is true, see GlobalAnalyticsRate;
+//  4. NaN otherwise, meaning that the integration doesn't mark analytics events.
 func (i *Instrumentation) AnalyticsRate(defaultGlobal bool) float64 {
 	if internal.BoolEnv("DD_TRACE_"+i.info.EnvVarPrefix+"_ANALYTICS_ENABLED", false) {
 		return 1.0
 	}
+	if rate, ok := integrationsAnalyticsRate(); ok {
+		return rate
+	}
 	if defaultGlobal {
 		return i.GlobalAnalyticsRate()
 	}
 	return math.NaN()
 }
 
+// integrationsAnalyticsRate returns the analytics rate set for all the integrations
+// through DD_TRACE_ANALYTICS_SAMPLE_RATE, if any.
+func integrationsAnalyticsRate() (float64, bool) {
+	rate := internal.FloatEnv("DD_TRACE_ANALYTICS_SAMPLE_RATE", math.NaN())
+	if math.IsNaN(rate) {
+		return rate, false
+	}
+	if rate < 0 || rate > 1 {
+		log.Warn("Ignoring DD_TRACE_ANALYTICS_SAMPLE_RATE=%f: it must be between 0 and 1.", rate)
+		return math.NaN(), false
+	}
+	return rate, true
+}
+
 func (i *Instrumentation) GlobalAnalyticsRate() float64 {
 	return globalconfig.AnalyticsRate()
 }
@@ -160,6 +188,13 @@ func NewHeaderTags(headers []string) HeaderTags {
 	return internal.NewLockMap(headerTagsMap)
 }
 
+// NewResponseHeaderTags is like NewHeaderTags, for the headers of HTTP responses, which are
+// tagged as http.response.headers.<header> unless mapped to another tag.
+func NewResponseHeaderTags(headers []string) HeaderTags {
+	headerTagsMap := normalizer.ResponseHeaderTagSlice(headers)
+	return internal.NewLockMap(headerTagsMap)
+}
+
 func (i *Instrumentation) HTTPHeadersAsTags() HeaderTags {
 	return globalconfig.HeaderTagMap()
 }
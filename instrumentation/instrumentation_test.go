@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024 Datadog, Inc.
+
+package instrumentation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/DataDog/dd-trace-go/v2/internal/globalconfig"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyticsRate(t *testing.T) {
+	instr := Load(PackageRedigo)
+	prev := globalconfig.AnalyticsRate()
+	globalconfig.SetAnalyticsRate(0.4)
+	t.Cleanup(func() { globalconfig.SetAnalyticsRate(prev) })
+
+	t.Run("default", func(t *testing.T) {
+		assert.True(t, math.IsNaN(instr.AnalyticsRate(false)))
+		assert.Equal(t, 0.4, instr.AnalyticsRate(true))
+	})
+
+	t.Run("all-integrations", func(t *testing.T) {
+		t.Setenv("DD_TRACE_ANALYTICS_SAMPLE_RATE", "0.25")
+		assert.Equal(t, 0.25, instr.AnalyticsRate(false))
+		assert.Equal(t, 0.25, instr.AnalyticsRate(true))
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Setenv("DD_TRACE_ANALYTICS_SAMPLE_RATE", "0.25")
+		t.Setenv("DD_TRACE_REDIGO_ANALYTICS_ENABLED", "true")
+		assert.Equal(t, 1.0, instr.AnalyticsRate(false))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Setenv("DD_TRACE_ANALYTICS_SAMPLE_RATE", "2")
+		assert.True(t, math.IsNaN(instr.AnalyticsRate(false)))
+		assert.Equal(t, 0.4, instr.AnalyticsRate(true))
+	})
+}
@@ -0,0 +1,43 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package instrumentation
+
+import (
+	"sync/atomic"
+
+	"github.com/DataDog/dd-trace-go/v2/internal"
+	"github.com/DataDog/dd-trace-go/v2/internal/log"
+	"github.com/DataDog/dd-trace-go/v2/internal/obfuscation"
+)
+
+var sqlObfuscation atomic.Bool
+
+func init() {
+	loadSQLObfuscation()
+}
+
+// loadSQLObfuscation reads from DD_TRACE_SQL_OBFUSCATION_ENABLED whether the SQL queries
+// are obfuscated before being set as the resource of the spans, which is disabled by default.
+func loadSQLObfuscation() {
+	sqlObfuscation.Store(internal.BoolEnv("DD_TRACE_SQL_OBFUSCATION_ENABLED", false))
+}
+
+// ObfuscateSQL returns query normalized the way the agent does it, with its literals
+// replaced by '?' and its IN lists collapsed, if SQL obfuscation is enabled with
+// DD_TRACE_SQL_OBFUSCATION_ENABLED; otherwise, query is returned as is. Integrations
+// should use it on the queries they set as resource names, so that the literals of the
+// queries, which can hold sensitive data, don't leave the application. The queries are
+// obfuscated by the obfuscator of the tracer, configured after the features of the agent.
+func (i *Instrumentation) ObfuscateSQL(query string) string {
+	if !sqlObfuscation.Load() || query == "" {
+		return query
+	}
+	oq, err := obfuscation.ObfuscateSQL(query)
+	if err != nil {
+		log.Debug("Error obfuscating SQL query: %s", err.Error())
+	}
+	return oq
+}
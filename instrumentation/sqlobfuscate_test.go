@@ -0,0 +1,34 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package instrumentation
+
+import (
+	"testing"
+
+	"github.com/DataDog/dd-trace-go/v2/internal/obfuscation"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscateSQL(t *testing.T) {
+	instr := Load(PackageDatabaseSQL)
+	const query = "SELECT * FROM users WHERE name = 'alice' AND id IN (1, 2, 3) AND age > 42"
+	t.Cleanup(loadSQLObfuscation)
+
+	t.Run("default", func(t *testing.T) {
+		loadSQLObfuscation()
+		assert.Equal(t, query, instr.ObfuscateSQL(query))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		t.Setenv("DD_TRACE_SQL_OBFUSCATION_ENABLED", "true")
+		ReloadConfig()
+		assert.Equal(t, "SELECT * FROM users WHERE name = ? AND id IN ( ? ) AND age > ?", instr.ObfuscateSQL(query))
+		assert.Equal(t, "SELECT * FROM users WHERE id = ?", instr.ObfuscateSQL("SELECT * FROM users WHERE id = ?"))
+		assert.Empty(t, instr.ObfuscateSQL(""))
+		assert.Equal(t, obfuscation.NonParsableSQL, instr.ObfuscateSQL("SELECT 'unterminated"))
+	})
+}
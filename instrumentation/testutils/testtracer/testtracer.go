@@ -73,6 +73,9 @@ type SpanLink struct {
 type TestTracer struct {
 	Spans        <-chan Span
 	roundTripper *mockTransport
+
+	mu      sync.Mutex
+	headers []http.Header
 }
 
 // Start calls [tracer.Start] with a mocked transport and provides a new [TestTracer] that allows to inspect
@@ -102,6 +105,9 @@ func Start(t *testing.T, opts ...Option) *TestTracer {
 		tracer.WithHTTPClient(httpClient),
 		tracer.WithLogger(&testLogger{T: t}),
 	}, cfg.TracerStartOpts...)
+	for k, v := range cfg.GlobalTags {
+		startOpts = append(startOpts, tracer.WithGlobalTag(k, v))
+	}
 
 	err := tracer.Start(startOpts...)
 	require.NoError(t, err)
@@ -112,6 +118,7 @@ func Start(t *testing.T, opts ...Option) *TestTracer {
 type config struct {
 	TracerStartOpts   []tracer.StartOption
 	AgentInfoResponse AgentInfo
+	GlobalTags        map[string]any
 }
 
 func defaultConfig() *config {
@@ -138,6 +145,51 @@ func WithAgentInfoResponse(response AgentInfo) Option {
 	}
 }
 
+// WithGlobalTags sets tags on every span created by the tracer, like [tracer.WithGlobalTag]. Tags set by
+// successive calls are merged.
+func WithGlobalTags(tags map[string]any) Option {
+	return func(cfg *config) {
+		if cfg.GlobalTags == nil {
+			cfg.GlobalTags = make(map[string]any, len(tags))
+		}
+		for k, v := range tags {
+			cfg.GlobalTags[k] = v
+		}
+	}
+}
+
+// Transport wraps base, or [http.DefaultTransport] if nil, into an [http.RoundTripper] recording the headers of
+// the outbound requests it sends, so that the propagation headers injected by an integration can be inspected
+// with [TestTracer.InjectedHeaders]. The mocked agent transport only sees the requests of the tracer, so the
+// client under test must be configured to use it.
+func (tt *TestTracer) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		tt.mu.Lock()
+		tt.headers = append(tt.headers, r.Header.Clone())
+		tt.mu.Unlock()
+		return base.RoundTrip(r)
+	})
+}
+
+// InjectedHeaders returns the headers of the outbound requests sent through [TestTracer.Transport], in the order
+// they were sent.
+func (tt *TestTracer) InjectedHeaders() []http.Header {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	headers := make([]http.Header, len(tt.headers))
+	copy(headers, tt.headers)
+	return headers
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
 // Stop stops the tracer. It should be called after the test finishes.
 func (tt *TestTracer) Stop() {
 	tt.roundTripper.Stop()
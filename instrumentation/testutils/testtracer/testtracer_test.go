@@ -0,0 +1,43 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2025 Datadog, Inc.
+
+package testtracer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/tracer"
+)
+
+func TestGlobalTagsAndInjectedHeaders(t *testing.T) {
+	tt := Start(t, WithGlobalTags(map[string]any{"team": "apm"}), WithGlobalTags(map[string]any{"tier": "1"}))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+	client := &http.Client{Transport: tt.Transport(nil)}
+
+	span := tracer.StartSpan("client.request")
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	require.NoError(t, tracer.Inject(span.Context(), tracer.HTTPHeadersCarrier(req.Header)))
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	span.Finish()
+
+	spans := tt.WaitForSpans(t, 1)
+	assert.Equal(t, "apm", spans[0].Meta["team"])
+	assert.Equal(t, "1", spans[0].Meta["tier"])
+
+	headers := tt.InjectedHeaders()
+	require.Len(t, headers, 1)
+	assert.Equal(t, strconv.FormatUint(spans[0].SpanID, 10), headers[0].Get("x-datadog-parent-id"))
+}
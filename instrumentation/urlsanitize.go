@@ -0,0 +1,113 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024 Datadog, Inc.
+
+package instrumentation
+
+import (
+	"hash/fnv"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/DataDog/dd-trace-go/v2/internal/log"
+)
+
+// urlSanitizePolicy specifies how the URLs tagged by the integrations are sanitized,
+// see DD_TRACE_URL_SANITIZE.
+type urlSanitizePolicy struct {
+	userinfo  bool // strip the userinfo, e.g. user:password@
+	dropQuery bool // drop the query string
+	hashQuery bool // replace the values of the query parameters with their hash
+}
+
+var urlPolicy atomic.Pointer[urlSanitizePolicy]
+
+func init() {
+	loadURLSanitizePolicy()
+}
+
+// loadURLSanitizePolicy reads the URL sanitization policy from DD_TRACE_URL_SANITIZE, a
+// comma-separated list of:
+//   - userinfo: strip the credentials of URLs, which is always done unless none is given;
+//   - query: drop query strings;
+//   - hash_query: replace the values of the query parameters with their hash, which
+//     keeps them comparable without revealing them;
+//   - none: don't sanitize URLs at all, overriding the other rules.
+func loadURLSanitizePolicy() {
+	p := &urlSanitizePolicy{userinfo: true}
+	none := false
+	for _, rule := range strings.Split(os.Getenv("DD_TRACE_URL_SANITIZE"), ",") {
+		switch strings.ToLower(strings.TrimSpace(rule)) {
+		case "userinfo", "":
+		case "query":
+			p.dropQuery = true
+		case "hash_query":
+			p.hashQuery = true
+		case "none":
+			none = true
+		default:
+			log.Warn("Ignoring unknown URL sanitization rule %q in DD_TRACE_URL_SANITIZE.", rule)
+		}
+	}
+	if none {
+		p = &urlSanitizePolicy{}
+	}
+	urlPolicy.Store(p)
+}
+
+// SanitizeURL returns rawURL sanitized with the URL sanitization policy set for all the
+// integrations by DD_TRACE_URL_SANITIZE, which strips the credentials of the userinfo
+// by default, and can drop or hash query strings. Integrations should use it on the URLs
+// they tag, since they can hold credentials or tokens. An unparsable URL is returned as is.
+func (i *Instrumentation) SanitizeURL(rawURL string) string {
+	p := urlPolicy.Load()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	changed := false
+	if p.userinfo && u.User != nil {
+		u.User = nil
+		changed = true
+	}
+	if u.RawQuery != "" && (p.dropQuery || p.hashQuery) {
+		u.RawQuery = sanitizeQuery(p, u.RawQuery)
+		changed = true
+	}
+	if !changed {
+		return rawURL
+	}
+	return u.String()
+}
+
+// SanitizeQuery returns rawQuery, a URL query string without the leading '?', sanitized
+// with the URL sanitization policy, see SanitizeURL.
+func (i *Instrumentation) SanitizeQuery(rawQuery string) string {
+	return sanitizeQuery(urlPolicy.Load(), rawQuery)
+}
+
+func sanitizeQuery(p *urlSanitizePolicy, rawQuery string) string {
+	switch {
+	case rawQuery == "":
+		return rawQuery
+	case p.dropQuery:
+		return ""
+	case !p.hashQuery:
+		return rawQuery
+	}
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		k, v, ok := strings.Cut(param, "=")
+		if !ok || v == "" {
+			continue
+		}
+		h := fnv.New64a()
+		h.Write([]byte(v))
+		params[i] = k + "=" + strconv.FormatUint(h.Sum64(), 16)
+	}
+	return strings.Join(params, "&")
+}
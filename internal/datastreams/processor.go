@@ -190,6 +190,9 @@ type Processor struct {
 	stopped              uint64
 	stop                 chan struct{} // closing this channel triggers shutdown
 	flushRequest         chan chan<- struct{}
+	idleTimeout          time.Duration // zero disables parking, see SetIdleTimeout
+	parked               int32         // set to 1 while the processor is parked
+	wake                 chan struct{} // wakes up a parked processor
 	stats                processorStats
 	transport            *httpTransport
 	statsd               internal.StatsdClient
@@ -208,7 +211,7 @@ func (p *Processor) time() time.Time {
 	return time.Now()
 }
 
-func NewProcessor(statsd internal.StatsdClient, env, service, version string, agentURL *url.URL, httpClient *http.Client) *Processor {
+func NewProcessor(statsd internal.StatsdClient, env, service, version string, agentURL *url.URL, httpClient *http.Client, opts ...TransportOption) *Processor {
 	if service == "" {
 		service = defaultServiceName
 	}
@@ -217,12 +220,13 @@ func NewProcessor(statsd internal.StatsdClient, env, service, version string, ag
 		tsTypeOriginBuckets:  make(map[bucketKey]bucket),
 		hashCache:            newHashCache(),
 		in:                   newFastQueue(),
+		wake:                 make(chan struct{}, 1),
 		stopped:              1,
 		statsd:               statsd,
 		env:                  env,
 		service:              service,
 		version:              version,
-		transport:            newHTTPTransport(agentURL, httpClient),
+		transport:            newHTTPTransport(agentURL, httpClient, opts...),
 		timeSource:           time.Now,
 	}
 	return p
@@ -318,6 +322,7 @@ func (p *Processor) flushInput() {
 }
 
 func (p *Processor) run(tick <-chan time.Time) {
+	lastInput := p.time()
 	for {
 		select {
 		case <-p.stop:
@@ -326,6 +331,10 @@ func (p *Processor) run(tick <-chan time.Time) {
 			return
 		case now := <-tick:
 			p.sendToAgent(p.flush(now))
+			if p.idle(now, lastInput) {
+				p.park()
+				lastInput = p.time()
+			}
 		case done := <-p.flushRequest:
 			p.flushInput()
 			p.sendToAgent(p.flush(time.Now().Add(bucketDuration * 10)))
@@ -336,11 +345,61 @@ func (p *Processor) run(tick <-chan time.Time) {
 				time.Sleep(time.Millisecond * 10)
 				continue
 			}
+			lastInput = p.time()
 			p.processInput(s)
 		}
 	}
 }
 
+// SetIdleTimeout makes the processor park its goroutine once it hasn't received any
+// checkpoint for d and all its buckets were flushed, instead of polling its input queue.
+// It resumes on the next checkpoint or flush. Zero, the default, disables parking.
+// It must be called before Start.
+func (p *Processor) SetIdleTimeout(d time.Duration) {
+	p.idleTimeout = d
+}
+
+// idle reports whether the processor received no input for the idle timeout and has
+// nothing left to flush, so that it can be parked without holding back any data.
+func (p *Processor) idle(now, lastInput time.Time) bool {
+	if p.idleTimeout <= 0 || now.Sub(lastInput) < p.idleTimeout {
+		return false
+	}
+	return len(p.tsTypeCurrentBuckets) == 0 && len(p.tsTypeOriginBuckets) == 0
+}
+
+// park blocks until new input is pushed, a flush is requested or the processor stops.
+func (p *Processor) park() {
+	atomic.StoreInt32(&p.parked, 1)
+	defer atomic.StoreInt32(&p.parked, 0)
+	if p.in.writePos.Load() > p.in.readPos.Load() {
+		// input was pushed before parked was set, so it didn't wake us up
+		return
+	}
+	log.Debug("Data streams processor idle for %s, parking until the next checkpoint.", p.idleTimeout)
+	select {
+	case <-p.wake:
+	case <-p.stop:
+	}
+}
+
+// push adds in to the input queue, waking up the processor if it is parked.
+func (p *Processor) push(in *processorInput) (dropped bool) {
+	dropped = p.in.push(in)
+	if atomic.LoadInt32(&p.parked) == 1 {
+		p.wakeUp()
+	}
+	return dropped
+}
+
+// wakeUp makes a parked processor resume, or the next call to park return right away.
+func (p *Processor) wakeUp() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
 func (p *Processor) Start() {
 	if atomic.SwapUint64(&p.stopped, 0) == 0 {
 		// already running
@@ -369,6 +428,7 @@ func (p *Processor) Flush() {
 		return
 	}
 	done := make(chan struct{})
+	p.wakeUp()
 	select {
 	case p.flushRequest <- done:
 		<-done
@@ -478,7 +538,7 @@ func (p *Processor) SetCheckpointWithParams(ctx context.Context, params options.
 		pathwayStart: pathwayStart,
 		edgeStart:    now,
 	}
-	dropped := p.in.push(&processorInput{typ: pointTypeStats, point: statsPoint{
+	dropped := p.push(&processorInput{typ: pointTypeStats, point: statsPoint{
 		serviceName:    service,
 		edgeTags:       edgeTags,
 		parentHash:     parentHash,
@@ -495,7 +555,7 @@ func (p *Processor) SetCheckpointWithParams(ctx context.Context, params options.
 }
 
 func (p *Processor) TrackKafkaCommitOffset(group string, topic string, partition int32, offset int64) {
-	dropped := p.in.push(&processorInput{typ: pointTypeKafkaOffset, kafkaOffset: kafkaOffset{
+	dropped := p.push(&processorInput{typ: pointTypeKafkaOffset, kafkaOffset: kafkaOffset{
 		offset:     offset,
 		group:      group,
 		topic:      topic,
@@ -508,7 +568,7 @@ func (p *Processor) TrackKafkaCommitOffset(group string, topic string, partition
 }
 
 func (p *Processor) TrackKafkaProduceOffset(topic string, partition int32, offset int64) {
-	dropped := p.in.push(&processorInput{typ: pointTypeKafkaOffset, kafkaOffset: kafkaOffset{
+	dropped := p.push(&processorInput{typ: pointTypeKafkaOffset, kafkaOffset: kafkaOffset{
 		offset:     offset,
 		topic:      topic,
 		partition:  partition,
@@ -523,7 +583,7 @@ func (p *Processor) TrackKafkaProduceOffset(topic string, partition int32, offse
 // TrackKafkaHighWatermarkOffset should be used in the consumer, to track the high watermark offsets of each partition.
 // The first argument is the Kafka cluster ID, and will be used later.
 func (p *Processor) TrackKafkaHighWatermarkOffset(_ string, topic string, partition int32, offset int64) {
-	dropped := p.in.push(&processorInput{typ: pointTypeKafkaOffset, kafkaOffset: kafkaOffset{
+	dropped := p.push(&processorInput{typ: pointTypeKafkaOffset, kafkaOffset: kafkaOffset{
 		offset:     offset,
 		topic:      topic,
 		partition:  partition,
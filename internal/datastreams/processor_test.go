@@ -11,6 +11,7 @@ import (
 	"net/url"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -333,6 +334,25 @@ func TestSetCheckpoint(t *testing.T) {
 	assert.Equal(t, statsPt2.hash, pathway.GetHash())
 }
 
+func TestSetCheckpointResource(t *testing.T) {
+	processor := Processor{
+		hashCache:  newHashCache(),
+		stopped:    1,
+		in:         newFastQueue(),
+		service:    "service-1",
+		env:        "env",
+		timeSource: time.Now,
+	}
+	processor.SetCheckpoint(context.Background(), "direction:in", "resource:GET /users", "type:http")
+	processor.SetCheckpoint(context.Background(), "direction:in", "resource:GET /orders", "type:http")
+
+	statsPt1 := processor.in.pop().point
+	statsPt2 := processor.in.pop().point
+
+	assert.Equal(t, pathwayHash(nodeHash("service-1", "env", []string{"direction:in", "resource:GET /users", "type:http"}, nil), 0), statsPt1.hash)
+	assert.NotEqual(t, statsPt1.hash, statsPt2.hash)
+}
+
 func TestSetCheckpointProcessTags(t *testing.T) {
 	t.Setenv("DD_EXPERIMENTAL_PROPAGATE_PROCESS_TAGS_ENABLED", "true")
 	processtags.Reload()
@@ -392,6 +412,53 @@ func TestKafkaLag(t *testing.T) {
 	assert.Equal(t, expectedBacklogs, payloads["service"].Stats[0].Backlogs)
 }
 
+func TestProcessorIdleTimeout(t *testing.T) {
+	client := &http.Client{Transport: &noOpTransport{}}
+	p := NewProcessor(&statsd.NoOpClientDirect{}, "env", "service", "v1", &url.URL{Scheme: "http", Host: "agent-address"}, client)
+	p.SetIdleTimeout(time.Minute)
+	p.stop = make(chan struct{})
+	p.flushRequest = make(chan chan<- struct{})
+	atomic.StoreUint64(&p.stopped, 0)
+	tick := make(chan time.Time)
+	done := make(chan struct{})
+	go func() {
+		p.run(tick)
+		close(done)
+	}()
+	isParked := func() bool { return atomic.LoadInt32(&p.parked) == 1 }
+
+	tick <- time.Now().Add(time.Second)
+	assert.Never(t, isParked, 50*time.Millisecond, time.Millisecond, "not idle for long enough")
+
+	tick <- time.Now().Add(time.Minute)
+	require.Eventually(t, isParked, time.Second, time.Millisecond)
+
+	// flushing wakes up a parked processor instead of blocking
+	p.Flush()
+	assert.False(t, isParked())
+	tick <- time.Now().Add(time.Minute)
+	require.Eventually(t, isParked, time.Second, time.Millisecond)
+
+	p.SetCheckpoint(context.Background(), "type:kafka", "topic:topic1")
+	require.Eventually(t, func() bool { return atomic.LoadInt64(&p.stats.payloadsIn) == 1 }, time.Second, time.Millisecond)
+	assert.False(t, isParked())
+
+	// the checkpoint's bucket is flushed by the tick, so it can park again
+	tick <- time.Now().Add(time.Minute)
+	require.Eventually(t, isParked, time.Second, time.Millisecond)
+
+	close(p.stop)
+	<-done
+
+	// buckets which weren't flushed yet prevent parking
+	now := time.Now()
+	p = NewProcessor(&statsd.NoOpClientDirect{}, "env", "service", "v1", &url.URL{Scheme: "http", Host: "agent-address"}, client)
+	p.SetIdleTimeout(time.Second)
+	assert.True(t, p.idle(now.Add(time.Minute), now))
+	p.addToBuckets(statsPoint{serviceName: "service", hash: 1}, now.UnixNano(), p.tsTypeCurrentBuckets)
+	assert.False(t, p.idle(now.Add(time.Minute), now))
+}
+
 type noOpTransport struct{}
 
 // RoundTrip does nothing and returns a dummy response.
@@ -21,19 +21,75 @@ import (
 )
 
 type httpTransport struct {
-	url     string            // the delivery URL for stats
-	client  *http.Client      // the HTTP client used in the POST
-	headers map[string]string // the Transport headers
+	url      string            // the delivery URL for stats
+	client   *http.Client      // the HTTP client used in the POST
+	headers  map[string]string // the Transport headers
+	compress bool              // whether payloads are gzip compressed
 }
 
-func newHTTPTransport(agentURL *url.URL, client *http.Client) *httpTransport {
+// defaultEndpointPath is the agent path which pipeline stats are posted to.
+const defaultEndpointPath = "/v0.1/pipeline_stats"
+
+// TransportOption configures how a Processor delivers pipeline stats to the agent.
+type TransportOption func(*transportConfig)
+
+type transportConfig struct {
+	path               string            // the agent path which stats are posted to
+	headers            map[string]string // extra headers added to every request
+	disableCompression bool              // send payloads uncompressed
+}
+
+// WithEndpointPath sets the path, relative to the agent URL, which pipeline stats are
+// posted to. It is useful when the agent sits behind a proxy which expects a different
+// route. Defaults to "/v0.1/pipeline_stats".
+func WithEndpointPath(path string) TransportOption {
+	return func(c *transportConfig) {
+		if path == "" {
+			return
+		}
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		c.path = path
+	}
+}
+
+// WithHeaders adds the given headers, such as authentication headers expected by an
+// agent proxy, to every request sending pipeline stats. They take precedence over the
+// default headers of the same name.
+func WithHeaders(headers map[string]string) TransportOption {
+	return func(c *transportConfig) {
+		if c.headers == nil {
+			c.headers = make(map[string]string, len(headers))
+		}
+		for k, v := range headers {
+			c.headers[k] = v
+		}
+	}
+}
+
+// WithCompression sets whether pipeline stats payloads are gzip compressed before
+// being sent. Compression is enabled by default.
+func WithCompression(enabled bool) TransportOption {
+	return func(c *transportConfig) {
+		c.disableCompression = !enabled
+	}
+}
+
+func newHTTPTransport(agentURL *url.URL, client *http.Client, opts ...TransportOption) *httpTransport {
+	cfg := transportConfig{path: defaultEndpointPath}
+	for _, fn := range opts {
+		fn(&cfg)
+	}
 	// initialize the default EncoderPool with Encoder headers
 	defaultHeaders := map[string]string{
 		"Datadog-Meta-Lang":             "go",
 		"Datadog-Meta-Lang-Version":     strings.TrimPrefix(runtime.Version(), "go"),
 		"Datadog-Meta-Lang-Interpreter": runtime.Compiler + "-" + runtime.GOARCH + "-" + runtime.GOOS,
 		"Content-Type":                  "application/msgpack",
-		"Content-Encoding":              "gzip",
+	}
+	if !cfg.disableCompression {
+		defaultHeaders["Content-Encoding"] = "gzip"
 	}
 	if cid := internal.ContainerID(); cid != "" {
 		defaultHeaders["Datadog-Container-ID"] = cid
@@ -41,26 +97,37 @@ func newHTTPTransport(agentURL *url.URL, client *http.Client) *httpTransport {
 	if entityID := internal.ContainerID(); entityID != "" {
 		defaultHeaders["Datadog-Entity-ID"] = entityID
 	}
-	url := fmt.Sprintf("%s/v0.1/pipeline_stats", agentURL.String())
+	for k, v := range cfg.headers {
+		defaultHeaders[k] = v
+	}
+	url := fmt.Sprintf("%s%s", strings.TrimSuffix(agentURL.String(), "/"), cfg.path)
 	return &httpTransport{
-		url:     url,
-		client:  client,
-		headers: defaultHeaders,
+		url:      url,
+		client:   client,
+		headers:  defaultHeaders,
+		compress: !cfg.disableCompression,
 	}
 }
 
 func (t *httpTransport) sendPipelineStats(p *StatsPayload) error {
 	var buf bytes.Buffer
-	gzipWriter, err := gzip.NewWriterLevel(&buf, gzip.BestSpeed)
-	if err != nil {
-		return err
+	var w io.Writer = &buf
+	var gzipWriter *gzip.Writer
+	if t.compress {
+		var err error
+		gzipWriter, err = gzip.NewWriterLevel(&buf, gzip.BestSpeed)
+		if err != nil {
+			return err
+		}
+		w = gzipWriter
 	}
-	if err := msgp.Encode(gzipWriter, p); err != nil {
+	if err := msgp.Encode(w, p); err != nil {
 		return err
 	}
-	err = gzipWriter.Close()
-	if err != nil {
-		return err
+	if gzipWriter != nil {
+		if err := gzipWriter.Close(); err != nil {
+			return err
+		}
 	}
 	req, err := http.NewRequest("POST", t.url, &buf)
 	if err != nil {
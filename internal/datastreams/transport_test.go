@@ -11,6 +11,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
 )
 
 type fakeTransport struct {
@@ -41,3 +43,38 @@ func TestHTTPTransport(t *testing.T) {
 	r := fakeTransport.requests[0]
 	assert.Equal(t, "http://agent-address:8126/v0.1/pipeline_stats", r.URL.String())
 }
+
+func TestHTTPTransportOptions(t *testing.T) {
+	fakeTransport := fakeTransport{}
+	transport := newHTTPTransport(
+		&url.URL{Scheme: "http", Host: "agent-address:8126", Path: "/proxy/"},
+		&http.Client{Transport: &fakeTransport},
+		WithEndpointPath("dsm/pipeline_stats"),
+		WithHeaders(map[string]string{"Authorization": "Bearer token"}),
+	)
+	assert.Nil(t, transport.sendPipelineStats(&StatsPayload{Env: "env-1"}))
+	assert.Len(t, fakeTransport.requests, 1)
+	r := fakeTransport.requests[0]
+	assert.Equal(t, "http://agent-address:8126/proxy/dsm/pipeline_stats", r.URL.String())
+	assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+	assert.Equal(t, "application/msgpack", r.Header.Get("Content-Type"))
+}
+
+func TestHTTPTransportCompression(t *testing.T) {
+	fakeTransport := fakeTransport{}
+	transport := newHTTPTransport(
+		&url.URL{Scheme: "http", Host: "agent-address:8126"},
+		&http.Client{Transport: &fakeTransport},
+		WithCompression(false),
+	)
+	assert.Nil(t, transport.sendPipelineStats(&StatsPayload{Service: "service-1"}))
+	require.Len(t, fakeTransport.requests, 1)
+	r := fakeTransport.requests[0]
+	assert.Empty(t, r.Header.Get("Content-Encoding"))
+
+	body, err := r.GetBody()
+	require.NoError(t, err)
+	var p StatsPayload
+	require.NoError(t, p.DecodeMsg(msgp.NewReader(body)))
+	assert.Equal(t, "service-1", p.Service)
+}
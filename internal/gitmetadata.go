@@ -40,8 +40,9 @@ const (
 )
 
 var (
-	initOnce        sync.Once
-	gitMetadataTags map[string]string
+	initOnce              sync.Once
+	gitMetadataTags       map[string]string
+	tracerGitMetadataTags map[string]string
 )
 
 func updateTags(tags map[string]string, key string, value string) {
@@ -75,7 +76,10 @@ func getTagsFromDDTags() map[string]string {
 	}
 }
 
-// getTagsFromBinary extracts git metadata from binary metadata.
+// getTagsFromBinary extracts git metadata from binary metadata, which holds the VCS
+// information stamped by the go command since Go 1.18, unless built with -buildvcs=false.
+// A binary built from a modified working tree reports the commit it was modified from,
+// which is the closest match of its source code.
 func getTagsFromBinary(readBuildInfo func() (*debug.BuildInfo, bool)) map[string]string {
 	res := make(map[string]string)
 	info, ok := readBuildInfo()
@@ -86,9 +90,10 @@ func getTagsFromBinary(readBuildInfo func() (*debug.BuildInfo, bool)) map[string
 	goPath := info.Path
 	var vcs, commitSha string
 	for _, s := range info.Settings {
-		if s.Key == "vcs" {
+		switch s.Key {
+		case "vcs":
 			vcs = s.Value
-		} else if s.Key == "vcs.revision" {
+		case "vcs.revision":
 			commitSha = s.Value
 		}
 	}
@@ -101,7 +106,9 @@ func getTagsFromBinary(readBuildInfo func() (*debug.BuildInfo, bool)) map[string
 	return res
 }
 
-// GetGitMetadataTags returns git metadata tags. Returned map is read-only
+// GetGitMetadataTags returns git metadata tags. Returned map is read-only.
+// The tags are read from, in order of precedence, the DD_GIT_* environment variables,
+// DD_TAGS, and the VCS information embedded in the binary.
 func GetGitMetadataTags() map[string]string {
 	initOnce.Do(initGitMetadataTags)
 	return gitMetadataTags
@@ -115,6 +122,11 @@ func initGitMetadataTags() {
 		updateAllTags(gitMetadataTags, getTagsFromDDTags())
 		updateAllTags(gitMetadataTags, getTagsFromBinary(debug.ReadBuildInfo))
 	}
+
+	tracerGitMetadataTags = make(map[string]string)
+	updateTags(tracerGitMetadataTags, TraceTagRepositoryURL, gitMetadataTags[TagRepositoryURL])
+	updateTags(tracerGitMetadataTags, TraceTagCommitSha, gitMetadataTags[TagCommitSha])
+	updateTags(tracerGitMetadataTags, TraceTagGoPath, gitMetadataTags[TagGoPath])
 }
 
 // RefreshGitMetadataTags reset cached metadata tags. NOT thread-safe, use for testing only
@@ -129,19 +141,14 @@ func CleanGitMetadataTags(tags map[string]string) {
 	delete(tags, TagGoPath)
 }
 
-// GetTracerGitMetadataTags returns git metadata tags for tracer
+// GetTracerGitMetadataTags returns git metadata tags for tracer. Returned map is read-only
 // NB: Currently tracer inject tags with some workaround
 // (only with _dd prefix and only for the first span in payload)
 // So we provide different tag names
+// The tags are computed once, as they are set on every trace chunk.
 func GetTracerGitMetadataTags() map[string]string {
-	res := make(map[string]string)
-	tags := GetGitMetadataTags()
-
-	updateTags(res, TraceTagRepositoryURL, tags[TagRepositoryURL])
-	updateTags(res, TraceTagCommitSha, tags[TagCommitSha])
-	updateTags(res, TraceTagGoPath, tags[TagGoPath])
-
-	return res
+	initOnce.Do(initGitMetadataTags)
+	return tracerGitMetadataTags
 }
 
 // removeCredentials returns the passed url with potential credentials removed.
@@ -10,6 +10,7 @@ package namingschema
 import (
 	"os"
 	"strings"
+	"sync"
 	"sync/atomic"
 
 	"github.com/DataDog/dd-trace-go/v2/internal"
@@ -36,6 +37,7 @@ type Config struct {
 var (
 	activeNamingSchema            atomic.Int32
 	removeIntegrationServiceNames atomic.Bool
+	integrationServices           sync.Map // default service names of the integrations, see RecordIntegrationService
 )
 
 func LoadFromEnv() {
@@ -96,3 +98,18 @@ func getRemoveIntegrationServiceNames() bool {
 func SetRemoveIntegrationServiceNames(v bool) {
 	removeIntegrationServiceNames.Store(v)
 }
+
+// RecordIntegrationService records service as the default service name of an integration,
+// i.e. the one it uses unless configured otherwise.
+func RecordIntegrationService(service string) {
+	if service != "" {
+		integrationServices.Store(service, struct{}{})
+	}
+}
+
+// IsIntegrationService reports whether service is the default service name of an
+// integration, as recorded by RecordIntegrationService.
+func IsIntegrationService(service string) bool {
+	_, ok := integrationServices.Load(service)
+	return ok
+}
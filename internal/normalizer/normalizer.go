@@ -24,13 +24,23 @@ var headerTagRegexp = regexp.MustCompile("[^a-zA-Z0-9 -]")
 // e.g, "first:second:third" gets split into `header = "first:second"` and `tag="third"`
 // The returned header is in canonical MIMEHeader format.
 func HeaderTag(headerAsTag string) (header string, tag string) {
+	return headerTag(headerAsTag, ext.HTTPRequestHeaders)
+}
+
+// ResponseHeaderTag is like HeaderTag, for the headers of HTTP responses, which are
+// tagged as http.response.headers.<header> by default.
+func ResponseHeaderTag(headerAsTag string) (header string, tag string) {
+	return headerTag(headerAsTag, ext.HTTPResponseHeaders)
+}
+
+func headerTag(headerAsTag, prefix string) (header string, tag string) {
 	header = strings.ToLower(strings.TrimSpace(headerAsTag))
 	// if a colon is found in `headerAsTag`
 	if last := strings.LastIndex(header, ":"); last >= 0 {
 		header, tag = header[:last], header[last+1:]
 		header, tag = strings.TrimSpace(header), strings.TrimSpace(tag)
 	} else {
-		tag = ext.HTTPRequestHeaders + "." + headerTagRegexp.ReplaceAllString(header, "_")
+		tag = prefix + "." + headerTagRegexp.ReplaceAllString(header, "_")
 	}
 	return textproto.CanonicalMIMEHeaderKey(header), tag
 }
@@ -38,9 +48,19 @@ func HeaderTag(headerAsTag string) (header string, tag string) {
 // HeaderTagSlice accepts a slice of strings that contain headers and optional mapped tag key.
 // See HeaderTag for details on formatting.
 func HeaderTagSlice(headers []string) map[string]string {
+	return headerTagSlice(headers, HeaderTag)
+}
+
+// ResponseHeaderTagSlice is like HeaderTagSlice, for the headers of HTTP responses.
+// See ResponseHeaderTag for details on formatting.
+func ResponseHeaderTagSlice(headers []string) map[string]string {
+	return headerTagSlice(headers, ResponseHeaderTag)
+}
+
+func headerTagSlice(headers []string, headerTag func(string) (string, string)) map[string]string {
 	headerTagsMap := make(map[string]string)
 	for _, h := range headers {
-		header, tag := HeaderTag(h)
+		header, tag := headerTag(h)
 		// If `header` or `tag` is just the empty string, we don't want to set it.
 		if len(header) == 0 || len(tag) == 0 {
 			log.Debug("Header-tag input is in unsupported format; dropping input value %s", h)
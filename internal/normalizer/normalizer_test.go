@@ -122,3 +122,15 @@ func TestHeaderTag(t *testing.T) {
 		assert.Equal(t, "", tag)
 	})
 }
+
+func TestResponseHeaderTag(t *testing.T) {
+	header, tag := ResponseHeaderTag("X-RateLimit-Remaining")
+	assert.Equal(t, "X-Ratelimit-Remaining", header)
+	assert.Equal(t, ext.HTTPResponseHeaders+".x-ratelimit-remaining", tag)
+
+	hMap := ResponseHeaderTagSlice([]string{"x-quota", "x-region:region"})
+	assert.Equal(t, map[string]string{
+		"X-Quota":  ext.HTTPResponseHeaders + ".x-quota",
+		"X-Region": "region",
+	}, hMap)
+}
@@ -0,0 +1,64 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package obfuscation holds the obfuscator shared by the tracer, which obfuscates the
+// resources of the spans it computes stats for, and by the integrations, which obfuscate
+// the queries they set as resources, so that both match what the agent produces.
+package obfuscation
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/DataDog/datadog-agent/pkg/obfuscate"
+)
+
+// NonParsableSQL is the resource of the SQL queries which can't be obfuscated, which is
+// the one used by the agent.
+const NonParsableSQL = "Non-parsable SQL query"
+
+var current atomic.Pointer[obfuscate.Obfuscator]
+
+// defaultObfuscator is used until the tracer sets its obfuscator with SetObfuscator.
+var defaultObfuscator = sync.OnceValue(func() *obfuscate.Obfuscator {
+	return obfuscate.NewObfuscator(Config(func(string) bool { return false }))
+})
+
+// Config returns the configuration of the obfuscator matching the agent, hasFlag
+// reporting whether the agent has a feature flag enabled.
+func Config(hasFlag func(flag string) bool) obfuscate.Config {
+	return obfuscate.Config{
+		SQL: obfuscate.SQLConfig{
+			TableNames:       hasFlag("table_names"),
+			ReplaceDigits:    hasFlag("quantize_sql_tables") || hasFlag("replace_sql_digits"),
+			KeepSQLAlias:     hasFlag("keep_sql_alias"),
+			DollarQuotedFunc: hasFlag("dollar_quoted_func"),
+		},
+	}
+}
+
+// SetObfuscator sets the obfuscator used by ObfuscateSQL to o, the one of the tracer.
+func SetObfuscator(o *obfuscate.Obfuscator) {
+	current.Store(o)
+}
+
+// Obfuscator returns the obfuscator set with SetObfuscator, or one with the default
+// configuration if none was set.
+func Obfuscator() *obfuscate.Obfuscator {
+	if o := current.Load(); o != nil {
+		return o
+	}
+	return defaultObfuscator()
+}
+
+// ObfuscateSQL returns query obfuscated by Obfuscator, or NonParsableSQL along with the
+// error if it can't be obfuscated.
+func ObfuscateSQL(query string) (string, error) {
+	oq, err := Obfuscator().ObfuscateSQLString(query)
+	if err != nil {
+		return NonParsableSQL, err
+	}
+	return oq.Query, nil
+}
@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package obfuscation
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/obfuscate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig(t *testing.T) {
+	flags := map[string]bool{"table_names": true, "replace_sql_digits": true}
+	cfg := Config(func(flag string) bool { return flags[flag] })
+	assert.True(t, cfg.SQL.TableNames)
+	assert.True(t, cfg.SQL.ReplaceDigits)
+	assert.False(t, cfg.SQL.KeepSQLAlias)
+	assert.False(t, cfg.SQL.DollarQuotedFunc)
+}
+
+func TestObfuscateSQL(t *testing.T) {
+	t.Cleanup(func() { SetObfuscator(nil) })
+	const query = "SELECT * FROM users_2024 WHERE id = 42"
+
+	oq, err := ObfuscateSQL(query)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM users_2024 WHERE id = ?", oq)
+
+	SetObfuscator(obfuscate.NewObfuscator(Config(func(flag string) bool { return flag == "replace_sql_digits" })))
+	oq, err = ObfuscateSQL(query)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM users_? WHERE id = ?", oq)
+
+	oq, err = ObfuscateSQL("SELECT 'unterminated")
+	assert.Error(t, err)
+	assert.Equal(t, NonParsableSQL, oq)
+}
@@ -67,6 +67,17 @@ func (p *ProcessTags) Slice() []string {
 	return p.slice
 }
 
+// Get returns the value of the process tag with the given key, if any.
+func (p *ProcessTags) Get(key string) (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	v, ok := p.tags[key]
+	return v, ok
+}
+
 func (p *ProcessTags) merge(newTags map[string]string) {
 	if len(newTags) == 0 {
 		return
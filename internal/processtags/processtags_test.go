@@ -25,6 +25,12 @@ func TestProcessTags(t *testing.T) {
 
 		assert.NotEmpty(t, p.Slice())
 		assert.Regexp(t, wantTagsRe, strings.Join(p.Slice(), ","), "wrong slice serialized tags")
+
+		typ, ok := p.Get("entrypoint.type")
+		assert.True(t, ok)
+		assert.Equal(t, "executable", typ)
+		_, ok = p.Get("unknown")
+		assert.False(t, ok)
 	})
 
 	t.Run("disabled", func(t *testing.T) {
@@ -35,5 +41,7 @@ func TestProcessTags(t *testing.T) {
 		assert.Nil(t, p)
 		assert.Empty(t, p.String())
 		assert.Empty(t, p.Slice())
+		_, ok := p.Get("entrypoint.name")
+		assert.False(t, ok)
 	})
 }
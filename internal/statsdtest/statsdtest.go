@@ -24,6 +24,7 @@ const (
 	callTypeCount
 	callTypeCountWithTimestamp
 	callTypeTiming
+	callTypeDistribution
 )
 
 var _ internal.StatsdClient = &TestStatsdClient{}
@@ -34,6 +35,7 @@ type TestStatsdClient struct {
 	incrCalls   []TestStatsdCall
 	countCalls  []TestStatsdCall
 	timingCalls []TestStatsdCall
+	distCalls   []TestStatsdCall
 	counts      map[string]int64
 	tags        []string
 	n           int
@@ -62,6 +64,10 @@ func (t TestStatsdCall) IntVal() int64 {
 	return t.intVal
 }
 
+func (t TestStatsdCall) FloatVal() float64 {
+	return t.floatVal
+}
+
 func (tg *TestStatsdClient) addCount(name string, value int64) {
 	tg.mu.Lock()
 	defer tg.mu.Unlock()
@@ -120,8 +126,19 @@ func (tg *TestStatsdClient) CountWithTimestamp(name string, value int64, tags []
 	})
 }
 
-func (tg *TestStatsdClient) DistributionSamples(_ string, _ []float64, _ []string, _ float64) error {
-	panic("not implemented")
+// DistributionSamples records one call per sample value.
+func (tg *TestStatsdClient) DistributionSamples(name string, values []float64, tags []string, rate float64) error {
+	for _, v := range values {
+		if err := tg.addMetric(callTypeDistribution, tags, TestStatsdCall{
+			name:     name,
+			floatVal: v,
+			tags:     make([]string, len(tags)),
+			rate:     rate,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (tg *TestStatsdClient) Timing(name string, value time.Duration, tags []string, rate float64) error {
@@ -146,6 +163,8 @@ func (tg *TestStatsdClient) addMetric(ct callType, tags []string, c TestStatsdCa
 		tg.countCalls = append(tg.countCalls, c)
 	case callTypeTiming:
 		tg.timingCalls = append(tg.timingCalls, c)
+	case callTypeDistribution:
+		tg.distCalls = append(tg.distCalls, c)
 	}
 	tg.tags = tags
 	tg.n++
@@ -196,6 +215,14 @@ func (tg *TestStatsdClient) TimingCalls() []TestStatsdCall {
 	return c
 }
 
+func (tg *TestStatsdClient) DistributionCalls() []TestStatsdCall {
+	tg.mu.RLock()
+	defer tg.mu.RUnlock()
+	c := make([]TestStatsdCall, len(tg.distCalls))
+	copy(c, tg.distCalls)
+	return c
+}
+
 func (tg *TestStatsdClient) CallNames() []string {
 	tg.mu.RLock()
 	defer tg.mu.RUnlock()
@@ -212,6 +239,9 @@ func (tg *TestStatsdClient) CallNames() []string {
 	for _, c := range tg.timingCalls {
 		n = append(n, c.name)
 	}
+	for _, c := range tg.distCalls {
+		n = append(n, c.name)
+	}
 	return n
 }
 
@@ -231,6 +261,9 @@ func (tg *TestStatsdClient) CallsByName() map[string]int {
 	for _, c := range tg.timingCalls {
 		counts[c.name]++
 	}
+	for _, c := range tg.distCalls {
+		counts[c.name]++
+	}
 	return counts
 }
 
@@ -259,6 +292,11 @@ func (tg *TestStatsdClient) GetCallsByName(name string) (calls []TestStatsdCall)
 			calls = append(calls, c)
 		}
 	}
+	for _, c := range tg.distCalls {
+		if c.Name() == name {
+			calls = append(calls, c)
+		}
+	}
 	return calls
 }
 
@@ -310,6 +348,7 @@ func (tg *TestStatsdClient) Reset() {
 	tg.incrCalls = tg.incrCalls[:0]
 	tg.countCalls = tg.countCalls[:0]
 	tg.timingCalls = tg.timingCalls[:0]
+	tg.distCalls = tg.distCalls[:0]
 	tg.counts = make(map[string]int64)
 	tg.tags = tg.tags[:0]
 	tg.n = 0